@@ -0,0 +1,5 @@
+data "openwrt_system_leds" "leds" {}
+
+output "leds" {
+  value = data.openwrt_system_leds.leds.names
+}
@@ -0,0 +1,17 @@
+# Keep the stratum reported by ntpd, for the data source to read it
+resource "openwrt_file" "ntp_stratum_hotplug" {
+  path    = "/etc/hotplug.d/ntp/90-stratum"
+  content = <<-EOT
+  [ "$ACTION" = stratum ] && [ -n "$stratum" ] && echo "$stratum" > /var/state/ntpd-stratum
+  EOT
+}
+
+data "openwrt_system_ntp" "ntp" {}
+
+output "ntp_running" {
+  value = data.openwrt_system_ntp.ntp.running
+}
+
+output "ntp_synchronized" {
+  value = data.openwrt_system_ntp.ntp.synchronized
+}
@@ -0,0 +1,5 @@
+# Set the POSIX.1 time zone string without looking it up in LuCI
+resource "openwrt_system" "system" {
+  zonename = "Europe/London"
+  timezone = provider::openwrt::posix_tz("Europe/London")
+}
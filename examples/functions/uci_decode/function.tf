@@ -0,0 +1,10 @@
+# Turn an existing configuration file into sections, to be managed with
+# the `sections` attribute of `openwrt_configfile`
+locals {
+  network = provider::openwrt::uci_decode(file("${path.module}/network"))
+}
+
+resource "openwrt_configfile" "network" {
+  name     = "network"
+  sections = [for section in local.network : section if section.type != "route"]
+}
@@ -5,6 +5,53 @@ provider "openwrt" {
   api_timeouts = {
     auth = "20s"
   }
+  # roll the changes back if the router is not reachable after applying them
+  confirmed_apply = {
+    rollback_timeout = "90s"
+  }
+}
+
+# Authenticate as a limited rpcd login, e.g. an openwrt_rpcd_login
+provider "openwrt" {
+  alias     = "dns"
+  user      = "terraform"
+  password  = "changeme"
+  remote    = "http://192.168.8.1:8080"
+  transport = "ubus"
+}
+
+# Manage several routers with the same credentials, selected by the device
+# attribute of the resources
+provider "openwrt" {
+  alias    = "fleet"
+  user     = "root"
+  password = "admin"
+  devices = {
+    for name, ip in { "branch-1" = "10.0.1.1", "branch-2" = "10.0.2.1" } :
+    name => { remote = "http://${ip}:80" }
+  }
+}
+
+resource "openwrt_cron_job" "reboot" {
+  provider = openwrt.fleet
+  for_each = toset(["branch-1", "branch-2"])
+  device   = each.key
+  name     = "reboot"
+  schedule = "0 5 * * sun"
+  command  = "reboot"
+}
+
+# Reach a router behind NAT through an SSH bastion
+provider "openwrt" {
+  alias    = "branch"
+  user     = "root"
+  password = "admin"
+  remote   = "http://192.168.1.1:80"
+  bastion = {
+    host        = "bastion.example.com:22"
+    user        = "jump"
+    private_key = file("~/.ssh/id_ed25519")
+  }
 }
 
 resource "openwrt_opkg" "wanted_packages" {
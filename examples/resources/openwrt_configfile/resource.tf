@@ -18,3 +18,27 @@ resource "openwrt_configfile" "system" {
      option urandom_seed '0'
   EOT
 }
+
+# The same settings written as sections, which can be generated with `for`
+# expressions
+resource "openwrt_configfile" "system_sections" {
+  name = "system"
+  sections = [
+    {
+      type    = "timeserver"
+      name    = "ntp"
+      options = { enabled = "1", enable_server = "0" }
+      lists   = { server = [for i in range(4) : "${i}.openwrt.pool.ntp.org"] }
+    },
+    {
+      type = "system"
+      options = {
+        hostname     = "OpenWrt"
+        timezone     = "UTC"
+        ttylogin     = "0"
+        log_size     = "64"
+        urandom_seed = "0"
+      }
+    },
+  ]
+}
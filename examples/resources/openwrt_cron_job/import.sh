@@ -0,0 +1,5 @@
+# Import a job of the crontab of root by its name
+terraform import openwrt_cron_job.logrotate logrotate
+
+# Import a job of the crontab of another user
+terraform import openwrt_cron_job.backup backup,backup
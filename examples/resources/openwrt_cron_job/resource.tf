@@ -0,0 +1,14 @@
+# Rotate the logs every night
+resource "openwrt_cron_job" "logrotate" {
+  name     = "logrotate"
+  schedule = "30 3 * * *"
+  command  = "/usr/sbin/logrotate /etc/logrotate.conf"
+}
+
+# Back up the configuration on weekdays as the backup user
+resource "openwrt_cron_job" "backup" {
+  name     = "backup"
+  user     = "backup"
+  schedule = "0 */6 * * mon-fri"
+  command  = "sysupgrade -b /tmp/backup.tar.gz"
+}
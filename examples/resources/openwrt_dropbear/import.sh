@@ -0,0 +1,5 @@
+# Import a dropbear section by its name
+terraform import openwrt_dropbear.wan wan
+
+# Import the default anonymous instance
+terraform import openwrt_dropbear.lan '@dropbear[0]'
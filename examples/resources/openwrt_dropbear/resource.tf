@@ -0,0 +1,16 @@
+# Key-only SSH access from the WAN on a separate port
+resource "openwrt_dropbear" "wan" {
+  name               = "wan"
+  port               = 2222
+  interface          = "wan"
+  password_auth      = false
+  root_password_auth = false
+}
+
+# The default instance is anonymous, it is managed by its position once
+# imported
+resource "openwrt_dropbear" "lan" {
+  name               = "@dropbear[0]"
+  password_auth      = true
+  root_password_auth = false
+}
@@ -0,0 +1,24 @@
+# Generate the private key of a WireGuard interface once, and remove it
+# along with the resource
+resource "openwrt_exec" "wg_key" {
+  command         = "umask 077 && wg genkey > /etc/wireguard/wg0.key"
+  destroy_command = "rm -f /etc/wireguard/wg0.key"
+}
+
+# Reload the wireless configuration whenever it changes
+resource "openwrt_exec" "wifi_reload" {
+  command = "wifi reload"
+  triggers = {
+    config = sha256(file("${path.module}/wireless"))
+  }
+}
+
+# Tolerate a failing command, and read its outcome
+resource "openwrt_exec" "forwarding" {
+  command          = "sysctl -w net.ipv4.ip_forward=1"
+  ignore_exit_code = true
+}
+
+output "forwarding_error" {
+  value = openwrt_exec.forwarding.exit_code == 0 ? "" : openwrt_exec.forwarding.stderr
+}
@@ -0,0 +1,2 @@
+# Import an existing file by its absolute path
+terraform import openwrt_file.etc_test_txt /etc/test.txt
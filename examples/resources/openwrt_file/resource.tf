@@ -5,3 +5,27 @@ resource "openwrt_file" "etc_test_txt" {
 this is a test
 EOT
 }
+
+resource "openwrt_file" "hotplug_script" {
+  path    = "/etc/hotplug.d/iface"
+  name    = "99-notify"
+  mode    = "0755"
+  owner   = "root"
+  group   = "root"
+  content = <<-EOT
+#!/bin/sh
+logger -t hotplug "$ACTION $INTERFACE"
+EOT
+}
+
+resource "openwrt_file" "ca_der" {
+  path           = "/etc/ssl/certs"
+  name           = "ca.der"
+  content_base64 = filebase64("${path.module}/ca.der")
+}
+
+resource "openwrt_file" "kernel_module" {
+  path   = "/lib/modules/custom"
+  name   = "driver.ko"
+  source = "${path.module}/build/driver.ko"
+}
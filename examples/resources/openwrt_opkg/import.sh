@@ -0,0 +1,2 @@
+# Import already installed packages as a comma-separated list
+terraform import openwrt_opkg.packages curl,luci
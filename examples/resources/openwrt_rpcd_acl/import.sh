@@ -0,0 +1,2 @@
+# Import an ACL group by its name, i.e. the name of its file without .json
+terraform import openwrt_rpcd_acl.dns terraform-dns
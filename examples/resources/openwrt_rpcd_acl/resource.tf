@@ -0,0 +1,19 @@
+# Let a login manage the DHCP and DNS configuration only
+resource "openwrt_rpcd_acl" "dns" {
+  name        = "terraform-dns"
+  description = "DHCP and DNS configuration"
+  read = {
+    ubus = {
+      uci     = ["get"]
+      session = ["access"]
+    }
+    uci = ["dhcp"]
+  }
+  write = {
+    ubus = {
+      uci = ["add", "set", "delete", "commit", "revert"]
+      rc  = ["init"]
+    }
+    uci = ["dhcp"]
+  }
+}
@@ -0,0 +1,2 @@
+# Import a login by its username
+terraform import openwrt_rpcd_login.terraform terraform
@@ -0,0 +1,15 @@
+# Limited login of the ubus transport, its hash being e.g. the output of
+# openssl passwd -6
+resource "openwrt_rpcd_login" "terraform" {
+  username      = "terraform"
+  password_hash = var.terraform_password_hash
+  read          = [openwrt_rpcd_acl.dns.name]
+  write         = [openwrt_rpcd_acl.dns.name]
+}
+
+# Login using the password of a system account
+resource "openwrt_rpcd_login" "backup" {
+  username      = "backup"
+  password_hash = "$p$backup"
+  read          = ["luci-app-backup"]
+}
@@ -0,0 +1,2 @@
+# Import an existing service by its init script name
+terraform import openwrt_service.dnsmasq dnsmasq
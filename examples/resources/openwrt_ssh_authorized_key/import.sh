@@ -0,0 +1,5 @@
+# Import a key of /etc/dropbear/authorized_keys by its fingerprint
+terraform import openwrt_ssh_authorized_key.backup SHA256:OBSE8s8Ndmx/QJuwK1ExTti7uprQ3MrV4UtY5XR0ne8
+
+# Import a key of another file
+terraform import openwrt_ssh_authorized_key.backup /root/.ssh/authorized_keys,SHA256:OBSE8s8Ndmx/QJuwK1ExTti7uprQ3MrV4UtY5XR0ne8
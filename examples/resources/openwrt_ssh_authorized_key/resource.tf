@@ -0,0 +1,10 @@
+# Let the admin log in as root
+resource "openwrt_ssh_authorized_key" "admin" {
+  key = file("~/.ssh/id_ed25519.pub")
+}
+
+# Restrict the backup key to the backup command
+resource "openwrt_ssh_authorized_key" "backup" {
+  key     = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBcOWhxMjxP+TGWVDCumbaiB0hipfWek6vauovPtyl1P backup@example.com"
+  options = "no-port-forwarding,no-pty,command=\"/usr/bin/backup\""
+}
@@ -0,0 +1,2 @@
+# Import an existing led section by its name
+terraform import openwrt_system_led.wan led_wan
@@ -0,0 +1,18 @@
+# Blink the WAN LED on the traffic of the wan device
+resource "openwrt_system_led" "wan" {
+  name        = "led_wan"
+  description = "WAN"
+  sysfs       = "green:wan"
+  trigger     = "netdev"
+  dev         = "wan"
+  mode        = "link tx rx"
+}
+
+# Signal the VPN with a slow blink
+resource "openwrt_system_led" "vpn" {
+  name     = "led_vpn"
+  sysfs    = "blue:status"
+  trigger  = "timer"
+  delayon  = 500
+  delayoff = 1500
+}
@@ -0,0 +1,2 @@
+# The ntp section is unique, any id can be used.
+terraform import openwrt_system_ntp.ntp ntp
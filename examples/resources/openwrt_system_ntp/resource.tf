@@ -0,0 +1,9 @@
+resource "openwrt_system_ntp" "ntp" {
+  enabled       = true
+  enable_server = true
+  interface     = "lan"
+  server = [
+    "0.openwrt.pool.ntp.org",
+    "1.openwrt.pool.ntp.org",
+  ]
+}
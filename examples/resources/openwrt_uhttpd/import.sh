@@ -0,0 +1,2 @@
+# Import the default instance by its name
+terraform import openwrt_uhttpd.main main
@@ -0,0 +1,10 @@
+# HTTPS only LuCI on the LAN, with the certificate of openwrt_uhttpd_certificate
+resource "openwrt_uhttpd" "main" {
+  name           = "main"
+  listen_http    = ["192.168.1.1:80"]
+  listen_https   = ["192.168.1.1:443"]
+  redirect_https = true
+  cert           = openwrt_uhttpd_certificate.main.cert_path
+  key            = openwrt_uhttpd_certificate.main.key_path
+  rfc1918_filter = true
+}
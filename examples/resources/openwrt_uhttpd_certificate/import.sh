@@ -0,0 +1,2 @@
+# Import the certificate and key of the default instance, by their paths
+terraform import openwrt_uhttpd_certificate.main /etc/uhttpd.crt,/etc/uhttpd.key
@@ -0,0 +1,5 @@
+# Certificate issued for the router, e.g. by an internal CA
+resource "openwrt_uhttpd_certificate" "main" {
+  certificate = file("${path.module}/router.lan.crt")
+  private_key = file("${path.module}/router.lan.key")
+}
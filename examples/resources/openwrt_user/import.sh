@@ -0,0 +1,2 @@
+# Import an existing account by its name
+terraform import openwrt_user.backup backup
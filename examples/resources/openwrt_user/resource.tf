@@ -0,0 +1,8 @@
+# Service account for the backup jobs
+resource "openwrt_user" "backup" {
+  name        = "backup"
+  uid         = 1000
+  description = "Backups"
+  home        = "/srv/backup"
+  shell       = "/bin/ash"
+}
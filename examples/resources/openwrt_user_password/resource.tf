@@ -0,0 +1,11 @@
+variable "root_password" {
+  type      = string
+  sensitive = true
+}
+
+# Rotate the root password, bump password_version to set a new one
+resource "openwrt_user_password" "root" {
+  username         = "root"
+  password         = var.root_password
+  password_version = "2024-01"
+}
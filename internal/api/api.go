@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
@@ -31,8 +32,10 @@ type Timeouts interface {
 	OpkgTimeouts
 	ServiceTimeouts
 	SystemTimeouts
+	UserTimeouts
 
 	Auth() time.Duration
+	Reconnect() time.Duration
 }
 
 type Client interface {
@@ -40,21 +43,27 @@ type Client interface {
 	OpkgFacade
 	ServiceFacade
 	SystemFacade
+	UserFacade
 
 	Auth(ctx context.Context, username, password string) error
-}
-
-type WithSession interface {
-	SetToken(ctx context.Context, token string) error
+	UseUbusTransport()
+	UseProxy(proxyUrl string) error
+	UseBastion(ctx context.Context, bastion Bastion) error
+	EnableConfirmedApply(rollbackTimeout time.Duration)
+	ConfirmedApply() bool
+	TransactionTo(ctx context.Context, config, remoteUrl string, stage func(ctx context.Context) error) error
+	RestartWebServer(ctx context.Context) error
 }
 
 type TimeoutsModel struct {
-	Auth types.String `tfsdk:"auth"`
+	Auth      types.String `tfsdk:"auth"`
+	Reconnect types.String `tfsdk:"reconnect"`
 
 	Fs      *FsTimeoutsModel      `tfsdk:"fs"`
 	Opkg    *OpkgTimeoutsModel    `tfsdk:"opkg"`
 	Service *ServiceTimeoutsModel `tfsdk:"service"`
 	System  *SystemTimeoutsModel  `tfsdk:"uci"`
+	User    *UserTimeoutsModel    `tfsdk:"user"`
 }
 
 type timeouts struct {
@@ -62,15 +71,24 @@ type timeouts struct {
 	OpkgTimeouts
 	ServiceTimeouts
 	SystemTimeouts
+	UserTimeouts
 
-	authTimeout time.Duration
+	authTimeout,
+	reconnectTimeout time.Duration
 }
 
 func (t *timeouts) Auth() time.Duration {
 	return t.authTimeout
 }
 
-const defaultAuthTimeout = 5 * time.Second
+func (t *timeouts) Reconnect() time.Duration {
+	return t.reconnectTimeout
+}
+
+const (
+	defaultAuthTimeout      = 5 * time.Second
+	defaultReconnectTimeout = 2 * time.Minute
+)
 
 var (
 	_ ClientFactory = (*clientFactory)(nil)
@@ -87,10 +105,16 @@ var (
 				Description:         `Authentication RPC timeout value`,
 				Optional:            true,
 			},
+			"reconnect": schema.StringAttribute{
+				MarkdownDescription: `Time to wait for the router to answer on its new address, after a change moving it (Default: 2m)`,
+				Description:         `Time to wait for the router to answer on its new address, after a change moving it (Default: 2m)`,
+				Optional:            true,
+			},
 			"fs":      fsTimeoutSchemaAttribute,
 			"opkg":    opkgTimeoutSchemaAttribute,
 			"service": serviceTimeoutSchemaAttribute,
 			"uci":     uciTimeoutSchemaAttribute,
+			"user":    userTimeoutSchemaAttribute,
 		},
 	}
 
@@ -109,6 +133,11 @@ var (
 	ErrFloatExpected    = fmt.Errorf("value not a float64 type")
 	ErrExecutionFailure = fmt.Errorf("execution returned value a failing result")
 	ErrPackageNotFound  = fmt.Errorf("package not found")
+	ErrFileNotFound     = fmt.Errorf("file not found")
+	ErrSectionNotFound  = fmt.Errorf("section not found")
+	ErrUserNotFound     = fmt.Errorf("user not found")
+	ErrRolledBack       = fmt.Errorf("changes rolled back by the router")
+	ErrNoChanges        = fmt.Errorf("no changes to apply")
 
 	ErrPackagesNotSpecified = fmt.Errorf("no packages specified")
 )
@@ -134,6 +163,19 @@ func (cf *clientFactory) ParseTimeouts(ctx context.Context, t *TimeoutsModel) (T
 		tflog.Debug(ctx, "parse timeout configuration: default auth config")
 	}
 
+	reconnectTimeout := defaultReconnectTimeout
+	if t != nil && !t.Reconnect.IsNull() {
+		parsedReconnectTimeout, err := time.ParseDuration(t.Reconnect.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		reconnectTimeout = parsedReconnectTimeout
+		tflog.Debug(ctx, "parse timeout configuration: reconnect config parsed")
+	} else {
+		tflog.Debug(ctx, "parse timeout configuration: default reconnect config")
+	}
+
 	fs, err := parseFsTimeouts(ctx, t)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing fs timeouts: %w", err)
@@ -154,12 +196,19 @@ func (cf *clientFactory) ParseTimeouts(ctx context.Context, t *TimeoutsModel) (T
 		return nil, fmt.Errorf("error parsing system timeouts: %w", err)
 	}
 
+	user, err := parseUserTimeouts(ctx, t)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing user timeouts: %w", err)
+	}
+
 	return &timeouts{
 		fs,
 		opkg,
 		service,
 		system,
+		user,
 		authTimeout,
+		reconnectTimeout,
 	}, nil
 }
 
@@ -172,10 +221,18 @@ type client struct {
 	OpkgFacade
 	ServiceFacade
 	SystemFacade
+	UserFacade
+
+	service *service
+	system  *system
 
-	needToken []WithSession
+	// credentialsMu guards the credentials, which SetPassword changes while
+	// other resources may re-authenticate.
+	credentialsMu      sync.RWMutex
+	username, password string
+	rollbackTimeout    time.Duration
 
-	url      *string
+	url      *remote
 	client   *http.Client
 	timeouts Timeouts
 }
@@ -185,7 +242,7 @@ func newClient(url string, t Timeouts) (Client, error) {
 		return nil, ErrMissingUrl
 	}
 	httpClient := &http.Client{}
-	remoteUrl := &url
+	remoteUrl := &remote{url: url}
 
 	fs := &fs{
 		timeouts: t,
@@ -211,25 +268,57 @@ func newClient(url string, t Timeouts) (Client, error) {
 		client:   httpClient,
 	}
 
+	user := &user{
+		timeouts: t,
+		fs:       fs,
+		url:      remoteUrl,
+		client:   httpClient,
+	}
+
 	client := &client{
 		FsFacade:      fs,
 		OpkgFacade:    opkg,
 		ServiceFacade: service,
 		SystemFacade:  system,
-		needToken: []WithSession{
-			fs, opkg, service, system,
-		},
-		timeouts: t,
-		url:      remoteUrl,
-		client:   httpClient,
+		UserFacade:    user,
+		service:       service,
+		system:        system,
+		timeouts:      t,
+		url:           remoteUrl,
+		client:        httpClient,
 	}
 
 	return client, nil
 }
 
 func (c *client) Auth(ctx context.Context, username, password string) error {
+	remoteUrl := c.url.String()
+	token, err := c.login(ctx, remoteUrl, username, password)
+	if err != nil {
+		return err
+	}
+	c.credentialsMu.Lock()
+	c.username, c.password = username, password
+	c.credentialsMu.Unlock()
+
+	c.setToken(remoteUrl, token)
+	return nil
+}
+
+// setToken moves all the facades to the session of token, opened at
+// remoteUrl.
+func (c *client) setToken(remoteUrl, token string) {
+	c.url.setSession(remoteUrl, token)
+}
+
+// login opens a new session on the router at remoteUrl, returning its token.
+func (c *client) login(ctx context.Context, remoteUrl, username, password string) (string, error) {
+	if c.url.viaUbus() {
+		return loginUbus(ctx, c.client, c.timeouts.Auth(), remoteUrl, username, password)
+	}
+
 	tflog.Debug(ctx, "authentication", map[string]interface{}{
-		"url":      c.url,
+		"url":      remoteUrl,
 		"username": username,
 	})
 	innerCtx, cancel := context.WithTimeout(ctx, c.timeouts.Auth())
@@ -244,25 +333,25 @@ func (c *client) Auth(ctx context.Context, username, password string) error {
 		Params: []string{username, password},
 	})
 	if err != nil {
-		return errors.Join(ErrMarshal, err)
+		return "", errors.Join(ErrMarshal, err)
 	}
-	u, err := url.JoinPath(*c.url, "cgi-bin/luci/rpc/auth")
+	u, err := url.JoinPath(remoteUrl, "cgi-bin/luci/rpc/auth")
 	if err != nil {
-		return errors.Join(ErrParsing, err)
+		return "", errors.Join(ErrParsing, err)
 	}
 
 	req, err := http.NewRequestWithContext(innerCtx, http.MethodPost, u, bytes.NewBuffer(b))
 	if err != nil {
-		return errors.Join(ErrHttpRequestCreation, err)
+		return "", errors.Join(ErrHttpRequestCreation, err)
 	}
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return errors.Join(ErrHttpRequestExecution, err)
+		return "", errors.Join(ErrHttpRequestExecution, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.Join(ErrHttpRequestExecution, fmt.Errorf("authentication request %+v replied with %d", req, resp.StatusCode))
+		return "", errors.Join(ErrHttpRequestExecution, fmt.Errorf("authentication request %+v replied with %d", req, resp.StatusCode))
 	}
 
 	defer resp.Body.Close() //nolint:errcheck
@@ -272,31 +361,71 @@ func (c *client) Auth(ctx context.Context, username, password string) error {
 		Error  string `json:"error"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return errors.Join(ErrUnMarshal, fmt.Errorf("failed to read authentication response body: %w", err))
+		return "", errors.Join(ErrUnMarshal, fmt.Errorf("failed to read authentication response body: %w", err))
 	}
 
 	if data.Error != "" {
-		return errors.Join(ErrAuth, fmt.Errorf("authentication error: %s", data.Error))
+		return "", errors.Join(ErrAuth, fmt.Errorf("authentication error: %s", data.Error))
 	}
 
 	if data.Result == "" {
-		return ErrEmptyResult
+		return "", ErrEmptyResult
 	}
 
 	tflog.Debug(ctx, "authentication performed", map[string]interface{}{
-		"url":      c.url,
+		"url":      remoteUrl,
 		"usernema": username,
 	})
 
-	token := data.Result
-	for _, v := range c.needToken {
-		err = v.SetToken(ctx, token)
-		if err != nil {
-			return fmt.Errorf("error on setting token for fs: %w", err)
-		}
-	}
+	return data.Result, nil
+}
 
-	return nil
+// remote is the address of the router, and the token of the session opened
+// on it. It is shared by all the facades, so that both can be swapped at once
+// when the management address moves or the session is opened again. It also
+// tells which transport the facades go through.
+type remote struct {
+	mu    sync.RWMutex
+	url   string
+	token string
+	ubus  bool
+}
+
+// session is the address and the token a call is made with, read together.
+type session struct {
+	url, token string
+}
+
+func (r *remote) session() session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return session{url: r.url, token: r.token}
+}
+
+func (r *remote) setSession(url, token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.url, r.token = url, token
+}
+
+func (r *remote) String() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.url
+}
+
+func (r *remote) set(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.url = url
+}
+
+// viaUbus reports whether the calls go through the ubus objects of rpcd
+// rather than through the LuCI RPC.
+func (r *remote) viaUbus() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ubus
 }
 
 type jsonRPCRequestBody struct {
@@ -322,9 +451,10 @@ type jsonRPCResponseBody struct {
 
 func call(
 	ctx context.Context, client *http.Client, timeout time.Duration,
-	remoteUrl, token, rpc, method string, params []any,
+	s session, rpc, method string, params []any,
 ) (json.RawMessage, error) {
-	innerCtx, cancel := context.WithTimeout(ctx, timeout)
+	remoteUrl, token := s.url, s.token
+	innerCtx, cancel := callContext(ctx, timeout)
 	defer cancel()
 	if remoteUrl == "" {
 		return nil, ErrMissingUrl
@@ -400,6 +530,128 @@ func call(
 	return *responseBody.Result, nil
 }
 
+type ubusRequestBody struct {
+	JsonRPC string `json:"jsonrpc"`
+	Id      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type ubusResponseBody struct {
+	Error  *jsonRPCResponseError `json:"error"`
+	Result []json.RawMessage     `json:"result"`
+}
+
+var _ error = (*ubusStatusError)(nil)
+
+type ubusStatusError struct {
+	Code int
+}
+
+func (u *ubusStatusError) Error() string {
+	switch u.Code {
+	case ubusStatusNotFound:
+		return "ubus call in error: not found"
+	case ubusStatusPermissionDenied:
+		return "ubus call in error: permission denied, check the rpcd ACL of the user"
+	}
+	return fmt.Sprintf("ubus call in error: status %d", u.Code)
+}
+
+const (
+	// ubusStatusNotFound is the UBUS_STATUS_NOT_FOUND status code
+	ubusStatusNotFound = 4
+	// ubusStatusNoData is the UBUS_STATUS_NO_DATA status code
+	ubusStatusNoData = 5
+	// ubusStatusPermissionDenied is the UBUS_STATUS_PERMISSION_DENIED status code
+	ubusStatusPermissionDenied = 6
+)
+
+// callUbus calls an ubus method through the /ubus endpoint of uhttpd, using
+// the session token. It is used for the methods that the LuCI RPC doesn't
+// expose, and for all of them with the ubus transport.
+func callUbus(
+	ctx context.Context, client *http.Client, timeout time.Duration,
+	s session, object, method string, args any,
+) (json.RawMessage, error) {
+	remoteUrl, token := s.url, s.token
+	innerCtx, cancel := callContext(ctx, timeout)
+	defer cancel()
+	if remoteUrl == "" {
+		return nil, ErrMissingUrl
+	}
+	if token == "" {
+		return nil, errors.Join(ErrAuth, fmt.Errorf("no auth is performed against %s", remoteUrl))
+	}
+	u, err := url.Parse(remoteUrl)
+	if err != nil {
+		return nil, errors.Join(ErrParsing, err)
+	}
+	u.Path = "ubus"
+
+	requestBody, err := json.Marshal(&ubusRequestBody{
+		JsonRPC: "2.0",
+		Id:      1,
+		Method:  "call",
+		Params:  []any{token, object, method, args},
+	})
+	if err != nil {
+		return nil, errors.Join(ErrMarshal, err)
+	}
+	req, err := http.NewRequestWithContext(innerCtx, http.MethodPost, u.String(), bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, errors.Join(ErrHttpRequestCreation, err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	tflog.Debug(ctx, "start - ubus call to remote", map[string]interface{}{
+		"host":   req.URL.Host,
+		"object": object,
+		"method": method,
+	})
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Join(ErrHttpRequestExecution, err)
+	}
+
+	tflog.Debug(ctx, "end - ubus call to remote", map[string]interface{}{
+		"host":   req.URL.Host,
+		"object": object,
+		"method": method,
+		"response": map[string]interface{}{
+			"statusCode": resp.StatusCode,
+		},
+	})
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(ErrHttpRequestExecution, fmt.Errorf("request %s %s replied with %d", object, method, resp.StatusCode))
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var responseBody ubusResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	if responseBody.Error != nil {
+		return nil, errors.Join(ErrRpcExecution, responseBody.Error)
+	}
+	if len(responseBody.Result) == 0 {
+		return nil, ErrEmptyResult
+	}
+
+	var status int
+	if err = json.Unmarshal(responseBody.Result[0], &status); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	if status != 0 {
+		return nil, errors.Join(ErrRpcExecution, &ubusStatusError{Code: status})
+	}
+
+	if len(responseBody.Result) < 2 {
+		return nil, nil
+	}
+	return responseBody.Result[1], nil
+}
+
 // Purge the sections from the anonymous things
 func purgeFields(d any) (any, error) {
 	b, err := json.Marshal(d)
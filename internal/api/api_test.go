@@ -0,0 +1,80 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSetTokenParallel swaps the session between two routers while calls
+// are made, each router only accepting the tokens of its own sessions.
+func TestSetTokenParallel(t *testing.T) {
+	var mismatches atomic.Int32
+	router := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req ubusRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if token, _ := req.Params[0].(string); !strings.HasPrefix(token, name) {
+				mismatches.Add(1)
+			}
+			json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+				"jsonrpc": "2.0", "id": req.Id,
+				"result": []any{0, map[string]any{"uhttpd": map[string]any{}}},
+			})
+		}))
+	}
+	lan, wan := router("lan"), router("wan")
+	defer lan.Close()
+	defer wan.Close()
+
+	serviceTimeouts, err := parseServiceTimeouts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, lan.URL)
+	c.timeouts.(*timeouts).ServiceTimeouts = serviceTimeouts
+	c.UseUbusTransport()
+	c.setToken(lan.URL, "lan0")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			if i%2 == 0 {
+				c.setToken(wan.URL, fmt.Sprintf("wan%d", i))
+			} else {
+				c.setToken(lan.URL, fmt.Sprintf("lan%d", i))
+			}
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 25 {
+				if _, err := c.IsRunning(context.Background(), webServerService); err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := mismatches.Load(); n > 0 {
+		t.Errorf("%d calls were made with the token of the other router", n)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const reconnectInterval = 2 * time.Second
+
+// EnableConfirmedApply makes CommitOrRevert apply the changes the way LuCI
+// does, with a rollback armed on the router for rollbackTimeout.
+func (c *client) EnableConfirmedApply(rollbackTimeout time.Duration) {
+	c.rollbackTimeout = rollbackTimeout
+}
+
+func (c *client) ConfirmedApply() bool {
+	return c.rollbackTimeout > 0
+}
+
+func (c *client) CommitOrRevert(ctx context.Context, section ...any) error {
+	return c.commitTo(ctx, "", section...)
+}
+
+// commitTo commits the configurations, the management address of the router
+// moving to remoteUrl once they are applied. An empty remoteUrl keeps the
+// current one.
+func (c *client) commitTo(ctx context.Context, remoteUrl string, section ...any) error {
+	if remoteUrl == "" {
+		remoteUrl = c.url.String()
+	}
+
+	if c.ConfirmedApply() {
+		return c.confirmedApply(ctx, remoteUrl, section...)
+	}
+
+	if err := c.SystemFacade.CommitOrRevert(ctx, section...); err != nil {
+		return err
+	}
+	if remoteUrl == c.url.String() {
+		return nil
+	}
+
+	token, err := c.reconnect(ctx, remoteUrl, time.Now().Add(c.timeouts.Reconnect()))
+	if err != nil {
+		return fmt.Errorf("the router was not reachable at %s after committing config %q: %w", remoteUrl, section, err)
+	}
+	c.setToken(remoteUrl, token)
+	return nil
+}
+
+// Transaction commits through CommitOrRevert, to apply the changes the way
+// it is configured. uci apply covers all the configurations staged in the
+// session, so with confirmed apply, the transactions are queued together
+// whatever their configuration.
+func (c *client) Transaction(ctx context.Context, config string, stage func(ctx context.Context) error) error {
+	return c.TransactionTo(ctx, config, "", stage)
+}
+
+// TransactionTo runs a transaction whose changes move the management address
+// of the router to remoteUrl, such as the address of the LAN. The client is
+// moved to remoteUrl by the commit of the batch holding the transaction,
+// before the other transactions are run.
+func (c *client) TransactionTo(ctx context.Context, config, remoteUrl string, stage func(ctx context.Context) error) error {
+	key := config
+	if c.ConfirmedApply() {
+		key = allConfigs
+	}
+	return c.system.transactions.run(ctx, key, config, remoteUrl, stage, c.commitTo, c.Revert)
+}
+
+// confirmedApply applies the staged changes with a rollback timer. They are
+// confirmed only once a new session can be opened at remoteUrl, proving that
+// the router is still reachable after the change. Otherwise nothing is sent,
+// and the router restores the previous configuration by itself when the
+// timer expires. Re-authentication is attempted during two thirds of the
+// rollback timeout, to leave time for the confirmation.
+func (c *client) confirmedApply(ctx context.Context, remoteUrl string, section ...any) error {
+	err := c.Apply(ctx, true, c.rollbackTimeout)
+	if errors.Is(err, ErrNoChanges) {
+		return nil
+	}
+	if err != nil {
+		toReturn := []error{fmt.Errorf("failed to apply config %q: %w", section, err)}
+		if err := c.Revert(ctx, section...); err != nil {
+			toReturn = append(toReturn, fmt.Errorf("failed to revert config %q: %w", section, err))
+		}
+		return errors.Join(toReturn...)
+	}
+
+	token, err := c.reconnect(ctx, remoteUrl, time.Now().Add(c.rollbackTimeout*2/3))
+	if err != nil {
+		return errors.Join(ErrRolledBack, fmt.Errorf(
+			"the router was not reachable at %s after applying config %q, it restores the previous configuration within %s: %w",
+			remoteUrl, section, c.rollbackTimeout, err))
+	}
+
+	// the rollback belongs to the session that applied the changes, which
+	// is still valid at the new address
+	c.url.set(remoteUrl)
+	if err := c.Confirm(ctx); err != nil {
+		return errors.Join(ErrRolledBack, fmt.Errorf(
+			"failed to confirm config %q, the router restores the previous configuration within %s: %w",
+			section, c.rollbackTimeout, err))
+	}
+	c.setToken(remoteUrl, token)
+	return nil
+}
+
+// reconnect polls the router at remoteUrl until a new session can be opened,
+// or the deadline is reached. On success, the token of the new session is
+// returned, the facades being left on the current one.
+func (c *client) reconnect(ctx context.Context, remoteUrl string, deadline time.Time) (string, error) {
+	for {
+		username, password := c.credentials()
+		token, err := c.login(ctx, remoteUrl, username, password)
+		if err == nil {
+			return token, nil
+		}
+		tflog.Debug(ctx, "re-authentication failed", map[string]interface{}{
+			"url":   remoteUrl,
+			"error": err.Error(),
+		})
+
+		if time.Now().After(deadline) {
+			return "", err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	datasourceschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Devices gives the clients of the routers of the provider configuration,
+// by device name. The router of the remote attribute of the provider is the
+// default device, named "".
+type Devices interface {
+	// Device returns the client of the device, connected on first use.
+	Device(ctx context.Context, name string) (Client, error)
+	// HasDevice tells whether the device is in the provider configuration.
+	HasDevice(name string) bool
+}
+
+const deviceDescription = "The name of the device of the `devices` of the provider the resource is on, " +
+	"prefixed to the import id with a colon, e.g. `branch:<id>`. (Default: the `remote` of the provider)"
+
+var (
+	ErrUnknownDevice = fmt.Errorf("unknown device")
+
+	DeviceSchemaAttribute = schema.StringAttribute{
+		MarkdownDescription: deviceDescription,
+		Description:         deviceDescription,
+		Optional:            true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+
+	DeviceDataSourceSchemaAttribute = datasourceschema.StringAttribute{
+		MarkdownDescription: "The name of the device of the `devices` of the provider to read. (Default: the `remote` of the provider)",
+		Description:         "The name of the device of the devices of the provider to read. (Default: the remote of the provider)",
+		Optional:            true,
+	}
+)
+
+// DevicesOf returns the devices of the provider data, which are either the
+// ones of the provider configuration, or a single client as the default
+// device.
+func DevicesOf(providerData any) (Devices, bool) {
+	switch data := providerData.(type) {
+	case Devices:
+		return data, true
+	case Client:
+		return singleDevice{data}, true
+	}
+	return nil, false
+}
+
+type singleDevice struct {
+	client Client
+}
+
+func (s singleDevice) Device(_ context.Context, name string) (Client, error) {
+	if name != "" {
+		return nil, fmt.Errorf("%w %q", ErrUnknownDevice, name)
+	}
+	return s.client, nil
+}
+
+func (s singleDevice) HasDevice(name string) bool {
+	return name == ""
+}
+
+// DeviceClient returns the client of the device selected by the device
+// attribute of a resource. The resources keep it in the copy of themselves
+// each operation runs on, their methods having value receivers.
+func DeviceClient(ctx context.Context, devices Devices, device types.String) (Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	client, err := devices.Device(ctx, device.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("device"), "Failed to connect to the device", err.Error())
+	}
+	return client, diags
+}
+
+// ImportDevice splits the id of an import into the device and the id of the
+// resource on it, as in <device>:<id>. The prefix is only taken as a device
+// if it is one of the provider configuration, the id being on the default
+// device otherwise. It returns the client of the device.
+func ImportDevice(ctx context.Context, devices Devices, importId string) (types.String, string, Client, diag.Diagnostics) {
+	device := types.StringNull()
+	if name, id, found := strings.Cut(importId, ":"); found && name != "" && devices.HasDevice(name) {
+		device, importId = types.StringValue(name), id
+	}
+
+	client, diags := DeviceClient(ctx, devices, device)
+	return device, importId, client, diags
+}
+
+// DeviceId returns the computed id of a resource on the device, prefixed with
+// the device name when it is set, so that the id imports the resource again.
+func DeviceId(device types.String, id string) string {
+	if device.ValueString() == "" {
+		return id
+	}
+	return device.ValueString() + ":" + id
+}
+
+// LocalId returns the id on the device of a computed id of DeviceId.
+func LocalId(device types.String, id string) string {
+	if device.ValueString() == "" {
+		return id
+	}
+	return strings.TrimPrefix(id, device.ValueString()+":")
+}
@@ -4,10 +4,18 @@
 package api
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
@@ -19,28 +27,63 @@ const (
 	defaultWriteFileTimeout  time.Duration = 30 * time.Second
 	defaultReadFileTimeout                 = 30 * time.Second
 	defaultRemoveFileTimeout               = 30 * time.Second
+	defaultStatFileTimeout                 = 30 * time.Second
+	defaultChmodFileTimeout                = 30 * time.Second
+	defaultChownFileTimeout                = 30 * time.Second
+	defaultRenameFileTimeout               = 30 * time.Second
+	defaultCopyFileTimeout                 = 30 * time.Second
+	defaultListDirTimeout                  = 30 * time.Second
+
+	etcPasswd = "/etc/passwd"
+	etcGroup  = "/etc/group"
+
+	tmpSuffix = ".tf-tmp"
+	// defaultFileMode is the mode of the files created by rpcd, which runs
+	// as root with a umask of 022.
+	defaultFileMode = "0644"
 )
 
 type FsTimeouts interface {
 	WriteFile() time.Duration
 	ReadFile() time.Duration
 	RemoveFile() time.Duration
+	StatFile() time.Duration
+	ChmodFile() time.Duration
+	ChownFile() time.Duration
+	RenameFile() time.Duration
+	CopyFile() time.Duration
+	ListDir() time.Duration
 }
 
 type FsTimeoutsModel struct {
 	WriteFileTimeout  types.String `tfsdk:"write_file"`
 	ReadFileTimeout   types.String `tfsdk:"read_file"`
 	RemoveFileTimeout types.String `tfsdk:"remove_file"`
+	StatFileTimeout   types.String `tfsdk:"stat_file"`
+	ChmodFileTimeout  types.String `tfsdk:"chmod_file"`
+	ChownFileTimeout  types.String `tfsdk:"chown_file"`
+	RenameFileTimeout types.String `tfsdk:"rename_file"`
+	CopyFileTimeout   types.String `tfsdk:"copy_file"`
+	ListDirTimeout    types.String `tfsdk:"list_dir"`
 }
 
 type FsFacade interface {
 	Writefile(ctx context.Context, path string, data []byte) error
+	WritefileMode(ctx context.Context, path string, data []byte, mode string) error
 	ReadFile(ctx context.Context, path string) ([]byte, error)
 	RemoveFile(ctx context.Context, path string) error
+	Stat(ctx context.Context, path string) (*FileInfo, error)
+	Chmod(ctx context.Context, path, mode string) error
+	Chown(ctx context.Context, path, owner, group string) error
+	Rename(ctx context.Context, src, dst string) error
+	Copy(ctx context.Context, src, dst string) error
+	ListDir(ctx context.Context, path string) ([]DirEntry, error)
 }
 
 type fsTimeouts struct {
-	writeFileTimeout, readFileTimeout, removeFileTimeout time.Duration
+	writeFileTimeout, readFileTimeout, removeFileTimeout,
+	statFileTimeout, chmodFileTimeout, chownFileTimeout,
+	renameFileTimeout, copyFileTimeout, listDirTimeout time.Duration
 }
 
 func (fsT *fsTimeouts) WriteFile() time.Duration {
@@ -55,10 +98,33 @@ func (fsT *fsTimeouts) RemoveFile() time.Duration {
 	return fsT.removeFileTimeout
 }
 
+func (fsT *fsTimeouts) StatFile() time.Duration {
+	return fsT.statFileTimeout
+}
+
+func (fsT *fsTimeouts) ChmodFile() time.Duration {
+	return fsT.chmodFileTimeout
+}
+
+func (fsT *fsTimeouts) ChownFile() time.Duration {
+	return fsT.chownFileTimeout
+}
+
+func (fsT *fsTimeouts) RenameFile() time.Duration {
+	return fsT.renameFileTimeout
+}
+
+func (fsT *fsTimeouts) CopyFile() time.Duration {
+	return fsT.copyFileTimeout
+}
+
+func (fsT *fsTimeouts) ListDir() time.Duration {
+	return fsT.listDirTimeout
+}
+
 var (
-	_ FsFacade    = (*fs)(nil)
-	_ WithSession = (*fs)(nil)
-	_ FsTimeouts  = (*fsTimeouts)(nil)
+	_ FsFacade   = (*fs)(nil)
+	_ FsTimeouts = (*fsTimeouts)(nil)
 
 	fsTimeoutSchemaAttribute = schema.SingleNestedAttribute{
 		MarkdownDescription: `Filesystem operations timeout configuration`,
@@ -80,6 +146,36 @@ var (
 				Description:         `Remove file RPC timeout value`,
 				Optional:            true,
 			},
+			"stat_file": schema.StringAttribute{
+				MarkdownDescription: `Stat file RPC timeout value`,
+				Description:         `Stat file RPC timeout value`,
+				Optional:            true,
+			},
+			"chmod_file": schema.StringAttribute{
+				MarkdownDescription: `Change file mode RPC timeout value`,
+				Description:         `Change file mode RPC timeout value`,
+				Optional:            true,
+			},
+			"chown_file": schema.StringAttribute{
+				MarkdownDescription: `Change file owner RPC timeout value`,
+				Description:         `Change file owner RPC timeout value`,
+				Optional:            true,
+			},
+			"rename_file": schema.StringAttribute{
+				MarkdownDescription: `Rename file RPC timeout value`,
+				Description:         `Rename file RPC timeout value`,
+				Optional:            true,
+			},
+			"copy_file": schema.StringAttribute{
+				MarkdownDescription: `Copy file RPC timeout value`,
+				Description:         `Copy file RPC timeout value`,
+				Optional:            true,
+			},
+			"list_dir": schema.StringAttribute{
+				MarkdownDescription: `List directory RPC timeout value`,
+				Description:         `List directory RPC timeout value`,
+				Optional:            true,
+			},
 		},
 	}
 )
@@ -88,6 +184,12 @@ func parseFsTimeouts(ctx context.Context, t *TimeoutsModel) (FsTimeouts, error)
 	readFileTimeout := defaultReadFileTimeout
 	removeFileTimeout := defaultRemoveFileTimeout
 	writeFileTimeout := defaultWriteFileTimeout
+	statFileTimeout := defaultStatFileTimeout
+	chmodFileTimeout := defaultChmodFileTimeout
+	chownFileTimeout := defaultChownFileTimeout
+	renameFileTimeout := defaultRenameFileTimeout
+	copyFileTimeout := defaultCopyFileTimeout
+	listDirTimeout := defaultListDirTimeout
 
 	if t != nil && t.Fs != nil && !t.Fs.ReadFileTimeout.IsNull() {
 		parsedReadFileTimeout, err := time.ParseDuration(t.Fs.ReadFileTimeout.ValueString())
@@ -125,10 +227,88 @@ func parseFsTimeouts(ctx context.Context, t *TimeoutsModel) (FsTimeouts, error)
 		tflog.Debug(ctx, "fs - parse timeout configuration: default write_file config")
 	}
 
+	if t != nil && t.Fs != nil && !t.Fs.StatFileTimeout.IsNull() {
+		parsedStatFileTimeout, err := time.ParseDuration(t.Fs.StatFileTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		statFileTimeout = parsedStatFileTimeout
+		tflog.Debug(ctx, "fs - parse timeout configuration: stat_file config parsed")
+	} else {
+		tflog.Debug(ctx, "fs - parse timeout configuration: default stat_file config")
+	}
+
+	if t != nil && t.Fs != nil && !t.Fs.ChmodFileTimeout.IsNull() {
+		parsedChmodFileTimeout, err := time.ParseDuration(t.Fs.ChmodFileTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		chmodFileTimeout = parsedChmodFileTimeout
+		tflog.Debug(ctx, "fs - parse timeout configuration: chmod_file config parsed")
+	} else {
+		tflog.Debug(ctx, "fs - parse timeout configuration: default chmod_file config")
+	}
+
+	if t != nil && t.Fs != nil && !t.Fs.ChownFileTimeout.IsNull() {
+		parsedChownFileTimeout, err := time.ParseDuration(t.Fs.ChownFileTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		chownFileTimeout = parsedChownFileTimeout
+		tflog.Debug(ctx, "fs - parse timeout configuration: chown_file config parsed")
+	} else {
+		tflog.Debug(ctx, "fs - parse timeout configuration: default chown_file config")
+	}
+
+	if t != nil && t.Fs != nil && !t.Fs.RenameFileTimeout.IsNull() {
+		parsedRenameFileTimeout, err := time.ParseDuration(t.Fs.RenameFileTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		renameFileTimeout = parsedRenameFileTimeout
+		tflog.Debug(ctx, "fs - parse timeout configuration: rename_file config parsed")
+	} else {
+		tflog.Debug(ctx, "fs - parse timeout configuration: default rename_file config")
+	}
+
+	if t != nil && t.Fs != nil && !t.Fs.CopyFileTimeout.IsNull() {
+		parsedCopyFileTimeout, err := time.ParseDuration(t.Fs.CopyFileTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		copyFileTimeout = parsedCopyFileTimeout
+		tflog.Debug(ctx, "fs - parse timeout configuration: copy_file config parsed")
+	} else {
+		tflog.Debug(ctx, "fs - parse timeout configuration: default copy_file config")
+	}
+
+	if t != nil && t.Fs != nil && !t.Fs.ListDirTimeout.IsNull() {
+		parsedListDirTimeout, err := time.ParseDuration(t.Fs.ListDirTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		listDirTimeout = parsedListDirTimeout
+		tflog.Debug(ctx, "fs - parse timeout configuration: list_dir config parsed")
+	} else {
+		tflog.Debug(ctx, "fs - parse timeout configuration: default list_dir config")
+	}
+
 	toReturn := &fsTimeouts{
 		writeFileTimeout,
 		readFileTimeout,
 		removeFileTimeout,
+		statFileTimeout,
+		chmodFileTimeout,
+		chownFileTimeout,
+		renameFileTimeout,
+		copyFileTimeout,
+		listDirTimeout,
 	}
 
 	tflog.Debug(ctx, "fs - timeout configuration parsed", map[string]interface{}{
@@ -141,25 +321,105 @@ func parseFsTimeouts(ctx context.Context, t *TimeoutsModel) (FsTimeouts, error)
 type fs struct {
 	timeouts FsTimeouts
 
-	token  string
-	url    *string
+	url    *remote
 	client *http.Client
 }
 
-func (c *fs) SetToken(ctx context.Context, token string) error {
-	c.token = token
+// Writefile atomically replaces the remote file: data is written to a
+// temporary file in the same directory, which is then renamed over the
+// target. Mode and ownership of an already existing file are preserved.
+func (c *fs) Writefile(ctx context.Context, filePath string, data []byte) error {
+	return c.WritefileMode(ctx, filePath, data, "")
+}
+
+// WritefileMode replaces the remote file like Writefile, with the given mode
+// instead of the one of the existing file, unless it is empty. The temporary
+// file is given its mode before data is written to it, so that a private
+// file is never readable by the other users.
+func (c *fs) WritefileMode(ctx context.Context, filePath string, data []byte, mode string) error {
+	info, err := c.stat(ctx, filePath)
+	if err != nil && !errors.Is(err, ErrFileNotFound) {
+		return err
+	}
+	if mode == "" && info != nil {
+		mode = info.Mode
+	}
+
+	dir, name := path.Split(filePath)
+	tmpPath := path.Join(dir, "."+name+tmpSuffix)
+
+	if mode != "" && mode != defaultFileMode {
+		// writing keeps the mode of an existing file
+		if err := c.write(ctx, tmpPath, []byte{}); err != nil {
+			return err
+		}
+		if err := c.Chmod(ctx, tmpPath, mode); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %w", tmpPath, err)
+		}
+	}
+	if err := c.write(ctx, tmpPath, data); err != nil {
+		return err
+	}
+
+	if err = c.replaceWith(ctx, filePath, tmpPath, info); err != nil {
+		if removeErr := c.RemoveFile(ctx, tmpPath); removeErr != nil {
+			tflog.Warn(ctx, "fs - unable to remove temporary file", map[string]interface{}{
+				"path":  tmpPath,
+				"error": removeErr.Error(),
+			})
+		}
+		return err
+	}
+
 	return nil
 }
 
-func (c *fs) Writefile(ctx context.Context, path string, data []byte) error {
+// write writes data to the remote file, creating it if needed.
+func (c *fs) write(ctx context.Context, filePath string, data []byte) error {
+	if c.url.viaUbus() {
+		_, err := callUbus(ctx, c.client, c.timeouts.WriteFile(),
+			c.url.session(), "file", "write", map[string]any{
+				"path":   filePath,
+				"data":   base64.StdEncoding.EncodeToString(data),
+				"base64": true,
+			})
+		return err
+	}
 	_, err := call(ctx, c.client, c.timeouts.WriteFile(),
-		*c.url, c.token, "fs", "writefile", []any{path, data})
+		c.url.session(), "fs", "writefile", []any{filePath, data})
 	return err
 }
 
+// replaceWith renames the temporary file over the target. The ownership of
+// the target is only copied when it differs from the one of the files rpcd
+// creates, to save the call in the common case.
+func (c *fs) replaceWith(ctx context.Context, filePath, tmpPath string, info *FileInfo) error {
+	if info != nil && (info.Uid != 0 || info.Gid != 0) {
+		if err := c.Chown(ctx, tmpPath, strconv.Itoa(info.Uid), strconv.Itoa(info.Gid)); err != nil {
+			return fmt.Errorf("failed to preserve ownership of %s: %w", filePath, err)
+		}
+	}
+
+	if err := c.Rename(ctx, tmpPath, filePath); err != nil {
+		return fmt.Errorf("failed to move %s in place: %w", tmpPath, err)
+	}
+
+	return nil
+}
+
+// ReadFile returns the content of the remote file. ErrFileNotFound is
+// returned when the remote file does not exist.
 func (c *fs) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	if c.url.viaUbus() {
+		return c.readFileUbus(ctx, path)
+	}
+
 	raw, err := call(ctx, c.client, c.timeouts.ReadFile(),
-		*c.url, c.token, "fs", "readfile", []any{path})
+		c.url.session(), "fs", "readfile", []any{path})
+	if errors.Is(err, ErrEmptyResult) {
+		// readfile replies with a null result when the file can not be opened
+		return nil, c.notFoundOr(ctx, path, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -170,8 +430,344 @@ func (c *fs) ReadFile(ctx context.Context, path string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
 }
 
+func (c *fs) readFileUbus(ctx context.Context, path string) ([]byte, error) {
+	raw, err := callUbus(ctx, c.client, c.timeouts.ReadFile(),
+		c.url.session(), "file", "read", map[string]any{
+			"path":   path,
+			"base64": true,
+		})
+	if isUbusStatus(err, ubusStatusNotFound) {
+		return nil, errors.Join(ErrFileNotFound, fmt.Errorf("%s: %w", path, err))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	return base64.StdEncoding.DecodeString(data.Data)
+}
+
 func (c *fs) RemoveFile(ctx context.Context, path string) error {
+	if c.url.viaUbus() {
+		_, err := callUbus(ctx, c.client, c.timeouts.RemoveFile(),
+			c.url.session(), "file", "remove", map[string]any{"path": path})
+		return err
+	}
+
 	_, err := call(ctx, c.client, c.timeouts.RemoveFile(),
-		*c.url, c.token, "fs", "remove", []any{path})
+		c.url.session(), "fs", "remove", []any{path})
 	return err
 }
+
+// FileInfo holds the ownership and permission details of a remote file.
+// Owner and Group are empty when the ids can not be resolved to names.
+type FileInfo struct {
+	Mode  string
+	Uid   int
+	Gid   int
+	Owner string
+	Group string
+}
+
+// DirEntry is an entry of a remote directory, its type is one of file,
+// directory, symlink, char, block, fifo or socket.
+type DirEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Stat returns the permissions of the remote file. ErrFileNotFound is
+// returned when the remote file does not exist.
+func (c *fs) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	info, err := c.stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner, err := c.lookupName(ctx, etcPasswd, info.Uid); err == nil {
+		info.Owner = owner
+	} else {
+		tflog.Debug(ctx, "fs - unable to resolve file owner", map[string]interface{}{
+			"uid":   info.Uid,
+			"error": err.Error(),
+		})
+	}
+
+	if group, err := c.lookupName(ctx, etcGroup, info.Gid); err == nil {
+		info.Group = group
+	} else {
+		tflog.Debug(ctx, "fs - unable to resolve file group", map[string]interface{}{
+			"gid":   info.Gid,
+			"error": err.Error(),
+		})
+	}
+
+	return info, nil
+}
+
+// stat returns the mode and ids of the remote file, without resolving them.
+func (c *fs) stat(ctx context.Context, path string) (*FileInfo, error) {
+	if c.url.viaUbus() {
+		raw, err := callUbus(ctx, c.client, c.timeouts.StatFile(),
+			c.url.session(), "file", "stat", map[string]any{"path": path})
+		if isUbusStatus(err, ubusStatusNotFound) {
+			return nil, errors.Join(ErrFileNotFound, fmt.Errorf("%s: %w", path, err))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var data struct {
+			Mode int `json:"mode"`
+			Uid  int `json:"uid"`
+			Gid  int `json:"gid"`
+		}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, errors.Join(ErrUnMarshal, err)
+		}
+		return &FileInfo{
+			// mode is the st_mode of the file, with its type bits
+			Mode: fmt.Sprintf("%04o", data.Mode&07777),
+			Uid:  data.Uid,
+			Gid:  data.Gid,
+		}, nil
+	}
+
+	raw, err := call(ctx, c.client, c.timeouts.StatFile(),
+		c.url.session(), "fs", "stat", []any{path})
+	if errors.Is(err, ErrEmptyResult) {
+		return nil, errors.Join(ErrFileNotFound, fmt.Errorf("%s: %w", path, err))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		ModeDec int `json:"modedec"`
+		Uid     int `json:"uid"`
+		Gid     int `json:"gid"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+
+	return &FileInfo{
+		// modedec carries the octal permission digits as a decimal number (e.g. 644)
+		Mode: fmt.Sprintf("%04d", data.ModeDec),
+		Uid:  data.Uid,
+		Gid:  data.Gid,
+	}, nil
+}
+
+func (c *fs) Chmod(ctx context.Context, path, mode string) error {
+	if c.url.viaUbus() {
+		_, err := execUbus(ctx, c.client, c.timeouts.ChmodFile(),
+			c.url.session(), "/bin/chmod", mode, path)
+		return err
+	}
+
+	_, err := call(ctx, c.client, c.timeouts.ChmodFile(),
+		c.url.session(), "fs", "chmod", []any{path, mode})
+	return err
+}
+
+func (c *fs) Chown(ctx context.Context, path, owner, group string) error {
+	if c.url.viaUbus() {
+		if owner == "" && group == "" {
+			return nil
+		}
+		_, err := execUbus(ctx, c.client, c.timeouts.ChownFile(),
+			c.url.session(), "/bin/chown", owner+":"+group, path)
+		return err
+	}
+
+	params := []any{path, nil, nil}
+	if owner != "" {
+		params[1] = owner
+	}
+	if group != "" {
+		params[2] = group
+	}
+
+	_, err := call(ctx, c.client, c.timeouts.ChownFile(),
+		c.url.session(), "fs", "chown", params)
+	return err
+}
+
+// notFoundOr tells a missing file apart from an unreadable one, checking
+// through stat whether the path exists.
+func (c *fs) notFoundOr(ctx context.Context, path string, readErr error) error {
+	_, err := c.Stat(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("file %s exists but can not be read: %w", path, readErr)
+}
+
+func (c *fs) Rename(ctx context.Context, src, dst string) error {
+	if c.url.viaUbus() {
+		_, err := execUbus(ctx, c.client, c.timeouts.RenameFile(),
+			c.url.session(), "/bin/mv", "-f", src, dst)
+		return err
+	}
+
+	_, err := call(ctx, c.client, c.timeouts.RenameFile(),
+		c.url.session(), "fs", "rename", []any{src, dst})
+	return err
+}
+
+func (c *fs) Copy(ctx context.Context, src, dst string) error {
+	if c.url.viaUbus() {
+		_, err := execUbus(ctx, c.client, c.timeouts.CopyFile(),
+			c.url.session(), "/bin/cp", "-f", src, dst)
+		return err
+	}
+
+	_, err := call(ctx, c.client, c.timeouts.CopyFile(),
+		c.url.session(), "fs", "copy", []any{src, dst})
+	return err
+}
+
+// ListDir returns the entries of the remote directory, through the file
+// object of rpcd. The LuCI RPC having no listing, the entries are listed by
+// the shell through sys.exec there, the types being reduced to file,
+// directory and symlink.
+func (c *fs) ListDir(ctx context.Context, path string) ([]DirEntry, error) {
+	if !c.url.viaUbus() {
+		return c.listDirLuci(ctx, path)
+	}
+
+	raw, err := callUbus(ctx, c.client, c.timeouts.ListDir(),
+		c.url.session(), "file", "list", map[string]any{"path": path})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Entries []DirEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	return data.Entries, nil
+}
+
+// listDirScript prints a dot once in the directory, then the type and the
+// name of each of its entries, one per line.
+const listDirScript = `cd %s 2>/dev/null || exit 0
+echo .
+for f in * .[!.]* ..?*; do
+	if [ -L "$f" ]; then echo "symlink $f"
+	elif [ -d "$f" ]; then echo "directory $f"
+	elif [ -e "$f" ]; then echo "file $f"
+	fi
+done`
+
+func (c *fs) listDirLuci(ctx context.Context, path string) ([]DirEntry, error) {
+	raw, err := call(ctx, c.client, c.timeouts.ListDir(),
+		c.url.session(), "sys", "exec", []any{fmt.Sprintf(listDirScript, shellQuote(path))})
+	if err != nil {
+		return nil, err
+	}
+	var output string
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	return parseDirListing(path, output)
+}
+
+// parseDirListing returns the entries printed by listDirScript.
+func parseDirListing(path, output string) ([]DirEntry, error) {
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if lines[0] != "." {
+		return nil, errors.Join(ErrFileNotFound, fmt.Errorf("%s is not a directory", path))
+	}
+	entries := []DirEntry{}
+	for _, line := range lines[1:] {
+		if entryType, name, ok := strings.Cut(line, " "); ok {
+			entries = append(entries, DirEntry{Name: name, Type: entryType})
+		}
+	}
+	return entries, nil
+}
+
+// shellQuote quotes s as a single word of the shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fileLocks holds the mutexes of LockFile, by client and path.
+var fileLocks sync.Map
+
+type fileLock struct {
+	fs   FsFacade
+	path string
+}
+
+// LockFile serializes the read-modify-write cycles of a remote file edited by
+// several resources, which would otherwise lose each other's changes when
+// applied in parallel. Each device having its own client, the files of the
+// other devices are edited meanwhile. It returns the unlock function.
+func LockFile(fs FsFacade, path string) func() {
+	mu, _ := fileLocks.LoadOrStore(fileLock{fs, path}, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// ReadLines returns the lines of a remote file edited line by line, and
+// whether it exists: a missing file has no lines.
+func ReadLines(ctx context.Context, fs FsFacade, path string) ([]string, bool, error) {
+	content, err := fs.ReadFile(ctx, path)
+	if errors.Is(err, ErrFileNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return nil, true, nil
+	}
+	return strings.Split(text, "\n"), true, nil
+}
+
+// WriteLines writes back the lines of a file read by ReadLines. The file is
+// created with mode when it did not exist, an existing one keeps its own.
+func WriteLines(ctx context.Context, fs FsFacade, path string, lines []string, exists bool, mode string) error {
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if exists {
+		return fs.Writefile(ctx, path, []byte(content))
+	}
+	return fs.WritefileMode(ctx, path, []byte(content), mode)
+}
+
+// lookupName resolves an id to its name through an /etc/passwd-like database
+func (c *fs) lookupName(ctx context.Context, database string, id int) (string, error) {
+	b, err := c.ReadFile(ctx, database)
+	if err != nil {
+		return "", err
+	}
+
+	wanted := strconv.Itoa(id)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) > 2 && fields[2] == wanted {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("id %d not found in %s", id, database)
+}
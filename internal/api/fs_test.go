@@ -0,0 +1,200 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingUbus records the file calls made through the ubus endpoint. The
+// files have mode, or are missing when it is zero.
+type recordingUbus struct {
+	mu    sync.Mutex
+	mode  int
+	calls []string
+}
+
+func (r *recordingUbus) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Id     int `json:"id"`
+		Params []json.RawMessage
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var object, method string
+	json.Unmarshal(body.Params[1], &object) //nolint:errcheck
+	json.Unmarshal(body.Params[2], &method) //nolint:errcheck
+	var args struct {
+		Path    string   `json:"path"`
+		Data    string   `json:"data"`
+		Command string   `json:"command"`
+		Params  []string `json:"params"`
+	}
+	json.Unmarshal(body.Params[3], &args) //nolint:errcheck
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reply := func(result ...any) {
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": body.Id, "result": result}) //nolint:errcheck
+	}
+	switch object + "." + method {
+	case "file.stat":
+		if r.mode == 0 {
+			reply(ubusStatusNotFound)
+			return
+		}
+		reply(0, map[string]any{"mode": 0100000 | r.mode, "uid": 0, "gid": 0})
+	case "file.read":
+		r.calls = append(r.calls, "read "+args.Path)
+		reply(ubusStatusNotFound)
+	case "file.write":
+		data, _ := base64.StdEncoding.DecodeString(args.Data)
+		r.calls = append(r.calls, fmt.Sprintf("write %s %q", args.Path, data))
+		reply(0)
+	case "file.exec":
+		r.calls = append(r.calls, strings.Join(append([]string{args.Command}, args.Params...), " "))
+		reply(0, map[string]any{"code": 0})
+	default:
+		reply(ubusStatusNotFound)
+	}
+}
+
+func TestWritefileMode(t *testing.T) {
+	fsTimeouts, err := parseFsTimeouts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for mode, expected := range map[string][]string{
+		"0600": {
+			`write /etc/.uhttpd.key.tf-tmp ""`,
+			"/bin/chmod 0600 /etc/.uhttpd.key.tf-tmp",
+			`write /etc/.uhttpd.key.tf-tmp "secret"`,
+			"/bin/mv -f /etc/.uhttpd.key.tf-tmp /etc/uhttpd.key",
+		},
+		defaultFileMode: {
+			`write /etc/.uhttpd.key.tf-tmp "secret"`,
+			"/bin/mv -f /etc/.uhttpd.key.tf-tmp /etc/uhttpd.key",
+		},
+	} {
+		router := &recordingUbus{}
+		server := httptest.NewServer(router)
+		c := &fs{timeouts: fsTimeouts, url: &remote{url: server.URL, token: "session", ubus: true}, client: server.Client()}
+
+		if err := c.WritefileMode(context.Background(), "/etc/uhttpd.key", []byte("secret"), mode); err != nil {
+			t.Errorf("%s: unexpected error: %v", mode, err)
+		}
+		// the mode is set before any data is written
+		if !slices.Equal(router.calls, expected) {
+			t.Errorf("%s: expected the calls %q, got %q", mode, expected, router.calls)
+		}
+		server.Close()
+	}
+}
+
+func TestWritefileKeepsMode(t *testing.T) {
+	fsTimeouts, err := parseFsTimeouts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := &recordingUbus{mode: 0600}
+	server := httptest.NewServer(router)
+	defer server.Close()
+	c := &fs{timeouts: fsTimeouts, url: &remote{url: server.URL, token: "session", ubus: true}, client: server.Client()}
+
+	if err := c.Writefile(context.Background(), "/etc/shadow", []byte("root:x")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the owner is kept by its ids, without reading the account databases
+	expected := []string{
+		`write /etc/.shadow.tf-tmp ""`,
+		"/bin/chmod 0600 /etc/.shadow.tf-tmp",
+		`write /etc/.shadow.tf-tmp "root:x"`,
+		"/bin/mv -f /etc/.shadow.tf-tmp /etc/shadow",
+	}
+	if !slices.Equal(router.calls, expected) {
+		t.Errorf("expected the calls %q, got %q", expected, router.calls)
+	}
+}
+
+func TestListDirScript(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"green:lan", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "it's a dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("green:lan", filepath.Join(dir, "red:wan")); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(path string) ([]DirEntry, error) {
+		output, err := exec.Command("/bin/sh", "-c", fmt.Sprintf(listDirScript, shellQuote(path))).Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return parseDirListing(path, string(output))
+	}
+
+	entries, err := list(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.SortFunc(entries, func(a, b DirEntry) int { return strings.Compare(a.Name, b.Name) })
+	expected := []DirEntry{
+		{Name: ".hidden", Type: "file"},
+		{Name: "green:lan", Type: "file"},
+		{Name: "it's a dir", Type: "directory"},
+		{Name: "red:wan", Type: "symlink"},
+	}
+	if !slices.Equal(entries, expected) {
+		t.Errorf("expected %v, got %v", expected, entries)
+	}
+
+	if _, err := list(filepath.Join(dir, "missing")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected a missing directory to be reported, got %v", err)
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	router, branch := &fs{}, &fs{}
+
+	unlock := LockFile(router, "/etc/crontabs/root")
+	// the same file of another device, and another file of the device, are
+	// not locked
+	LockFile(branch, "/etc/crontabs/root")()
+	LockFile(router, "/etc/dropbear/authorized_keys")()
+
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		LockFile(router, "/etc/crontabs/root")()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("expected the file to stay locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+}
@@ -0,0 +1,39 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+type operationTimeoutKey struct{}
+
+// WithOperationTimeout returns a context bounded by the timeout of a resource
+// operation, as set in the timeouts block of the resource, e.g.
+// plan.Timeouts.Create. The calls made with it are only bounded by this
+// timeout, instead of their per-call timeouts of the provider configuration.
+// Without a timeout set, the context is returned as is.
+func WithOperationTimeout(
+	ctx context.Context, timeout func(context.Context, time.Duration) (time.Duration, diag.Diagnostics),
+) (context.Context, context.CancelFunc, diag.Diagnostics) {
+	d, diags := timeout(ctx, 0)
+	if diags.HasError() || d <= 0 {
+		return ctx, func() {}, diags
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return context.WithValue(ctx, operationTimeoutKey{}, d), cancel, diags
+}
+
+// callContext returns the context of a call, bounded by timeout unless it is
+// part of an operation with its own timeout.
+func callContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Value(operationTimeoutKey{}).(time.Duration); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
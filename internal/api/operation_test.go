@@ -0,0 +1,48 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func operationTimeout(d time.Duration) func(context.Context, time.Duration) (time.Duration, diag.Diagnostics) {
+	return func(_ context.Context, def time.Duration) (time.Duration, diag.Diagnostics) {
+		if d == 0 {
+			return def, nil
+		}
+		return d, nil
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	ctx, cancel, diags := WithOperationTimeout(context.Background(), operationTimeout(0))
+	defer cancel()
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("expected no deadline without an operation timeout")
+	}
+	callCtx, callCancel := callContext(ctx, time.Second)
+	defer callCancel()
+	if deadline, ok := callCtx.Deadline(); !ok || time.Until(deadline) > time.Second {
+		t.Errorf("expected the call to be bounded by its own timeout, got %v", deadline)
+	}
+
+	ctx, cancel, diags = WithOperationTimeout(context.Background(), operationTimeout(time.Hour))
+	defer cancel()
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	callCtx, callCancel = callContext(ctx, time.Second)
+	defer callCancel()
+	if deadline, ok := callCtx.Deadline(); !ok || time.Until(deadline) < time.Minute {
+		t.Errorf("expected the call to be bounded by the operation timeout, got %v", deadline)
+	}
+}
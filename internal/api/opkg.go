@@ -4,11 +4,13 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
@@ -69,7 +71,6 @@ func (opkgT *opkgTimeouts) RemovePackages() time.Duration {
 
 var (
 	_ OpkgFacade   = (*opkg)(nil)
-	_ WithSession  = (*opkg)(nil)
 	_ OpkgTimeouts = (*opkgTimeouts)(nil)
 
 	opkgTimeoutSchemaAttribute = schema.SingleNestedAttribute{
@@ -166,8 +167,7 @@ func parseOpkgTimeouts(ctx context.Context, t *TimeoutsModel) (OpkgTimeouts, err
 type opkg struct {
 	timeouts OpkgTimeouts
 
-	token  string
-	url    *string
+	url    *remote
 	client *http.Client
 }
 
@@ -182,14 +182,15 @@ type Status struct {
 	// Install   bool `json:"install"`
 }
 
-func (c *opkg) SetToken(ctx context.Context, token string) error {
-	c.token = token
-	return nil
-}
-
 func (c *opkg) UpdatePackages(ctx context.Context) error {
+	if c.url.viaUbus() {
+		_, err := execUbus(ctx, c.client, c.timeouts.UpdatePackages(),
+			c.url.session(), opkgCommand, "update")
+		return err
+	}
+
 	result, err := call(ctx, c.client, c.timeouts.UpdatePackages(),
-		*c.url, c.token,
+		c.url.session(),
 		"ipkg", "update", []any{})
 	if err != nil {
 		return err
@@ -212,9 +213,48 @@ func (c *opkg) UpdatePackages(ctx context.Context) error {
 	return nil
 }
 
+// opkgCommand is run through the file object of rpcd with the ubus transport.
+const opkgCommand = "/bin/opkg"
+
+// parseOpkgStatus reads the package out of the output of opkg status. The
+// package is reported as not installed when opkg knows nothing about it.
+func parseOpkgStatus(output, pack string) *PackageInfo {
+	info := &PackageInfo{}
+	found := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Package":
+			found = value == pack
+		case "Version":
+			if found {
+				info.Version = value
+			}
+		case "Status":
+			if found {
+				info.Status.Installed = strings.HasSuffix(value, " installed")
+			}
+		}
+	}
+	return info
+}
+
 func (c *opkg) CheckPackage(ctx context.Context, pack string) (*PackageInfo, error) {
+	if c.url.viaUbus() {
+		output, err := execUbus(ctx, c.client, c.timeouts.CheckPackage(),
+			c.url.session(), opkgCommand, "status", pack)
+		if err != nil {
+			return nil, err
+		}
+		return parseOpkgStatus(output, pack), nil
+	}
+
 	result, err := call(ctx, c.client, c.timeouts.CheckPackage(),
-		*c.url, c.token,
+		c.url.session(),
 		"ipkg", "status", []any{pack})
 	if err != nil {
 		return nil, err
@@ -247,12 +287,18 @@ func (c *opkg) InstallPackages(ctx context.Context, packages ...string) error {
 		return ErrPackagesNotSpecified
 	}
 
+	if c.url.viaUbus() {
+		_, err := execUbus(ctx, c.client, c.timeouts.InstallPackages(),
+			c.url.session(), opkgCommand, append([]string{"install"}, packages...)...)
+		return err
+	}
+
 	toApi := make([]any, 0, packagesLen)
 	for _, aPackage := range packages {
 		toApi = append(toApi, aPackage)
 	}
 	result, err := call(ctx, c.client, c.timeouts.InstallPackages(),
-		*c.url, c.token, "ipkg", "install", toApi)
+		c.url.session(), "ipkg", "install", toApi)
 	if err != nil {
 		return err
 	}
@@ -280,12 +326,18 @@ func (c *opkg) RemovePackages(ctx context.Context, packages ...string) error {
 		return ErrPackageNotFound
 	}
 
+	if c.url.viaUbus() {
+		_, err := execUbus(ctx, c.client, c.timeouts.RemovePackages(),
+			c.url.session(), opkgCommand, append([]string{"remove"}, packages...)...)
+		return err
+	}
+
 	toApi := make([]any, 0, packagesLen)
 	for _, aPackage := range packages {
 		toApi = append(toApi, aPackage)
 	}
 	result, err := call(ctx, c.client, c.timeouts.RemovePackages(),
-		*c.url, c.token, "ipkg", "remove", toApi)
+		c.url.session(), "ipkg", "remove", toApi)
 	if err != nil {
 		return err
 	}
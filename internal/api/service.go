@@ -5,9 +5,15 @@ package api
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
@@ -23,6 +29,8 @@ const (
 	defaultStartServiceTimeout                 = 30 * time.Second
 	defaultStopSeviceTimeout                   = 30 * time.Second
 	defaultRestartServiceTimeout               = 30 * time.Second
+	defaultIsRunningTimeout                    = 30 * time.Second
+	defaultExecTimeout                         = 60 * time.Second
 )
 
 type ServiceTimeouts interface {
@@ -33,6 +41,8 @@ type ServiceTimeouts interface {
 	StartService() time.Duration
 	StopSevice() time.Duration
 	RestartService() time.Duration
+	IsRunning() time.Duration
+	Exec() time.Duration
 }
 
 type ServiceTimeoutsModel struct {
@@ -43,6 +53,8 @@ type ServiceTimeoutsModel struct {
 	StartServiceTimeout   types.String `tfsdk:"start_service"`
 	StopSeviceTimeout     types.String `tfsdk:"stop_sevice"`
 	RestartServiceTimeout types.String `tfsdk:"restart_service"`
+	IsRunningTimeout      types.String `tfsdk:"is_running"`
+	ExecTimeout           types.String `tfsdk:"exec"`
 }
 
 type ServiceFacade interface {
@@ -53,6 +65,8 @@ type ServiceFacade interface {
 	StartService(ctx context.Context, serviceName string) error
 	StopSevice(ctx context.Context, serviceName string) error
 	RestartService(ctx context.Context, serviceName string) error
+	IsRunning(ctx context.Context, serviceName string) (bool, error)
+	Exec(ctx context.Context, command string) (*ExecResult, error)
 }
 
 type serviceTimeouts struct {
@@ -62,7 +76,9 @@ type serviceTimeouts struct {
 	enableServiceTimeout,
 	startServiceTimeout,
 	stopSeviceTimeout,
-	restartServiceTimeout time.Duration
+	restartServiceTimeout,
+	isRunningTimeout,
+	execTimeout time.Duration
 }
 
 func (sT *serviceTimeouts) ListServices() time.Duration {
@@ -93,9 +109,16 @@ func (sT *serviceTimeouts) RestartService() time.Duration {
 	return sT.restartServiceTimeout
 }
 
+func (sT *serviceTimeouts) IsRunning() time.Duration {
+	return sT.isRunningTimeout
+}
+
+func (sT *serviceTimeouts) Exec() time.Duration {
+	return sT.execTimeout
+}
+
 var (
 	_ ServiceFacade   = (*service)(nil)
-	_ WithSession     = (*service)(nil)
 	_ ServiceTimeouts = (*serviceTimeouts)(nil)
 
 	serviceTimeoutSchemaAttribute = schema.SingleNestedAttribute{
@@ -138,6 +161,16 @@ var (
 				Description:         `Restart service RPC timeout value`,
 				Optional:            true,
 			},
+			"is_running": schema.StringAttribute{
+				MarkdownDescription: `Is running service RPC timeout value`,
+				Description:         `Is running service RPC timeout value`,
+				Optional:            true,
+			},
+			"exec": schema.StringAttribute{
+				MarkdownDescription: `Command execution RPC timeout value`,
+				Description:         `Command execution RPC timeout value`,
+				Optional:            true,
+			},
 		},
 	}
 )
@@ -150,6 +183,8 @@ func parseServiceTimeouts(ctx context.Context, t *TimeoutsModel) (ServiceTimeout
 	startServiceTimeout := defaultStartServiceTimeout
 	stopSeviceTimeout := defaultStopSeviceTimeout
 	restartServiceTimeout := defaultRestartServiceTimeout
+	isRunningTimeout := defaultIsRunningTimeout
+	execTimeout := defaultExecTimeout
 
 	if t != nil && t.Service != nil && !t.Service.ListServicesTimeout.IsNull() {
 		parsedListServicesTimeout, err := time.ParseDuration(t.Service.ListServicesTimeout.ValueString())
@@ -235,6 +270,30 @@ func parseServiceTimeouts(ctx context.Context, t *TimeoutsModel) (ServiceTimeout
 		tflog.Debug(ctx, "service - parse timeout configuration: default restart_service config")
 	}
 
+	if t != nil && t.Service != nil && !t.Service.IsRunningTimeout.IsNull() {
+		parsedIsRunningTimeout, err := time.ParseDuration(t.Service.IsRunningTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		isRunningTimeout = parsedIsRunningTimeout
+		tflog.Debug(ctx, "service - parse timeout configuration: is_running config parsed")
+	} else {
+		tflog.Debug(ctx, "service - parse timeout configuration: default is_running config")
+	}
+
+	if t != nil && t.Service != nil && !t.Service.ExecTimeout.IsNull() {
+		parsedExecTimeout, err := time.ParseDuration(t.Service.ExecTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		execTimeout = parsedExecTimeout
+		tflog.Debug(ctx, "service - parse timeout configuration: exec config parsed")
+	} else {
+		tflog.Debug(ctx, "service - parse timeout configuration: default exec config")
+	}
+
 	return &serviceTimeouts{
 		listServicesTimeout,
 		isEnabledTimeout,
@@ -243,14 +302,15 @@ func parseServiceTimeouts(ctx context.Context, t *TimeoutsModel) (ServiceTimeout
 		startServiceTimeout,
 		stopSeviceTimeout,
 		restartServiceTimeout,
+		isRunningTimeout,
+		execTimeout,
 	}, nil
 }
 
 type service struct {
 	timeouts ServiceTimeouts
 
-	token  string
-	url    *string
+	url    *remote
 	client *http.Client
 }
 
@@ -259,14 +319,58 @@ type ServiceInfo struct {
 	Status  Status
 }
 
-func (s *service) SetToken(ctx context.Context, token string) error {
-	s.token = token
-	return nil
+// rcList returns the init scripts known to the rc object of rpcd, with
+// their enabled state.
+func (s *service) rcList(ctx context.Context, timeout time.Duration) (map[string]bool, error) {
+	result, err := callUbus(ctx, s.client, timeout,
+		s.url.session(), "rc", "list", map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err = json.Unmarshal(result, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+
+	enabled := make(map[string]bool, len(data))
+	for name, script := range data {
+		enabled[name] = script.Enabled
+	}
+	return enabled, nil
+}
+
+// rcInit runs the action of the init script through the rc object of rpcd.
+func (s *service) rcInit(ctx context.Context, timeout time.Duration, serviceName, action string) error {
+	_, err := callUbus(ctx, s.client, timeout,
+		s.url.session(), "rc", "init", map[string]any{
+			"name":   serviceName,
+			"action": action,
+		})
+	if isUbusStatus(err, ubusStatusNotFound) {
+		return errors.Join(ErrExecutionFailure, err)
+	}
+	return err
 }
 
 func (s *service) ListServices(ctx context.Context) ([]string, error) {
+	if s.url.viaUbus() {
+		scripts, err := s.rcList(ctx, s.timeouts.ListServices())
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(scripts))
+		for name := range scripts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
 	result, err := call(ctx, s.client, s.timeouts.ListServices(),
-		*s.url, s.token,
+		s.url.session(),
 		"sys", "init.names", []any{})
 	if err != nil {
 		return nil, err
@@ -280,8 +384,16 @@ func (s *service) ListServices(ctx context.Context) ([]string, error) {
 }
 
 func (s *service) IsEnabled(ctx context.Context, serviceName string) (bool, error) {
+	if s.url.viaUbus() {
+		scripts, err := s.rcList(ctx, s.timeouts.IsEnabled())
+		if err != nil {
+			return false, err
+		}
+		return scripts[serviceName], nil
+	}
+
 	result, err := call(ctx, s.client, s.timeouts.IsEnabled(),
-		*s.url, s.token,
+		s.url.session(),
 		"sys", "init.enabled", []any{serviceName})
 	if err != nil {
 		return false, err
@@ -295,8 +407,12 @@ func (s *service) IsEnabled(ctx context.Context, serviceName string) (bool, erro
 }
 
 func (s *service) DisableService(ctx context.Context, serviceName string) error {
+	if s.url.viaUbus() {
+		return s.rcInit(ctx, s.timeouts.DisableService(), serviceName, "disable")
+	}
+
 	result, err := call(ctx, s.client, s.timeouts.DisableService(),
-		*s.url, s.token,
+		s.url.session(),
 		"sys", "init.disable", []any{serviceName})
 	if err != nil {
 		return err
@@ -314,8 +430,12 @@ func (s *service) DisableService(ctx context.Context, serviceName string) error
 }
 
 func (s *service) EnableService(ctx context.Context, serviceName string) error {
+	if s.url.viaUbus() {
+		return s.rcInit(ctx, s.timeouts.EnableService(), serviceName, "enable")
+	}
+
 	result, err := call(ctx, s.client, s.timeouts.EnableService(),
-		*s.url, s.token,
+		s.url.session(),
 		"sys", "init.enable", []any{serviceName})
 	if err != nil {
 		return err
@@ -333,8 +453,12 @@ func (s *service) EnableService(ctx context.Context, serviceName string) error {
 }
 
 func (s *service) StartService(ctx context.Context, serviceName string) error {
+	if s.url.viaUbus() {
+		return s.rcInit(ctx, s.timeouts.StartService(), serviceName, "start")
+	}
+
 	result, err := call(ctx, s.client, s.timeouts.StartService(),
-		*s.url, s.token,
+		s.url.session(),
 		"sys", "init.start", []any{serviceName})
 	if err != nil {
 		return err
@@ -351,8 +475,12 @@ func (s *service) StartService(ctx context.Context, serviceName string) error {
 }
 
 func (s *service) StopSevice(ctx context.Context, serviceName string) error {
+	if s.url.viaUbus() {
+		return s.rcInit(ctx, s.timeouts.StopSevice(), serviceName, "stop")
+	}
+
 	result, err := call(ctx, s.client, s.timeouts.StopSevice(),
-		*s.url, s.token,
+		s.url.session(),
 		"sys", "init.stop", []any{serviceName})
 	if err != nil {
 		return err
@@ -368,6 +496,84 @@ func (s *service) StopSevice(ctx context.Context, serviceName string) error {
 	return nil
 }
 
+// restart restarts the service in a single call, unlike RestartService, for
+// the services the calls go through.
+func (s *service) restart(ctx context.Context, serviceName string) error {
+	if s.url.viaUbus() {
+		return s.rcInit(ctx, s.timeouts.RestartService(), serviceName, "restart")
+	}
+
+	result, err := call(ctx, s.client, s.timeouts.RestartService(),
+		s.url.session(),
+		"sys", "init.restart", []any{serviceName})
+	if err != nil {
+		return err
+	}
+
+	var data bool
+	if err = json.Unmarshal(result, &data); err != nil {
+		return errors.Join(ErrUnMarshal, err)
+	}
+	if !data {
+		return ErrExecutionFailure
+	}
+	return nil
+}
+
+// webServerService is the service serving both LuCI and the ubus endpoint.
+const webServerService = "uhttpd"
+
+// RestartWebServer restarts uhttpd, which serves the calls themselves: the
+// reply may be lost with the connection, so the restarted server is polled
+// for the reconnect timeout. The rpcd session outlives the restart, and is
+// kept along with the changes staged in it, unless it expired meanwhile.
+func (c *client) RestartWebServer(ctx context.Context) error {
+	err := c.service.restart(ctx, webServerService)
+	if err != nil && !errors.Is(err, ErrHttpRequestExecution) {
+		return fmt.Errorf("failed to restart %s: %w", webServerService, err)
+	}
+	tflog.Debug(ctx, "web server restarted, re-dialling", map[string]interface{}{
+		"error": fmt.Sprint(err),
+	})
+
+	// the kept-alive connections are bound to the previous server, and the
+	// restart may still be in progress when the call returns
+	c.client.CloseIdleConnections()
+	remoteUrl := c.url.String()
+	deadline := time.Now().Add(c.timeouts.Reconnect())
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reconnectInterval):
+		}
+
+		running, err := c.service.IsRunning(ctx, webServerService)
+		if err == nil && running {
+			return nil
+		}
+		if isAuthFailure(err) {
+			token, err := c.reconnect(ctx, remoteUrl, deadline)
+			if err != nil {
+				return fmt.Errorf("the router was not reachable at %s after restarting %s: %w", remoteUrl, webServerService, err)
+			}
+			c.setToken(remoteUrl, token)
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s is not running", webServerService)
+		}
+		tflog.Debug(ctx, "web server not reachable yet", map[string]interface{}{
+			"url":   remoteUrl,
+			"error": err.Error(),
+		})
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the router was not reachable at %s after restarting %s: %w", remoteUrl, webServerService, err)
+		}
+	}
+}
+
 func (s *service) RestartService(ctx context.Context, serviceName string) error {
 	err := s.StopSevice(ctx, serviceName)
 	if err != nil {
@@ -375,3 +581,114 @@ func (s *service) RestartService(ctx context.Context, serviceName string) error
 	}
 	return s.StartService(ctx, serviceName)
 }
+
+// IsRunning reports whether procd has a running instance of the service.
+// The LuCI RPC having no access to procd, the running action of the init
+// script of the service is run through sys.exec there.
+func (s *service) IsRunning(ctx context.Context, serviceName string) (bool, error) {
+	if !s.url.viaUbus() {
+		return s.isRunningLuci(ctx, serviceName)
+	}
+
+	result, err := callUbus(ctx, s.client, s.timeouts.IsRunning(),
+		s.url.session(),
+		"service", "list", map[string]any{"name": serviceName})
+	if err != nil {
+		return false, err
+	}
+
+	var data map[string]struct {
+		Instances map[string]struct {
+			Running bool `json:"running"`
+		} `json:"instances"`
+	}
+	if err = json.Unmarshal(result, &data); err != nil {
+		return false, errors.Join(ErrUnMarshal, err)
+	}
+
+	for _, instance := range data[serviceName].Instances {
+		if instance.Running {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *service) isRunningLuci(ctx context.Context, serviceName string) (bool, error) {
+	result, err := call(ctx, s.client, s.timeouts.IsRunning(),
+		s.url.session(),
+		"sys", "exec", []any{shellQuote("/etc/init.d/"+serviceName) + " running && echo running"})
+	if err != nil {
+		return false, err
+	}
+
+	var output string
+	if err = json.Unmarshal(result, &output); err != nil {
+		return false, errors.Join(ErrUnMarshal, err)
+	}
+	return strings.TrimSpace(output) == "running", nil
+}
+
+// ExecResult is the outcome of a command run by Exec.
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Exec runs the command with the shell of the router, and returns its
+// result whatever its exit code. Through ubus, it requires the exec
+// permission on /bin/sh in the ACL of the user.
+func (s *service) Exec(ctx context.Context, command string) (*ExecResult, error) {
+	if s.url.viaUbus() {
+		return runUbus(ctx, s.client, s.timeouts.Exec(),
+			s.url.session(), "/bin/sh", "-c", command)
+	}
+
+	// sys.exec only returns the standard output, so the exit code and the
+	// standard error are printed after it, behind a boundary the command
+	// cannot guess
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	boundary := "openwrt-exec-" + hex.EncodeToString(b)
+	result, err := call(ctx, s.client, s.timeouts.Exec(),
+		s.url.session(),
+		"sys", "exec", []any{execScript(command, boundary)})
+	if err != nil {
+		return nil, err
+	}
+
+	var output string
+	if err = json.Unmarshal(result, &output); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	return parseExecOutput(output, boundary)
+}
+
+// execScript wraps the command to print its exit code and its standard error
+// after its standard output, behind the boundary.
+func execScript(command, boundary string) string {
+	return "e=$(mktemp) || exit 1\n" +
+		"(\n" + command + "\n) 2>\"$e\"\n" +
+		"printf '\\n" + boundary + "%d\\n' \"$?\"\n" +
+		"cat \"$e\"\n" +
+		"rm -f \"$e\""
+}
+
+// parseExecOutput splits the output of the script of Exec into the standard
+// output, the exit code and the standard error of the command.
+func parseExecOutput(output, boundary string) (*ExecResult, error) {
+	i := strings.LastIndex(output, "\n"+boundary)
+	if i < 0 {
+		return nil, errors.Join(ErrExecutionFailure, fmt.Errorf("the command did not run: %q", output))
+	}
+
+	code, stderr, _ := strings.Cut(output[i+1+len(boundary):], "\n")
+	exitCode, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, errors.Join(ErrParsing, fmt.Errorf("invalid exit code %q: %w", code, err))
+	}
+	return &ExecResult{Stdout: output[:i], Stderr: stderr, ExitCode: exitCode}, nil
+}
@@ -0,0 +1,199 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecScript(t *testing.T) {
+	const boundary = "openwrt-exec-test"
+
+	for command, expected := range map[string]ExecResult{
+		"echo out":                       {Stdout: "out\n"},
+		"printf out; echo err >&2":       {Stdout: "out", Stderr: "err\n"},
+		"echo out\necho err >&2; exit 3": {Stdout: "out\n", Stderr: "err\n", ExitCode: 3},
+		"false":                          {ExitCode: 1},
+	} {
+		output, err := exec.Command("/bin/sh", "-c", execScript(command, boundary)).Output()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", command, err)
+		}
+		result, err := parseExecOutput(string(output), boundary)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", command, err)
+			continue
+		}
+		if *result != expected {
+			t.Errorf("%q: expected %+v, got %+v", command, expected, *result)
+		}
+	}
+
+	if _, err := parseExecOutput("sh: mktemp: not found\n", boundary); err == nil {
+		t.Error("expected an error without the boundary")
+	}
+}
+
+// fakeUbus answers the ubus calls of RestartWebServer. With expire, the
+// session expires during the restart, its calls being denied afterwards.
+type fakeUbus struct {
+	mu       sync.Mutex
+	expire   bool
+	expired  bool
+	sessions int
+	logins   int
+}
+
+func (f *fakeUbus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req ubusRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	session, object, method := req.Params[0], req.Params[1], req.Params[2]
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reply := func(result ...any) {
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.Id, "result": result}) //nolint:errcheck
+	}
+	switch {
+	case object == "session" && method == "login":
+		f.logins++
+		f.sessions++
+		f.expired = false
+		reply(0, map[string]any{"ubus_rpc_session": fmt.Sprint(f.sessions)})
+	case f.expired:
+		json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+			"jsonrpc": "2.0", "id": req.Id,
+			"error": map[string]any{"code": ubusAccessDenied, "message": "Access denied"},
+		})
+	case session != fmt.Sprint(f.sessions):
+		reply(ubusStatusPermissionDenied)
+	case object == "rc" && method == "init":
+		f.expired = f.expire
+		reply(0)
+	case object == "service" && method == "list":
+		reply(0, map[string]any{"uhttpd": map[string]any{
+			"instances": map[string]any{"instance1": map[string]any{"running": true}},
+		}})
+	default:
+		reply(ubusStatusNotFound)
+	}
+}
+
+func TestRestartWebServer(t *testing.T) {
+	for name, expire := range map[string]bool{
+		"session kept":    false,
+		"session expired": true,
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := &fakeUbus{expire: expire}
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			serviceTimeouts, err := parseServiceTimeouts(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := newTestClient(t, server.URL)
+			c.timeouts.(*timeouts).ServiceTimeouts = serviceTimeouts
+			c.timeouts.(*timeouts).reconnectTimeout = 10 * time.Second
+			c.UseUbusTransport()
+			ctx := context.Background()
+			if err := c.Auth(ctx, "root", "secret"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := c.RestartWebServer(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := 1
+			if expire {
+				expected = 2
+			}
+			if router.logins != expected {
+				t.Errorf("expected %d logins, got %d", expected, router.logins)
+			}
+			if _, err := c.service.IsRunning(ctx, webServerService); err != nil {
+				t.Errorf("expected the session to be valid, got %v", err)
+			}
+		})
+	}
+}
+
+// fakeLuci answers the LuCI RPC calls of RestartWebServer, uhttpd being
+// reported as stopped by the first probes.
+type fakeLuci struct {
+	mu       sync.Mutex
+	stopped  int
+	commands []string
+}
+
+func (f *fakeLuci) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req jsonRPCRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reply := func(result any) {
+		json.NewEncoder(w).Encode(map[string]any{"id": 1, "result": result, "error": nil}) //nolint:errcheck
+	}
+	switch {
+	case r.URL.Path == "/cgi-bin/luci/rpc/auth":
+		reply("token")
+	case r.URL.Query().Get("auth") != "token":
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case r.URL.Path == "/cgi-bin/luci/rpc/sys" && req.Method == "init.restart":
+		reply(true)
+	case r.URL.Path == "/cgi-bin/luci/rpc/sys" && req.Method == "exec":
+		f.commands = append(f.commands, fmt.Sprint(req.Params[0]))
+		if f.stopped > 0 {
+			f.stopped--
+			reply("")
+			return
+		}
+		reply("running\n")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRestartWebServerLuci(t *testing.T) {
+	router := &fakeLuci{stopped: 1}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	serviceTimeouts, err := parseServiceTimeouts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, server.URL)
+	c.timeouts.(*timeouts).ServiceTimeouts = serviceTimeouts
+	c.timeouts.(*timeouts).reconnectTimeout = 10 * time.Second
+	ctx := context.Background()
+	if err := c.Auth(ctx, "root", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.RestartWebServer(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// uhttpd is probed through the LuCI RPC until it runs again
+	const probe = "'/etc/init.d/uhttpd' running && echo running"
+	if len(router.commands) != 2 || router.commands[0] != probe || router.commands[1] != probe {
+		t.Errorf("expected two probes %q, got %q", probe, router.commands)
+	}
+}
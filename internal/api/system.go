@@ -13,6 +13,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -24,6 +25,8 @@ const (
 	defaultAddTimeout                          = 30 * time.Second
 	defaultDeleteTimeout                       = 30 * time.Second
 	defaultCommitOrRevertTimeout               = 30 * time.Second
+	defaultApplyTimeout                        = 30 * time.Second
+	defaultConfirmTimeout                      = 30 * time.Second
 )
 
 type SystemTimeouts interface {
@@ -32,6 +35,8 @@ type SystemTimeouts interface {
 	Add() time.Duration
 	Delete() time.Duration
 	CommitOrRevert() time.Duration
+	Apply() time.Duration
+	Confirm() time.Duration
 }
 
 type SystemTimeoutsModel struct {
@@ -40,15 +45,28 @@ type SystemTimeoutsModel struct {
 	AddTimeout            types.String `tfsdk:"add"`
 	DeleteTimeout         types.String `tfsdk:"delete"`
 	CommitOrRevertTimeout types.String `tfsdk:"commit_or_revert"`
+	ApplyTimeout          types.String `tfsdk:"apply"`
+	ConfirmTimeout        types.String `tfsdk:"confirm"`
 }
 
 type SystemFacade interface {
 	GetAll(ctx context.Context, section ...any) ([]System, error)
 	GetSystem(ctx context.Context) (*System, error)
+	GetNtp(ctx context.Context) (*Ntp, error)
+	GetLed(ctx context.Context, name string) (*Led, error)
+	GetDropbear(ctx context.Context, name string) (*Dropbear, error)
+	GetRpcdLogin(ctx context.Context, username string) (*RpcdLogin, error)
+	GetUhttpd(ctx context.Context, name string) (*Uhttpd, error)
+	AddSection(ctx context.Context, config, sectionType, name string, values map[string]any) error
 	TSet(ctx context.Context, data any, section ...any) error
 	Add(ctx context.Context, section ...any) (string, error)
 	Delete(ctx context.Context, section ...any) error
 	CommitOrRevert(ctx context.Context, section ...any) error
+	Revert(ctx context.Context, section ...any) error
+	Transaction(ctx context.Context, config string, stage func(ctx context.Context) error) error
+	Apply(ctx context.Context, rollback bool, timeout time.Duration) error
+	Confirm(ctx context.Context) error
+	ReplaceConfig(ctx context.Context, config string, c *uci.Config) error
 }
 
 type systemTimeouts struct {
@@ -56,7 +74,9 @@ type systemTimeouts struct {
 	tSetTimeout,
 	addTimeout,
 	deleteTimeout,
-	commitOrRevertTimeout time.Duration
+	commitOrRevertTimeout,
+	applyTimeout,
+	confirmTimeout time.Duration
 }
 
 func (sT *systemTimeouts) GetAll() time.Duration {
@@ -79,9 +99,16 @@ func (sT *systemTimeouts) CommitOrRevert() time.Duration {
 	return sT.commitOrRevertTimeout
 }
 
+func (sT *systemTimeouts) Apply() time.Duration {
+	return sT.applyTimeout
+}
+
+func (sT *systemTimeouts) Confirm() time.Duration {
+	return sT.confirmTimeout
+}
+
 var (
 	_ SystemFacade   = (*system)(nil)
-	_ WithSession    = (*system)(nil)
 	_ SystemTimeouts = (*systemTimeouts)(nil)
 
 	uciTimeoutSchemaAttribute = schema.SingleNestedAttribute{
@@ -114,6 +141,16 @@ var (
 				Description:         `Commit or revert operation timeout configuration`,
 				Optional:            true,
 			},
+			"apply": schema.StringAttribute{
+				MarkdownDescription: `Apply (with rollback) ubus call timeout value`,
+				Description:         `Apply (with rollback) ubus call timeout value`,
+				Optional:            true,
+			},
+			"confirm": schema.StringAttribute{
+				MarkdownDescription: `Confirm ubus call timeout value`,
+				Description:         `Confirm ubus call timeout value`,
+				Optional:            true,
+			},
 		},
 	}
 )
@@ -124,6 +161,8 @@ func parseSystemTimeouts(ctx context.Context, t *TimeoutsModel) (SystemTimeouts,
 	addTimeout := defaultAddTimeout
 	deleteTimeout := defaultDeleteTimeout
 	commitOrRevertTimeout := defaultCommitOrRevertTimeout
+	applyTimeout := defaultApplyTimeout
+	confirmTimeout := defaultConfirmTimeout
 
 	if t != nil && t.System != nil && !t.System.GetAllTimeout.IsNull() {
 		parsedGetAllTimeout, err := time.ParseDuration(t.System.GetAllTimeout.ValueString())
@@ -185,20 +224,46 @@ func parseSystemTimeouts(ctx context.Context, t *TimeoutsModel) (SystemTimeouts,
 		tflog.Debug(ctx, "system - parse timeout configuration: default commit_or_revert config")
 	}
 
+	if t != nil && t.System != nil && !t.System.ApplyTimeout.IsNull() {
+		parsedApplyTimeout, err := time.ParseDuration(t.System.ApplyTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		applyTimeout = parsedApplyTimeout
+		tflog.Debug(ctx, "system - parse timeout configuration: apply config parsed")
+	} else {
+		tflog.Debug(ctx, "system - parse timeout configuration: default apply config")
+	}
+
+	if t != nil && t.System != nil && !t.System.ConfirmTimeout.IsNull() {
+		parsedConfirmTimeout, err := time.ParseDuration(t.System.ConfirmTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		confirmTimeout = parsedConfirmTimeout
+		tflog.Debug(ctx, "system - parse timeout configuration: confirm config parsed")
+	} else {
+		tflog.Debug(ctx, "system - parse timeout configuration: default confirm config")
+	}
+
 	return &systemTimeouts{
 		getAllTimeout,
 		tSetTimeout,
 		addTimeout,
 		deleteTimeout,
 		commitOrRevertTimeout,
+		applyTimeout,
+		confirmTimeout,
 	}, nil
 }
 
 type system struct {
-	timeouts SystemTimeouts
+	timeouts     SystemTimeouts
+	transactions transactions
 
-	token  string
-	url    *string
+	url    *remote
 	client *http.Client
 }
 
@@ -206,6 +271,7 @@ type System struct {
 	Id        string `json:".name,omitempty"`
 	Type      string `json:".type,omitzero,omitempty"`
 	Anonymous bool   `json:".anonymous,omitzero,omitempty"`
+	Index     int    `json:".index,omitempty"`
 
 	Hostname        string `json:"hostname,omitzero"`
 	Description     string `json:"description,omitzero"`
@@ -233,9 +299,72 @@ type System struct {
 	ZramSizeMb      string `json:"zram_size_mb,omitzero"`
 }
 
-func (c *system) SetToken(ctx context.Context, token string) error {
-	c.token = token
-	return nil
+type Led struct {
+	Id        string `json:".name,omitempty"`
+	Type      string `json:".type,omitzero,omitempty"`
+	Anonymous bool   `json:".anonymous,omitzero,omitempty"`
+
+	Name     string `json:"name,omitzero"`
+	Sysfs    string `json:"sysfs,omitzero"`
+	Trigger  string `json:"trigger,omitzero"`
+	Dev      string `json:"dev,omitzero"`
+	Mode     string `json:"mode,omitzero"`
+	DelayOn  string `json:"delayon,omitzero"`
+	DelayOff string `json:"delayoff,omitzero"`
+	Default  string `json:"default,omitzero"`
+}
+
+type Dropbear struct {
+	Id        string `json:".name,omitempty"`
+	Type      string `json:".type,omitzero,omitempty"`
+	Anonymous bool   `json:".anonymous,omitzero,omitempty"`
+
+	Port             string `json:"Port,omitzero"`
+	Interface        string `json:"Interface,omitzero"`
+	PasswordAuth     string `json:"PasswordAuth,omitzero"`
+	RootPasswordAuth string `json:"RootPasswordAuth,omitzero"`
+	GatewayPorts     string `json:"GatewayPorts,omitzero"`
+}
+
+// RpcdLogin is a login section of the rpcd configuration, Read and Write
+// being the names of the ACL groups it is granted.
+type RpcdLogin struct {
+	Id        string `json:".name,omitempty"`
+	Type      string `json:".type,omitzero,omitempty"`
+	Anonymous bool   `json:".anonymous,omitzero,omitempty"`
+
+	Username string   `json:"username,omitzero"`
+	Password string   `json:"password,omitzero"`
+	Timeout  string   `json:"timeout,omitzero"`
+	Read     []string `json:"read,omitzero"`
+	Write    []string `json:"write,omitzero"`
+}
+
+type Uhttpd struct {
+	Id        string `json:".name,omitempty"`
+	Type      string `json:".type,omitzero,omitempty"`
+	Anonymous bool   `json:".anonymous,omitzero,omitempty"`
+
+	ListenHttp    []string `json:"listen_http,omitzero"`
+	ListenHttps   []string `json:"listen_https,omitzero"`
+	RedirectHttps string   `json:"redirect_https,omitzero"`
+	Home          string   `json:"home,omitzero"`
+	Cert          string   `json:"cert,omitzero"`
+	Key           string   `json:"key,omitzero"`
+	MaxRequests   string   `json:"max_requests,omitzero"`
+	ScriptTimeout string   `json:"script_timeout,omitzero"`
+	Rfc1918Filter string   `json:"rfc1918_filter,omitzero"`
+}
+
+type Ntp struct {
+	Id        string `json:".name,omitempty"`
+	Type      string `json:".type,omitzero,omitempty"`
+	Anonymous bool   `json:".anonymous,omitzero,omitempty"`
+
+	Enabled      string   `json:"enabled,omitzero"`
+	EnableServer string   `json:"enable_server,omitzero"`
+	Interface    string   `json:"interface,omitzero"`
+	Server       []string `json:"server,omitzero"`
 }
 
 func (c *system) GetAll(ctx context.Context, sections ...any) ([]System, error) {
@@ -243,8 +372,7 @@ func (c *system) GetAll(ctx context.Context, sections ...any) ([]System, error)
 		return nil, fmt.Errorf("no sections specified")
 	}
 
-	result, err := call(ctx, c.client, c.timeouts.GetAll(),
-		*c.url, c.token, "uci", "get_all", sections)
+	result, err := c.getAll(ctx, sections...)
 	if err != nil {
 		return nil, err
 	}
@@ -260,19 +388,154 @@ func (c *system) GetAll(ctx context.Context, sections ...any) ([]System, error)
 	return slices.Collect(maps.Values(data)), nil
 }
 
+// GetSystem returns the first section of type system, i.e. @system[0]. The
+// system configuration always holds exactly one of them.
 func (c *system) GetSystem(ctx context.Context) (*System, error) {
 	result, err := c.GetAll(ctx, "system")
 	if err != nil {
 		return nil, err
 	}
 
+	var found *System
 	for _, aResult := range result {
-		if aResult.Anonymous && aResult.Type == "system" {
-			return &aResult, nil
+		if aResult.Type == "system" && (found == nil || aResult.Index < found.Index) {
+			found = &aResult
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("system section not found")
+	}
+	return found, nil
+}
+
+// GetNtp returns the timeserver section named ntp, that configures sysntpd.
+func (c *system) GetNtp(ctx context.Context) (*Ntp, error) {
+	var data Ntp
+	if err := c.getSection(ctx, "system", "timeserver", "ntp", &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetLed returns the led section of the given name. ErrSectionNotFound is
+// returned when there is none.
+func (c *system) GetLed(ctx context.Context, name string) (*Led, error) {
+	var data Led
+	if err := c.getSection(ctx, "system", "led", name, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetDropbear returns the dropbear section of the given name, which may use
+// the extended syntax, e.g. @dropbear[0]. ErrSectionNotFound is returned when
+// there is none.
+func (c *system) GetDropbear(ctx context.Context, name string) (*Dropbear, error) {
+	var data Dropbear
+	if err := c.getSection(ctx, "dropbear", "dropbear", name, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetRpcdLogin returns the login section of rpcd for the given username,
+// login sections being anonymous. ErrSectionNotFound is returned when there
+// is none.
+func (c *system) GetRpcdLogin(ctx context.Context, username string) (*RpcdLogin, error) {
+	result, err := c.getAll(ctx, "rpcd")
+	if errors.Is(err, ErrEmptyResult) {
+		return nil, errors.Join(ErrSectionNotFound, fmt.Errorf("login %q of rpcd", username))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]RpcdLogin
+	if err = json.Unmarshal(result, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	for _, login := range data {
+		if login.Type == "login" && login.Username == username {
+			return &login, nil
 		}
 	}
+	return nil, errors.Join(ErrSectionNotFound, fmt.Errorf("login %q of rpcd", username))
+}
 
-	return nil, fmt.Errorf("system section not found")
+// GetUhttpd returns the uhttpd section of the given name. ErrSectionNotFound
+// is returned when there is none.
+func (c *system) GetUhttpd(ctx context.Context, name string) (*Uhttpd, error) {
+	var data Uhttpd
+	if err := c.getSection(ctx, "uhttpd", "uhttpd", name, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// getSection unmarshals the named section into v, after checking its type.
+func (c *system) getSection(ctx context.Context, config, sectionType, name string, v any) error {
+	result, err := c.getAll(ctx, config, name)
+	if errors.Is(err, ErrEmptyResult) {
+		return errors.Join(ErrSectionNotFound, fmt.Errorf("%s section %q of %s", sectionType, name, config))
+	}
+	if err != nil {
+		return err
+	}
+
+	var header struct {
+		Type string `json:".type"`
+	}
+	if err = json.Unmarshal(result, &header); err != nil {
+		return errors.Join(ErrUnMarshal, err)
+	}
+	if header.Type != sectionType {
+		return errors.Join(ErrSectionNotFound, fmt.Errorf("section %q of %s is a %s section, not a %s one", name, config, header.Type, sectionType))
+	}
+
+	if err = json.Unmarshal(result, v); err != nil {
+		return errors.Join(ErrUnMarshal, err)
+	}
+	return nil
+}
+
+// getAll returns the named configuration, or one of its sections, in the
+// format of the get_all method of the LuCI RPC. ErrEmptyResult is returned
+// when there is none.
+func (c *system) getAll(ctx context.Context, params ...any) (json.RawMessage, error) {
+	if !c.url.viaUbus() {
+		return call(ctx, c.client, c.timeouts.GetAll(),
+			c.url.session(), "uci", "get_all", params)
+	}
+
+	result, err := callUbus(ctx, c.client, c.timeouts.GetAll(),
+		c.url.session(), "uci", "get", uciArgs(params...))
+	if isUbusStatus(err, ubusStatusNotFound) {
+		return nil, ErrEmptyResult
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Values json.RawMessage `json:"values"`
+	}
+	if err = json.Unmarshal(result, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	return data.Values, nil
+}
+
+// uciArgs turns the positional parameters of the LuCI RPC, i.e. config,
+// section and option, into the arguments of the uci object of rpcd.
+func uciArgs(params ...any) map[string]any {
+	args := map[string]any{}
+	for i, name := range []string{"config", "section", "option"} {
+		if i < len(params) {
+			args[name] = params[i]
+		}
+	}
+	return args
 }
 
 func (c *system) TSet(ctx context.Context, data any, section ...any) error {
@@ -280,15 +543,29 @@ func (c *system) TSet(ctx context.Context, data any, section ...any) error {
 	if err != nil {
 		return err
 	}
+	if c.url.viaUbus() {
+		args := uciArgs(section...)
+		args["values"] = data
+		_, err = callUbus(ctx, c.client, c.timeouts.TSet(),
+			c.url.session(), "uci", "set", args)
+		return err
+	}
 	section = append(section, data)
 	_, err = call(ctx, c.client, c.timeouts.TSet(),
-		*c.url, c.token, "uci", "tset", section)
+		c.url.session(), "uci", "tset", section)
 	return err
 }
 
 func (c *system) Add(ctx context.Context, section ...any) (string, error) {
+	if c.url.viaUbus() {
+		if len(section) != 2 {
+			return "", fmt.Errorf("expected a config and a section type, got %v", section)
+		}
+		return c.addUbus(ctx, map[string]any{"config": section[0], "type": section[1]})
+	}
+
 	raw, err := call(ctx, c.client, c.timeouts.Add(),
-		*c.url, c.token, "uci", "add", section)
+		c.url.session(), "uci", "add", section)
 	if err != nil {
 		return "", err
 	}
@@ -299,15 +576,64 @@ func (c *system) Add(ctx context.Context, section ...any) (string, error) {
 	return s, nil
 }
 
+// AddSection adds a named section with its options, Add only adding anonymous
+// ones.
+func (c *system) AddSection(ctx context.Context, config, sectionType, name string, values map[string]any) error {
+	if c.url.viaUbus() {
+		_, err := c.addUbus(ctx, map[string]any{
+			"config": config,
+			"type":   sectionType,
+			"name":   name,
+			"values": values,
+		})
+		return err
+	}
+
+	_, err := call(ctx, c.client, c.timeouts.Add(),
+		c.url.session(), "uci", "section", []any{config, sectionType, name, values})
+	return err
+}
+
+func (c *system) addUbus(ctx context.Context, args map[string]any) (string, error) {
+	result, err := callUbus(ctx, c.client, c.timeouts.Add(),
+		c.url.session(), "uci", "add", args)
+	if err != nil {
+		return "", err
+	}
+
+	var data struct {
+		Section string `json:"section"`
+	}
+	if err := json.Unmarshal(result, &data); err != nil {
+		return "", errors.Join(ErrUnMarshal, err)
+	}
+	return data.Section, nil
+}
+
 func (c *system) Delete(ctx context.Context, section ...any) error {
+	if c.url.viaUbus() {
+		_, err := callUbus(ctx, c.client, c.timeouts.Delete(),
+			c.url.session(), "uci", "delete", uciArgs(section...))
+		return err
+	}
+
 	_, err := call(ctx, c.client, c.timeouts.Delete(),
-		*c.url, c.token, "uci", "delete", section)
+		c.url.session(), "uci", "delete", section)
 	return err
 }
 
 func (c *system) uciCommit(ctx context.Context, section ...any) error {
+	if c.url.viaUbus() {
+		_, err := callUbus(ctx, c.client, c.timeouts.CommitOrRevert(),
+			c.url.session(), "uci", "commit", uciArgs(section...))
+		if err != nil {
+			return fmt.Errorf("uci commit call ko: %w", err)
+		}
+		return nil
+	}
+
 	resp, err := call(ctx, c.client, c.timeouts.CommitOrRevert(),
-		*c.url, c.token, "uci", "commit", section)
+		c.url.session(), "uci", "commit", section)
 	if err != nil {
 		return fmt.Errorf("uci commit call ko: %w", err)
 	}
@@ -323,9 +649,18 @@ func (c *system) uciCommit(ctx context.Context, section ...any) error {
 	return err
 }
 
-func (c *system) uciRevert(ctx context.Context, section ...any) error {
+func (c *system) Revert(ctx context.Context, section ...any) error {
+	if c.url.viaUbus() {
+		_, err := callUbus(ctx, c.client, c.timeouts.CommitOrRevert(),
+			c.url.session(), "uci", "revert", uciArgs(section...))
+		if err != nil {
+			return fmt.Errorf("uci revert call ko: %w", err)
+		}
+		return nil
+	}
+
 	resp, err := call(ctx, c.client, c.timeouts.CommitOrRevert(),
-		*c.url, c.token, "uci", "revert", section)
+		c.url.session(), "uci", "revert", section)
 	if err != nil {
 		return fmt.Errorf("uci revert call ko: %w", err)
 	}
@@ -346,7 +681,7 @@ func (c *system) CommitOrRevert(ctx context.Context, section ...any) error {
 	err := c.uciCommit(ctx, section...)
 	if err != nil {
 		toReturn = append(toReturn, fmt.Errorf("failed to commit config %q: %w", section, err))
-		err = c.uciRevert(ctx, section...)
+		err = c.Revert(ctx, section...)
 		if err != nil {
 			toReturn = append(toReturn, fmt.Errorf("failed to revert config %q: %w", section, err))
 		}
@@ -357,3 +692,93 @@ func (c *system) CommitOrRevert(ctx context.Context, section ...any) error {
 	}
 	return nil
 }
+
+// Transaction stages the changes of config with stage, then commits them.
+// Transactions of the same configuration are staged one at a time, and
+// committed together when they are queued while a commit is in progress.
+// When a transaction fails, only its changes are left out: the others are
+// reverted and staged again, so stage may be called more than once and must
+// only stage changes.
+func (c *system) Transaction(ctx context.Context, config string, stage func(ctx context.Context) error) error {
+	commit := func(ctx context.Context, _ string, section ...any) error {
+		return c.CommitOrRevert(ctx, section...)
+	}
+	return c.transactions.run(ctx, config, config, "", stage, commit, c.Revert)
+}
+
+// Apply applies the changes staged in the session. With rollback, the router
+// restores the previous configuration unless Confirm is called within the
+// timeout.
+func (c *system) Apply(ctx context.Context, rollback bool, timeout time.Duration) error {
+	_, err := callUbus(ctx, c.client, c.timeouts.Apply(),
+		c.url.session(), "uci", "apply", map[string]any{
+			"rollback": rollback,
+			"timeout":  int(timeout.Seconds()),
+		})
+
+	var statusErr *ubusStatusError
+	if errors.As(err, &statusErr) && statusErr.Code == ubusStatusNoData {
+		return ErrNoChanges
+	}
+	return err
+}
+
+// Confirm cancels the pending rollback, keeping the applied changes.
+func (c *system) Confirm(ctx context.Context) error {
+	_, err := callUbus(ctx, c.client, c.timeouts.Confirm(),
+		c.url.session(), "uci", "confirm", map[string]any{})
+	return err
+}
+
+// ReplaceConfig stages the changes turning the named configuration into the
+// given one: all of its sections are deleted, then the new ones are created
+// in order. Nothing is written until the changes are committed or applied.
+func (c *system) ReplaceConfig(ctx context.Context, config string, cfg *uci.Config) error {
+	result, err := c.getAll(ctx, config)
+	if err != nil && !errors.Is(err, ErrEmptyResult) {
+		return err
+	}
+
+	var current map[string]json.RawMessage
+	if err == nil {
+		if err := json.Unmarshal(result, &current); err != nil {
+			return errors.Join(ErrUnMarshal, err)
+		}
+	}
+
+	for name := range current {
+		if err := c.Delete(ctx, config, name); err != nil {
+			return fmt.Errorf("failed to delete section %q of %s: %w", name, config, err)
+		}
+	}
+
+	for _, section := range cfg.Sections {
+		values := map[string]any{}
+		for _, option := range section.Options {
+			if option.IsList {
+				values[option.Name] = option.Values
+			} else {
+				values[option.Name] = option.Values[0]
+			}
+		}
+
+		if section.Name != "" {
+			if err := c.AddSection(ctx, config, section.Type, section.Name, values); err != nil {
+				return fmt.Errorf("failed to add section %q to %s: %w", section.Name, config, err)
+			}
+			continue
+		}
+
+		name, err := c.Add(ctx, config, section.Type)
+		if err != nil {
+			return fmt.Errorf("failed to add %s section to %s: %w", section.Type, config, err)
+		}
+		if len(values) > 0 {
+			if err := c.TSet(ctx, values, config, name); err != nil {
+				return fmt.Errorf("failed to set options of section %q of %s: %w", name, config, err)
+			}
+		}
+	}
+
+	return nil
+}
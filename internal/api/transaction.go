@@ -0,0 +1,250 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// allConfigs is the queue key of the transactions when a single commit
+// covers the changes of every configuration, as `uci apply` does.
+const allConfigs = "*"
+
+// transaction is a set of changes to a configuration, staged by a single
+// resource.
+type transaction struct {
+	ctx    context.Context
+	config string
+	// remote is the address the router moves to once the changes are
+	// committed, empty when it stays.
+	remote string
+	stage  func(ctx context.Context) error
+	err    error
+
+	// lead tells the transaction woken up to run the next batch, instead
+	// of returning its result.
+	lead bool
+	wake chan struct{}
+}
+
+type transactionQueue struct {
+	pending []*transaction
+	busy    bool
+}
+
+// transactions serializes the staging of UCI changes. The changes staged in
+// a session are shared by all the resources: without it, a resource could
+// commit the half-staged changes of another, or revert them along with its
+// own.
+//
+// Transactions are queued by configuration. The first one to arrive stages
+// the pending transactions one after the other and commits them at once;
+// the ones arriving meanwhile wait for the next batch, run by the first of
+// them.
+type transactions struct {
+	mu     sync.Mutex
+	queues map[string]*transactionQueue
+}
+
+// commitFunc commits the configurations, the router moving to remoteUrl
+// unless it is empty.
+type commitFunc func(ctx context.Context, remoteUrl string, section ...any) error
+
+// run queues the transaction under key, and returns its result once its
+// batch is committed. commit and revert are called with the config of each
+// transaction of the batch.
+func (t *transactions) run(ctx context.Context, key, config, remote string, stage func(ctx context.Context) error,
+	commit commitFunc, revert func(ctx context.Context, section ...any) error) error {
+	tx := &transaction{ctx: ctx, config: config, remote: remote, stage: stage, wake: make(chan struct{})}
+
+	t.mu.Lock()
+	if t.queues == nil {
+		t.queues = map[string]*transactionQueue{}
+	}
+	queue, ok := t.queues[key]
+	if !ok {
+		queue = &transactionQueue{}
+		t.queues[key] = queue
+	}
+	queue.pending = append(queue.pending, tx)
+	if queue.busy {
+		t.mu.Unlock()
+		if err := t.wait(tx, queue); err != nil {
+			return err
+		}
+		if !tx.lead {
+			return tx.err
+		}
+		t.mu.Lock()
+	}
+	queue.busy = true
+	batch := queue.pending
+	queue.pending = nil
+	t.mu.Unlock()
+
+	commitBatch(batchContext(ctx), batch, commit, revert)
+
+	t.mu.Lock()
+	if len(queue.pending) > 0 {
+		next := queue.pending[0]
+		next.lead = true
+		close(next.wake)
+	} else {
+		delete(t.queues, key)
+	}
+	t.mu.Unlock()
+
+	for _, other := range batch {
+		if other != tx {
+			close(other.wake)
+		}
+	}
+	return tx.err
+}
+
+// wait waits for the transaction to be run by the leader of its batch, or
+// to lead the next one. It gives up when the context of the transaction is
+// done before it is taken into a batch.
+func (t *transactions) wait(tx *transaction, queue *transactionQueue) error {
+	select {
+	case <-tx.wake:
+		return nil
+	case <-tx.ctx.Done():
+	}
+
+	t.mu.Lock()
+	if i := slices.Index(queue.pending, tx); i >= 0 && !tx.lead {
+		queue.pending = slices.Delete(queue.pending, i, i+1)
+		t.mu.Unlock()
+		return tx.ctx.Err()
+	}
+	t.mu.Unlock()
+	// the batch holding it is already running, or waits for it to lead
+	<-tx.wake
+	return nil
+}
+
+// batchContext returns the context of the commits and reverts of a batch.
+// They cover the changes of every transaction, so the cancellation or the
+// operation timeout of the leading resource does not apply to them: the
+// calls are only bounded by their own timeouts.
+func batchContext(ctx context.Context) context.Context {
+	return context.WithValue(context.WithoutCancel(ctx), operationTimeoutKey{}, nil)
+}
+
+// commitBatch stages the changes of the batch, then commits them. When the
+// commit fails, the transactions are committed one at a time, so that only
+// the failing ones report an error.
+//
+// Reverting a batch only drops the changes staged in the session: a stage
+// writing a file in place, as a configuration file committed without
+// confirmed apply does, is left as is, and written again when the stage is
+// run again.
+func commitBatch(ctx context.Context, batch []*transaction, commit commitFunc, revert func(ctx context.Context, section ...any) error) {
+	staged := stageBatch(ctx, batch, revert)
+	if len(staged) == 0 {
+		return
+	}
+
+	err := commitConfigs(ctx, staged, commit)
+	if err == nil {
+		return
+	}
+	// the router restored the previous configuration as a whole, nothing
+	// tells which transaction made it unreachable
+	if len(staged) == 1 || errors.Is(err, ErrRolledBack) {
+		for _, tx := range staged {
+			tx.err = err
+		}
+		return
+	}
+
+	if err := revertConfigs(ctx, staged, revert); err != nil {
+		for _, tx := range staged {
+			tx.err = err
+		}
+		return
+	}
+	for _, tx := range staged {
+		if tx.err = tx.stage(tx.ctx); tx.err != nil {
+			if err := revert(ctx, tx.config); err != nil {
+				tx.err = errors.Join(tx.err, fmt.Errorf("failed to revert config %q: %w", tx.config, err))
+			}
+			continue
+		}
+		tx.err = commit(ctx, tx.remote, tx.config)
+	}
+}
+
+// stageBatch stages the changes of the batch in order, and returns the
+// transactions staged. When one of them fails, the configurations are
+// reverted, and the others staged again without it.
+func stageBatch(ctx context.Context, batch []*transaction, revert func(ctx context.Context, section ...any) error) []*transaction {
+	for len(batch) > 0 {
+		failed := slices.IndexFunc(batch, func(tx *transaction) bool {
+			tx.err = tx.stage(tx.ctx)
+			return tx.err != nil
+		})
+		if failed < 0 {
+			return batch
+		}
+
+		if err := revertConfigs(ctx, batch, revert); err != nil {
+			for _, tx := range batch {
+				tx.err = errors.Join(tx.err, err)
+			}
+			return nil
+		}
+		batch = slices.Delete(slices.Clone(batch), failed, failed+1)
+	}
+	return nil
+}
+
+// commitConfigs commits the configurations of the batch. The router moves
+// with the first commit, which applies all of them with confirmed apply, and
+// the only one otherwise, the batches being of a single configuration.
+func commitConfigs(ctx context.Context, batch []*transaction, commit commitFunc) error {
+	remote := remoteOf(batch)
+	for _, config := range configsOf(batch) {
+		if err := commit(ctx, remote, config); err != nil {
+			return err
+		}
+		remote = ""
+	}
+	return nil
+}
+
+func revertConfigs(ctx context.Context, batch []*transaction, revert func(ctx context.Context, section ...any) error) error {
+	for _, config := range configsOf(batch) {
+		if err := revert(ctx, config); err != nil {
+			return fmt.Errorf("failed to revert config %q: %w", config, err)
+		}
+	}
+	return nil
+}
+
+// configsOf returns the configurations changed by the batch, in order.
+func configsOf(batch []*transaction) []string {
+	var configs []string
+	for _, tx := range batch {
+		if !slices.Contains(configs, tx.config) {
+			configs = append(configs, tx.config)
+		}
+	}
+	return configs
+}
+
+// remoteOf returns the address the router moves to with the batch, if any.
+func remoteOf(batch []*transaction) string {
+	for _, tx := range slices.Backward(batch) {
+		if tx.remote != "" {
+			return tx.remote
+		}
+	}
+	return ""
+}
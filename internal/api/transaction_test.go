@@ -0,0 +1,300 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSession stages changes by configuration, as UCI does in a session.
+// Committing a configuration holding a "reject" change fails and reverts it.
+type fakeSession struct {
+	mu        sync.Mutex
+	staged    map[string][]string
+	committed map[string][]string
+	commits   int
+}
+
+func newFakeSession() *fakeSession {
+	return &fakeSession{staged: map[string][]string{}, committed: map[string][]string{}}
+}
+
+func (s *fakeSession) stage(config string, changes ...string) func(ctx context.Context) error {
+	return func(_ context.Context) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, change := range changes {
+			if change == "fail" {
+				return fmt.Errorf("failed to stage %v", changes)
+			}
+			s.staged[config] = append(s.staged[config], change)
+		}
+		return nil
+	}
+}
+
+func (s *fakeSession) commit(_ context.Context, _ string, section ...any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	config := section[0].(string)
+	s.commits++
+	if slices.Contains(s.staged[config], "reject") {
+		delete(s.staged, config)
+		return fmt.Errorf("commit of %s rejected", config)
+	}
+	s.committed[config] = append(s.committed[config], s.staged[config]...)
+	delete(s.staged, config)
+	return nil
+}
+
+func (s *fakeSession) revert(_ context.Context, section ...any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.staged, section[0].(string))
+	return nil
+}
+
+// runQueued runs first, and the others once they are all queued behind it,
+// so that they are committed in a single batch. It returns the result of
+// each transaction, first included.
+func runQueued(t *testing.T, txs *transactions, s *fakeSession, first func(ctx context.Context) error, others ...func(ctx context.Context) error) []error {
+	t.Helper()
+
+	results := make([]error, len(others)+1)
+	started := make(chan struct{})
+	commit := func(ctx context.Context, remoteUrl string, section ...any) error {
+		select {
+		case <-started:
+		default:
+			close(started)
+			// hold the first commit until the others are queued
+			for deadline := time.Now().Add(5 * time.Second); ; {
+				txs.mu.Lock()
+				queued := len(txs.queues["dhcp"].pending)
+				txs.mu.Unlock()
+				if queued == len(others) || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+		return s.commit(ctx, remoteUrl, section...)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0] = txs.run(context.Background(), "dhcp", "dhcp", "", first, commit, s.revert)
+	}()
+	<-started
+	for i, stage := range others {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i+1] = txs.run(context.Background(), "dhcp", "dhcp", "", stage, commit, s.revert)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func TestTransactionsCoalesceCommits(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	var others []func(ctx context.Context) error
+	for i := range 5 {
+		others = append(others, s.stage("dhcp", fmt.Sprintf("host%d.ip", i), fmt.Sprintf("host%d.mac", i)))
+	}
+	for i, err := range runQueued(t, txs, s, s.stage("dhcp", "lan.start"), others...) {
+		if err != nil {
+			t.Errorf("transaction %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if s.commits != 2 {
+		t.Errorf("expected 2 commits, got %d", s.commits)
+	}
+	if got := len(s.committed["dhcp"]); got != 11 {
+		t.Errorf("expected 11 changes to be committed, got %v", s.committed["dhcp"])
+	}
+	// the changes of a transaction are staged together
+	for i := range 5 {
+		j := slices.Index(s.committed["dhcp"], fmt.Sprintf("host%d.ip", i))
+		if j < 0 || j+1 >= len(s.committed["dhcp"]) || s.committed["dhcp"][j+1] != fmt.Sprintf("host%d.mac", i) {
+			t.Errorf("expected the changes of host%d to be committed together, got %v", i, s.committed["dhcp"])
+		}
+	}
+	if len(txs.queues) != 0 {
+		t.Errorf("expected the queues to be released, got %v", txs.queues)
+	}
+}
+
+func TestTransactionsRevertFailingStage(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	results := runQueued(t, txs, s, s.stage("dhcp", "lan.start"),
+		s.stage("dhcp", "host0.ip"),
+		s.stage("dhcp", "host1.ip", "fail"),
+		s.stage("dhcp", "host2.ip"),
+	)
+
+	for i, err := range results {
+		if (err != nil) != (i == 2) {
+			t.Errorf("transaction %d: unexpected result: %v", i, err)
+		}
+	}
+	slices.Sort(s.committed["dhcp"])
+	if expected := []string{"host0.ip", "host2.ip", "lan.start"}; !slices.Equal(s.committed["dhcp"], expected) {
+		t.Errorf("expected %v to be committed, got %v", expected, s.committed["dhcp"])
+	}
+}
+
+func TestTransactionsRetryFailingCommit(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	results := runQueued(t, txs, s, s.stage("dhcp", "lan.start"),
+		s.stage("dhcp", "host0.ip"),
+		s.stage("dhcp", "reject"),
+		s.stage("dhcp", "host2.ip"),
+	)
+
+	for i, err := range results {
+		if (err != nil) != (i == 2) {
+			t.Errorf("transaction %d: unexpected result: %v", i, err)
+		}
+	}
+	slices.Sort(s.committed["dhcp"])
+	if expected := []string{"host0.ip", "host2.ip", "lan.start"}; !slices.Equal(s.committed["dhcp"], expected) {
+		t.Errorf("expected %v to be committed, got %v", expected, s.committed["dhcp"])
+	}
+}
+
+func TestTransactionsRolledBack(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	rolledBack := func(_ context.Context, _ string, _ ...any) error {
+		return errors.Join(ErrRolledBack, errors.New("router unreachable"))
+	}
+	err := txs.run(context.Background(), allConfigs, "network", "", s.stage("network", "lan.ipaddr"), rolledBack, s.revert)
+	if !errors.Is(err, ErrRolledBack) {
+		t.Errorf("expected the rollback to be reported, got %v", err)
+	}
+}
+
+func TestTransactionsMoveRemote(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	var remotes []string
+	commit := func(ctx context.Context, remoteUrl string, section ...any) error {
+		remotes = append(remotes, remoteUrl)
+		return s.commit(ctx, remoteUrl, section...)
+	}
+	err := txs.run(context.Background(), "network", "network", "http://192.168.2.1", s.stage("network", "lan.ipaddr"), commit, s.revert)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = txs.run(context.Background(), "system", "system", "", s.stage("system", "hostname"), commit, s.revert)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the address only moves with the commit of the transaction changing it
+	if expected := []string{"http://192.168.2.1", ""}; !slices.Equal(remotes, expected) {
+		t.Errorf("expected the commits to move the router to %q, got %q", expected, remotes)
+	}
+}
+
+func TestTransactionsCancelQueued(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	commit := func(ctx context.Context, remoteUrl string, section ...any) error {
+		select {
+		case <-started:
+		default:
+			close(started)
+			<-release
+		}
+		return s.commit(ctx, remoteUrl, section...)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- txs.run(context.Background(), "dhcp", "dhcp", "", s.stage("dhcp", "lan.start"), commit, s.revert)
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error)
+	go func() {
+		queued <- txs.run(ctx, "dhcp", "dhcp", "", s.stage("dhcp", "host0.ip"), commit, s.revert)
+	}()
+	for {
+		txs.mu.Lock()
+		n := len(txs.queues["dhcp"].pending)
+		txs.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	// the queued transaction gives up while the batch ahead still commits
+	select {
+	case err := <-queued:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the transaction to be canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the canceled transaction kept waiting")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"lan.start"}; !slices.Equal(s.committed["dhcp"], expected) {
+		t.Errorf("expected %v to be committed, got %v", expected, s.committed["dhcp"])
+	}
+	if len(txs.queues) != 0 {
+		t.Errorf("expected the queues to be released, got %v", txs.queues)
+	}
+}
+
+func TestTransactionsCommitOutlivesLeader(t *testing.T) {
+	s := newFakeSession()
+	txs := &transactions{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	commit := func(ctx context.Context, remoteUrl string, section ...any) error {
+		// the leading resource gives up while the batch commits
+		cancel()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return s.commit(ctx, remoteUrl, section...)
+	}
+
+	if err := txs.run(ctx, "dhcp", "dhcp", "", s.stage("dhcp", "lan.start"), commit, s.revert); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if expected := []string{"lan.start"}; !slices.Equal(s.committed["dhcp"], expected) {
+		t.Errorf("expected %v to be committed, got %v", expected, s.committed["dhcp"])
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var proxySchemes = []string{"http", "https", "socks5"}
+
+// Bastion is the SSH host the router is reached through.
+type Bastion struct {
+	// Host is the address of the bastion, with an optional port.
+	Host       string
+	User       string
+	PrivateKey []byte
+	// HostKey is the public key of the bastion, in the authorized_keys
+	// format. Without it, the key is checked against ~/.ssh/known_hosts.
+	HostKey string
+}
+
+// transport returns the transport shared by the facades, created on first
+// use from the default one, which honours the HTTPS_PROXY and HTTP_PROXY
+// variables.
+func (c *client) transport() *http.Transport {
+	if c.client.Transport == nil {
+		c.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return c.client.Transport.(*http.Transport)
+}
+
+// UseProxy makes the client reach the router through the proxy at proxyUrl,
+// instead of the one of the environment. It must be called before Auth.
+func (c *client) UseProxy(proxyUrl string) error {
+	u, err := url.Parse(proxyUrl)
+	if err != nil {
+		return fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+	if !slices.Contains(proxySchemes, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("proxy url %q is not a %v url", proxyUrl, proxySchemes)
+	}
+
+	c.transport().Proxy = http.ProxyURL(u)
+	return nil
+}
+
+// UseBastion makes the client reach the router through an SSH tunnel opened
+// on the bastion, the connections to the router being forwarded by it. The
+// SSH connection is opened right away, to report any error at once, and
+// opened again when it is lost. It must be called before Auth.
+func (c *client) UseBastion(ctx context.Context, bastion Bastion) error {
+	signer, err := ssh.ParsePrivateKey(bastion.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse the private key of the bastion: %w", err)
+	}
+
+	hostKeyCallback, err := bastionHostKeyCallback(bastion.HostKey)
+	if err != nil {
+		return err
+	}
+
+	addr := bastion.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	tunnel := &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            bastion.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         c.timeouts.Auth(),
+		},
+	}
+	if _, err := tunnel.connect(ctx); err != nil {
+		return err
+	}
+
+	transport := c.transport()
+	// the router is reached from the bastion, not from the proxy
+	transport.Proxy = nil
+	transport.DialContext = tunnel.DialContext
+	return nil
+}
+
+func bastionHostKeyCallback(hostKey string) (ssh.HostKeyCallback, error) {
+	if hostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the host key of the bastion: %w", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("no host key set for the bastion, and no home directory to find known_hosts: %w", err)
+	}
+	callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("no host key set for the bastion, and failed to read known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// sshTunnel dials the connections through an SSH connection to addr.
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// connect returns the SSH connection, opened if there is none.
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	tflog.Debug(ctx, "bastion connection", map[string]interface{}{
+		"addr": t.addr,
+		"user": t.config.User,
+	})
+	dialer := &net.Dialer{Timeout: t.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the bastion %s: %w", t.addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to open an SSH connection to the bastion %s: %w", t.addr, err)
+	}
+	t.client = ssh.NewClient(sshConn, chans, reqs)
+	return t.client, nil
+}
+
+// reset drops client, unless another connection replaced it already.
+func (t *sshTunnel) reset(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == client {
+		client.Close() //nolint:errcheck
+		t.client = nil
+	}
+}
+
+// DialContext opens a connection to addr from the bastion. When it fails
+// because the SSH connection was lost, the connection is opened again once.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	for retry := true; ; retry = false {
+		client, err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := client.DialContext(ctx, network, addr)
+		if err == nil {
+			return conn, nil
+		}
+		if _, _, keepaliveErr := client.SendRequest("keepalive@openssh.com", true, nil); keepaliveErr == nil || !retry {
+			return nil, fmt.Errorf("failed to reach %s through the bastion %s: %w", addr, t.addr, err)
+		}
+		t.reset(client)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// fakeBastion accepts the SSH connections authenticated with clientKey, and
+// forwards their direct-tcpip channels. drop closes the open connections.
+func fakeBastion(t *testing.T, clientKey ssh.PublicKey) (addr string, hostKey ssh.PublicKey, drop func()) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, fmt.Errorf("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() }) //nolint:errcheck
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	drop = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close() //nolint:errcheck
+		}
+		conns = nil
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+						newChannel.Reject(ssh.UnknownChannelType, "unsupported") //nolint:errcheck
+						continue
+					}
+					upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+					if err != nil {
+						newChannel.Reject(ssh.ConnectionFailed, err.Error()) //nolint:errcheck
+						continue
+					}
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						upstream.Close() //nolint:errcheck
+						continue
+					}
+					go ssh.DiscardRequests(requests)
+					go func() {
+						io.Copy(channel, upstream) //nolint:errcheck
+						channel.Close()            //nolint:errcheck
+					}()
+					go func() {
+						io.Copy(upstream, channel) //nolint:errcheck
+						upstream.Close()           //nolint:errcheck
+					}()
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), hostSigner.PublicKey(), drop
+}
+
+func newTestClient(t *testing.T, remoteUrl string) *client {
+	t.Helper()
+
+	c, err := newClient(remoteUrl, &timeouts{authTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*client)
+}
+
+func TestUseBastion(t *testing.T) {
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "luci") //nolint:errcheck
+	}))
+	defer router.Close()
+
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshClientPub, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, hostKey, drop := fakeBastion(t, sshClientPub)
+
+	c := newTestClient(t, router.URL)
+	err = c.UseBastion(context.Background(), Bastion{
+		Host:       addr,
+		User:       "jump",
+		PrivateKey: pem.EncodeToMemory(block),
+		HostKey:    string(ssh.MarshalAuthorizedKey(hostKey)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := range 2 {
+		resp, err := c.client.Get(router.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close() //nolint:errcheck
+		if string(body) != "luci" {
+			t.Errorf("request %d: expected the router to answer through the bastion, got %q", i, body)
+		}
+
+		// the SSH connection is opened again once lost
+		c.client.CloseIdleConnections()
+		drop()
+	}
+}
+
+func TestUseBastionHostKeyMismatch(t *testing.T) {
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshClientPub, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, _, _ := fakeBastion(t, sshClientPub)
+
+	c := newTestClient(t, "http://router.lan")
+	err = c.UseBastion(context.Background(), Bastion{
+		Host:       addr,
+		User:       "jump",
+		PrivateKey: pem.EncodeToMemory(block),
+		HostKey:    string(ssh.MarshalAuthorizedKey(sshClientPub)),
+	})
+	if err == nil || !strings.Contains(err.Error(), "key mismatch") {
+		t.Errorf("expected the host key to be rejected, got %v", err)
+	}
+}
+
+func TestUseProxy(t *testing.T) {
+	for proxyUrl, valid := range map[string]bool{
+		"socks5://127.0.0.1:1080":    true,
+		"http://proxy.example:3128":  true,
+		"https://proxy.example:3128": true,
+		"ftp://proxy.example":        false,
+		"proxy.example:3128":         false,
+	} {
+		c := newTestClient(t, "http://router.lan")
+		err := c.UseProxy(proxyUrl)
+		if (err == nil) != valid {
+			t.Errorf("%s: unexpected result: %v", proxyUrl, err)
+			continue
+		}
+		if !valid {
+			continue
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "http://router.lan", nil)
+		u, err := c.transport().Proxy(req)
+		if err != nil || u.String() != proxyUrl {
+			t.Errorf("%s: expected the requests to go through the proxy, got %v, %v", proxyUrl, u, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ubusNullSession is the session id of the unauthenticated ubus calls, the
+// only one allowed to call session login.
+const ubusNullSession = "00000000000000000000000000000000"
+
+// UseUbusTransport makes the client go through the ubus objects of rpcd
+// instead of the LuCI RPC: the session is opened with session login, so any
+// login of /etc/config/rpcd may be used, and the calls are checked against
+// its ACLs. It must be called before Auth.
+func (c *client) UseUbusTransport() {
+	c.url.mu.Lock()
+	defer c.url.mu.Unlock()
+	c.url.ubus = true
+}
+
+// loginUbus opens a new rpcd session on the router at remoteUrl, returning
+// its token.
+func loginUbus(ctx context.Context, client *http.Client, timeout time.Duration, remoteUrl, username, password string) (string, error) {
+	tflog.Debug(ctx, "ubus authentication", map[string]interface{}{
+		"url":      remoteUrl,
+		"username": username,
+	})
+
+	result, err := callUbus(ctx, client, timeout, session{url: remoteUrl, token: ubusNullSession},
+		"session", "login", map[string]any{
+			"username": username,
+			"password": password,
+		})
+	if isUbusStatus(err, ubusStatusPermissionDenied) {
+		return "", errors.Join(ErrAuth, fmt.Errorf("invalid credentials for %s", username))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var data struct {
+		Session string `json:"ubus_rpc_session"`
+	}
+	if err := json.Unmarshal(result, &data); err != nil {
+		return "", errors.Join(ErrUnMarshal, err)
+	}
+	if data.Session == "" {
+		return "", ErrEmptyResult
+	}
+	return data.Session, nil
+}
+
+// execUbus runs a command through the exec method of the rpcd file object,
+// which requires the exec permission on the command in the ACL of the user.
+// It returns the standard output of the command.
+func execUbus(
+	ctx context.Context, client *http.Client, timeout time.Duration,
+	s session, command string, params ...string,
+) (string, error) {
+	result, err := runUbus(ctx, client, timeout, s, command, params...)
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return "", errors.Join(ErrExecutionFailure, fmt.Errorf("%s %v exited with %d: %s", command, params, result.ExitCode, result.Stderr))
+	}
+	return result.Stdout, nil
+}
+
+// runUbus runs a command like execUbus, returning its result whatever its
+// exit code.
+func runUbus(
+	ctx context.Context, client *http.Client, timeout time.Duration,
+	s session, command string, params ...string,
+) (*ExecResult, error) {
+	if params == nil {
+		params = []string{}
+	}
+	result, err := callUbus(ctx, client, timeout, s,
+		"file", "exec", map[string]any{
+			"command": command,
+			"params":  params,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Code   int    `json:"code"`
+		Stdout string `json:"stdout"`
+		Stderr string `json:"stderr"`
+	}
+	if err := json.Unmarshal(result, &data); err != nil {
+		return nil, errors.Join(ErrUnMarshal, err)
+	}
+	return &ExecResult{Stdout: data.Stdout, Stderr: data.Stderr, ExitCode: data.Code}, nil
+}
+
+func isUbusStatus(err error, code int) bool {
+	var statusErr *ubusStatusError
+	return errors.As(err, &statusErr) && statusErr.Code == code
+}
+
+// ubusAccessDenied is the JSON-RPC error code of uhttpd for the calls of an
+// unknown or expired session.
+const ubusAccessDenied = -32002
+
+// isAuthFailure reports whether the call failed because the session is not
+// valid anymore.
+func isAuthFailure(err error) bool {
+	var rpcErr *jsonRPCResponseError
+	return errors.Is(err, ErrAuth) ||
+		isUbusStatus(err, ubusStatusPermissionDenied) ||
+		errors.As(err, &rpcErr) && rpcErr.Code == ubusAccessDenied
+}
@@ -0,0 +1,286 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultSetPasswordTimeout time.Duration = 30 * time.Second
+
+	etcShadow = "/etc/shadow"
+)
+
+type UserTimeouts interface {
+	SetPassword() time.Duration
+}
+
+type UserTimeoutsModel struct {
+	SetPasswordTimeout types.String `tfsdk:"set_password"`
+}
+
+type UserFacade interface {
+	SetPassword(ctx context.Context, username, password string) error
+	GetUser(ctx context.Context, name string) (*User, error)
+	SetUser(ctx context.Context, u *User) error
+	DeleteUser(ctx context.Context, name string) error
+}
+
+type userTimeouts struct {
+	setPasswordTimeout time.Duration
+}
+
+func (uT *userTimeouts) SetPassword() time.Duration {
+	return uT.setPasswordTimeout
+}
+
+var (
+	_ UserFacade   = (*user)(nil)
+	_ UserTimeouts = (*userTimeouts)(nil)
+
+	userTimeoutSchemaAttribute = schema.SingleNestedAttribute{
+		MarkdownDescription: `User operations timeout configuration`,
+		Description:         `User operations timeout configuration`,
+		Optional:            true,
+		Attributes: map[string]schema.Attribute{
+			"set_password": schema.StringAttribute{
+				MarkdownDescription: `Set password RPC timeout value`,
+				Description:         `Set password RPC timeout value`,
+				Optional:            true,
+			},
+		},
+	}
+)
+
+func parseUserTimeouts(ctx context.Context, t *TimeoutsModel) (UserTimeouts, error) {
+	setPasswordTimeout := defaultSetPasswordTimeout
+
+	if t != nil && t.User != nil && !t.User.SetPasswordTimeout.IsNull() {
+		parsedSetPasswordTimeout, err := time.ParseDuration(t.User.SetPasswordTimeout.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		setPasswordTimeout = parsedSetPasswordTimeout
+		tflog.Debug(ctx, "user - parse timeout configuration: set_password config parsed")
+	} else {
+		tflog.Debug(ctx, "user - parse timeout configuration: default set_password config")
+	}
+
+	return &userTimeouts{
+		setPasswordTimeout,
+	}, nil
+}
+
+// User is an entry of /etc/passwd.
+type User struct {
+	Name  string
+	Uid   int
+	Gid   int
+	Gecos string
+	Home  string
+	Shell string
+}
+
+func (u *User) String() string {
+	return strings.Join([]string{
+		u.Name, "x", strconv.Itoa(u.Uid), strconv.Itoa(u.Gid), u.Gecos, u.Home, u.Shell,
+	}, ":")
+}
+
+// user manages the accounts of the router. The password goes through LuCI,
+// while the entries of /etc/passwd and /etc/shadow are edited as files.
+type user struct {
+	timeouts UserTimeouts
+	fs       FsFacade
+
+	// databasesMu serializes the read-modify-write cycles of /etc/passwd and
+	// /etc/shadow, and the password changes, which rewrite /etc/shadow too:
+	// without it, parallel resources would overwrite each other's entries.
+	// Each device having its own client, the other devices are not blocked.
+	databasesMu sync.Mutex
+
+	url    *remote
+	client *http.Client
+}
+
+// SetPassword keeps the credentials of the provider up to date when the
+// password of its own user is changed, for the later re-authentications.
+func (c *client) SetPassword(ctx context.Context, username, password string) error {
+	if err := c.UserFacade.SetPassword(ctx, username, password); err != nil {
+		return err
+	}
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+	if username == c.username {
+		c.password = password
+	}
+	return nil
+}
+
+// credentials returns the credentials of the provider, for the
+// re-authentications.
+func (c *client) credentials() (string, string) {
+	c.credentialsMu.RLock()
+	defer c.credentialsMu.RUnlock()
+	return c.username, c.password
+}
+
+func (c *user) SetPassword(ctx context.Context, username, password string) error {
+	c.databasesMu.Lock()
+	defer c.databasesMu.Unlock()
+
+	if c.url.viaUbus() {
+		// the setPassword method of the luci object of rpcd runs passwd
+		_, err := callUbus(ctx, c.client, c.timeouts.SetPassword(),
+			c.url.session(), "luci", "setPassword", map[string]any{
+				"username": username,
+				"password": password,
+			})
+		return err
+	}
+
+	result, err := call(ctx, c.client, c.timeouts.SetPassword(),
+		c.url.session(), "sys", "user.setpasswd", []any{username, password})
+	if err != nil {
+		return err
+	}
+
+	// the exit code of passwd, or a boolean with the most recent Lua versions
+	var data any
+	if err = json.Unmarshal(result, &data); err != nil {
+		return errors.Join(ErrUnMarshal, err)
+	}
+	if data != float64(0) && data != true {
+		return errors.Join(ErrExecutionFailure, fmt.Errorf("passwd %s returned %v", username, data))
+	}
+	return nil
+}
+
+// GetUser returns the entry of /etc/passwd of the given name.
+// ErrUserNotFound is returned when there is none.
+func (c *user) GetUser(ctx context.Context, name string) (*User, error) {
+	lines, err := c.readLines(ctx, etcPasswd)
+	if err != nil {
+		return nil, err
+	}
+
+	i := indexOfEntry(lines, name)
+	if i < 0 {
+		return nil, errors.Join(ErrUserNotFound, fmt.Errorf("%s in %s", name, etcPasswd))
+	}
+
+	fields := strings.Split(lines[i], ":")
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("invalid entry of %s in %s", name, etcPasswd)
+	}
+	uid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid of %s in %s: %w", name, etcPasswd, err)
+	}
+	gid, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid of %s in %s: %w", name, etcPasswd, err)
+	}
+
+	return &User{
+		Name:  name,
+		Uid:   uid,
+		Gid:   gid,
+		Gecos: fields[4],
+		Home:  fields[5],
+		Shell: fields[6],
+	}, nil
+}
+
+// SetUser adds or replaces the entry of /etc/passwd of the user. A locked
+// entry is added to /etc/shadow if the user has none yet.
+func (c *user) SetUser(ctx context.Context, u *User) error {
+	c.databasesMu.Lock()
+	defer c.databasesMu.Unlock()
+
+	lines, err := c.readLines(ctx, etcPasswd)
+	if err != nil {
+		return err
+	}
+	if i := indexOfEntry(lines, u.Name); i >= 0 {
+		lines[i] = u.String()
+	} else {
+		lines = append(lines, u.String())
+	}
+	if err := c.writeLines(ctx, etcPasswd, lines); err != nil {
+		return err
+	}
+
+	lines, err = c.readLines(ctx, etcShadow)
+	if err != nil {
+		return err
+	}
+	if indexOfEntry(lines, u.Name) >= 0 {
+		return nil
+	}
+	return c.writeLines(ctx, etcShadow, append(lines, u.Name+":*:0:0:99999:7:::"))
+}
+
+// DeleteUser removes the entries of the user from /etc/passwd and
+// /etc/shadow.
+func (c *user) DeleteUser(ctx context.Context, name string) error {
+	c.databasesMu.Lock()
+	defer c.databasesMu.Unlock()
+
+	for _, database := range []string{etcPasswd, etcShadow} {
+		lines, err := c.readLines(ctx, database)
+		if err != nil {
+			return err
+		}
+		i := indexOfEntry(lines, name)
+		if i < 0 {
+			continue
+		}
+		if err := c.writeLines(ctx, database, append(lines[:i], lines[i+1:]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *user) readLines(ctx context.Context, database string) ([]string, error) {
+	content, err := c.fs.ReadFile(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+func (c *user) writeLines(ctx context.Context, database string, lines []string) error {
+	return c.fs.Writefile(ctx, database, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// indexOfEntry returns the index of the line of name in an /etc/passwd-like
+// database, or -1.
+func indexOfEntry(lines []string, name string) int {
+	for i, line := range lines {
+		if entry, _, _ := strings.Cut(line, ":"); entry == name {
+			return i
+		}
+	}
+	return -1
+}
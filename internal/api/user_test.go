@@ -0,0 +1,70 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryFs keeps the files in memory. Its writes are slow, so that parallel
+// read-modify-write cycles overlap unless they are serialized.
+type memoryFs struct {
+	FsFacade
+
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func (f *memoryFs) ReadFile(_ context.Context, path string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return []byte(f.files[path]), nil
+}
+
+func (f *memoryFs) Writefile(_ context.Context, path string, data []byte) error {
+	time.Sleep(5 * time.Millisecond)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = string(data)
+	return nil
+}
+
+func TestSetUserParallel(t *testing.T) {
+	fs := &memoryFs{files: map[string]string{
+		etcPasswd: "root:x:0:0:root:/root:/bin/ash\n",
+		etcShadow: "root:$1$hash:19000:0:99999:7:::\n",
+	}}
+	u := &user{fs: fs}
+
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := u.SetUser(context.Background(), &User{
+				Name: fmt.Sprintf("user%d", i), Uid: 1000 + i, Gid: 1000 + i, Home: "/var", Shell: "/bin/false",
+			})
+			if err != nil {
+				t.Errorf("user%d: unexpected error: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, database := range []string{etcPasswd, etcShadow} {
+		for i := range 5 {
+			if !strings.Contains(fs.files[database], fmt.Sprintf("user%d:", i)) {
+				t.Errorf("expected user%d in %s, got %q", i, database, fs.files[database])
+			}
+		}
+		if !strings.HasPrefix(fs.files[database], "root:") {
+			t.Errorf("expected root to be kept in %s, got %q", database, fs.files[database])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package functions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/tzdata"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*posixTZFunction)(nil)
+
+type posixTZFunction struct{}
+
+func NewPosixTZFunction() function.Function {
+	return &posixTZFunction{}
+}
+
+func (f posixTZFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "posix_tz"
+}
+
+func (f posixTZFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Convert an IANA time zone name to its POSIX.1 string",
+		MarkdownDescription: "Returns the POSIX.1 string of an IANA time zone name, as expected by the `timezone` " +
+			"option of openwrt, e.g. `GMT0BST,M3.5.0/1,M10.5.0` for `Europe/London`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "zone",
+				MarkdownDescription: "The IANA time zone name, e.g. `Europe/London`.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f posixTZFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var zone string
+	resp.Error = req.Arguments.Get(ctx, &zone)
+	if resp.Error != nil {
+		return
+	}
+
+	tz, ok := tzdata.POSIX(zone)
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("unknown time zone %q", zone))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, tz)
+}
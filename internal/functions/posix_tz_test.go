@@ -0,0 +1,42 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package functions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/functions"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPosixTZ(t *testing.T) {
+	ctx := context.Background()
+	f := functions.NewPosixTZFunction()
+
+	run := func(zone string) *function.RunResponse {
+		resp := &function.RunResponse{
+			Result: function.NewResultData(types.StringUnknown()),
+		}
+		f.Run(ctx, function.RunRequest{
+			Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(zone)}),
+		}, resp)
+		return resp
+	}
+
+	resp := run("Europe/London")
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+	if expected := types.StringValue("GMT0BST,M3.5.0/1,M10.5.0"); !resp.Result.Value().Equal(expected) {
+		t.Errorf("unexpected result: got %s, want %s", resp.Result.Value(), expected)
+	}
+
+	resp = run("Europe/Londres")
+	if resp.Error == nil || resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != 0 {
+		t.Errorf("expected an error on the zone argument, got %v", resp.Error)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package functions
+
+import (
+	"context"
+
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = (*uciDecodeFunction)(nil)
+
+type uciDecodeFunction struct{}
+
+func NewUCIDecodeFunction() function.Function {
+	return &uciDecodeFunction{}
+}
+
+func (f uciDecodeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "uci_decode"
+}
+
+func (f uciDecodeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Decode the content of an UCI configuration file",
+		MarkdownDescription: "Parses the content of an UCI configuration file, as found in `/etc/config`, " +
+			"and returns its sections in the format of the `sections` attribute of `openwrt_configfile`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "text",
+				MarkdownDescription: "The UCI text to decode.",
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.ObjectType{AttrTypes: customtypes.UCISectionAttrTypes},
+		},
+	}
+}
+
+func (f uciDecodeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var text string
+	resp.Error = req.Arguments.Get(ctx, &text)
+	if resp.Error != nil {
+		return
+	}
+
+	c, err := uci.Parse(text)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	sections, diags := customtypes.NewUCISections(ctx, c)
+	if diags.HasError() {
+		resp.Error = function.FuncErrorFromDiags(ctx, diags)
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, sections)
+}
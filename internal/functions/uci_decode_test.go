@@ -0,0 +1,60 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package functions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/functions"
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestUCIDecode(t *testing.T) {
+	ctx := context.Background()
+	f := functions.NewUCIDecodeFunction()
+
+	run := func(text string) *function.RunResponse {
+		resp := &function.RunResponse{
+			Result: function.NewResultData(types.ListUnknown(types.ObjectType{AttrTypes: customtypes.UCISectionAttrTypes})),
+		}
+		f.Run(ctx, function.RunRequest{
+			Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(text)}),
+		}, resp)
+		return resp
+	}
+
+	resp := run("config interface 'lan'\n\toption proto 'static'\n\tlist dns '1.1.1.1'\n\nconfig rule\n")
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+
+	expected := types.ListValueMust(types.ObjectType{AttrTypes: customtypes.UCISectionAttrTypes}, []attr.Value{
+		types.ObjectValueMust(customtypes.UCISectionAttrTypes, map[string]attr.Value{
+			"type":    types.StringValue("interface"),
+			"name":    types.StringValue("lan"),
+			"options": types.MapValueMust(types.StringType, map[string]attr.Value{"proto": types.StringValue("static")}),
+			"lists": types.MapValueMust(types.ListType{ElemType: types.StringType}, map[string]attr.Value{
+				"dns": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("1.1.1.1")}),
+			}),
+		}),
+		types.ObjectValueMust(customtypes.UCISectionAttrTypes, map[string]attr.Value{
+			"type":    types.StringValue("rule"),
+			"name":    types.StringNull(),
+			"options": types.MapNull(types.StringType),
+			"lists":   types.MapNull(types.ListType{ElemType: types.StringType}),
+		}),
+	})
+	if !resp.Result.Value().Equal(expected) {
+		t.Errorf("unexpected result:\n got: %s\nwant: %s", resp.Result.Value(), expected)
+	}
+
+	resp = run("config system\n\toption hostname 'router\n")
+	if resp.Error == nil || resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != 0 {
+		t.Errorf("expected an error on the text argument, got %v", resp.Error)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+)
+
+var _ api.Devices = (*devices)(nil)
+
+// deviceSettings are the remote and the credentials of a device.
+type deviceSettings struct {
+	remote, username, password string
+}
+
+// device is connected on first use, and kept for the other resources. A
+// failed connection is attempted again by the next resource.
+type device struct {
+	mu       sync.Mutex
+	settings deviceSettings
+	client   api.Client
+}
+
+// devices are the routers of the provider configuration, the default one
+// being "".
+type devices struct {
+	devices map[string]*device
+	connect func(ctx context.Context, settings deviceSettings) (api.Client, error)
+}
+
+func (d *devices) HasDevice(name string) bool {
+	_, ok := d.devices[name]
+	return ok
+}
+
+func (d *devices) Device(ctx context.Context, name string) (api.Client, error) {
+	dev, ok := d.devices[name]
+	if !ok {
+		if name == "" {
+			return nil, fmt.Errorf("the provider has no remote, select one of its devices with the device attribute")
+		}
+		return nil, fmt.Errorf("%w %q, it is not in the devices of the provider", api.ErrUnknownDevice, name)
+	}
+
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	if dev.client != nil {
+		return dev.client, nil
+	}
+	client, err := d.connect(ctx, dev.settings)
+	if err != nil {
+		if name != "" {
+			return nil, fmt.Errorf("device %q: %w", name, err)
+		}
+		return nil, err
+	}
+	dev.client = client
+	return client, nil
+}
@@ -5,24 +5,39 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"regexp"
+	"time"
 
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/functions"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/cron"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/dropbear"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/exec"
 	"github.com/foxboron/terraform-provider-openwrt/internal/resources/fs"
 	"github.com/foxboron/terraform-provider-openwrt/internal/resources/opkg"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/rpcd"
 	"github.com/foxboron/terraform-provider-openwrt/internal/resources/service"
 	"github.com/foxboron/terraform-provider-openwrt/internal/resources/system"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/uhttpd"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/user"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var (
-	_ provider.Provider = (*OpenWRTProvider)(nil)
+	_ provider.Provider              = (*OpenWRTProvider)(nil)
+	_ provider.ProviderWithFunctions = (*OpenWRTProvider)(nil)
 
 	openWRTRemoteEnv,
 	openWRTRemoteEnvSet = os.LookupEnv("OPENWRT_REMOTE")
@@ -54,9 +69,42 @@ type OpenWRTProviderModel struct {
 	Password types.String `tfsdk:"password"`
 	Remote   types.String `tfsdk:"remote"`
 
-	ApiTimeouts *api.TimeoutsModel `tfsdk:"api_timeouts"`
+	Transport types.String  `tfsdk:"transport"`
+	ProxyUrl  types.String  `tfsdk:"proxy_url"`
+	Bastion   *BastionModel `tfsdk:"bastion"`
+
+	Devices map[string]DeviceModel `tfsdk:"devices"`
+
+	ApiTimeouts    *api.TimeoutsModel   `tfsdk:"api_timeouts"`
+	ConfirmedApply *ConfirmedApplyModel `tfsdk:"confirmed_apply"`
+}
+
+type ConfirmedApplyModel struct {
+	RollbackTimeout types.String `tfsdk:"rollback_timeout"`
+}
+
+type DeviceModel struct {
+	Remote   types.String `tfsdk:"remote"`
+	User     types.String `tfsdk:"user"`
+	Password types.String `tfsdk:"password"`
+}
+
+type BastionModel struct {
+	Host       types.String `tfsdk:"host"`
+	User       types.String `tfsdk:"user"`
+	PrivateKey types.String `tfsdk:"private_key"`
+	HostKey    types.String `tfsdk:"host_key"`
 }
 
+var deviceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+const defaultRollbackTimeout = 90 * time.Second
+
+const (
+	transportLuci = "luci"
+	transportUbus = "ubus"
+)
+
 func (p *OpenWRTProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "openwrt"
 	resp.Version = p.version
@@ -84,7 +132,104 @@ The JSON RPC API requires a couple of packages to be used. Please see [Using the
 				Description:         `The username of the admin account. Optionally OPENWRT_REMOTE env variable can be set and used to specify the remote url. One between this attribute or the env variable must be set`,
 				Optional:            true,
 			},
+			"transport": schema.StringAttribute{
+				MarkdownDescription: "How the provider talks to the router, one of `luci` or `ubus`. (Default: `luci`)\n\n" +
+					"`luci` goes through the LuCI JSON RPC API, which requires the `root` account or another account with full access. " +
+					"`ubus` goes through the `/ubus` endpoint of uhttpd and authenticates with any `config login` of `/etc/config/rpcd`, such as the ones of `openwrt_rpcd_login`: " +
+					"every call is checked against the ACLs of the login, so it must grant the `uci`, `file`, `rc` and `luci` objects the managed resources need. " +
+					"Files are changed with `file exec` of `/bin/chmod`, `/bin/chown`, `/bin/mv` and `/bin/cp`, packages with `/bin/opkg`, and the commands of `openwrt_exec` with `/bin/sh`. " +
+					"With `ubus`, the package lists are not updated when the provider is configured.",
+				Description: "How the provider talks to the router, one of luci or ubus. (Default: luci)",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(transportLuci, transportUbus),
+				},
+			},
+			"devices": schema.MapNestedAttribute{
+				MarkdownDescription: "The routers managed by the provider besides the `remote` one, by device name. " +
+					"A resource is on one of them when its `device` attribute is set to its name, and on the `remote` router otherwise. " +
+					"Each device is connected to when a resource first needs it, with the `transport`, `proxy_url`, `bastion`, `api_timeouts` and `confirmed_apply` settings of the provider. " +
+					"Without `remote`, the `device` attribute must be set on every resource.",
+				Description: "The routers managed by the provider besides the remote one, by device name, selected by the device attribute of the resources.",
+				Optional:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"remote": schema.StringAttribute{
+							MarkdownDescription: "The URL of the JSON RPC API of the device.",
+							Description:         "The URL of the JSON RPC API of the device.",
+							Required:            true,
+						},
+						"user": schema.StringAttribute{
+							MarkdownDescription: "The username of the account on the device. (Default: the `user` of the provider)",
+							Description:         "The username of the account on the device. (Default: the user of the provider)",
+							Optional:            true,
+						},
+						"password": schema.StringAttribute{
+							MarkdownDescription: "The password of the account on the device. (Default: the `password` of the provider)",
+							Description:         "The password of the account on the device. (Default: the password of the provider)",
+							Optional:            true,
+							Sensitive:           true,
+						},
+					},
+				},
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(stringvalidator.RegexMatches(deviceNameRegexp, "must only contain letters, digits, dashes and underscores")),
+				},
+			},
+			"proxy_url": schema.StringAttribute{
+				MarkdownDescription: "The URL of the proxy the router is reached through, with the `http`, `https` or `socks5` scheme. " +
+					"Without it, the proxy of the `HTTPS_PROXY` or `HTTP_PROXY` env variables is used, if any.",
+				Description: "The URL of the proxy the router is reached through, with the http, https or socks5 scheme. Without it, the proxy of the HTTPS_PROXY or HTTP_PROXY env variables is used, if any.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("bastion")),
+				},
+			},
+			"bastion": schema.SingleNestedAttribute{
+				MarkdownDescription: "Reach the router through an SSH tunnel opened on a bastion, e.g. when it sits behind a NAT. " +
+					"The connections to the `remote` URL are forwarded by the bastion, so its host is resolved from there.",
+				Description: "Reach the router through an SSH tunnel opened on a bastion, e.g. when it sits behind a NAT.",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"host": schema.StringAttribute{
+						MarkdownDescription: "The address of the bastion, with an optional port. (Default port: 22)",
+						Description:         "The address of the bastion, with an optional port. (Default port: 22)",
+						Required:            true,
+					},
+					"user": schema.StringAttribute{
+						MarkdownDescription: "The user the SSH connection is opened as.",
+						Description:         "The user the SSH connection is opened as.",
+						Required:            true,
+					},
+					"private_key": schema.StringAttribute{
+						MarkdownDescription: "The unencrypted private key the SSH connection is authenticated with, in the PEM or OpenSSH format.",
+						Description:         "The unencrypted private key the SSH connection is authenticated with, in the PEM or OpenSSH format.",
+						Required:            true,
+						Sensitive:           true,
+					},
+					"host_key": schema.StringAttribute{
+						MarkdownDescription: "The public key of the bastion, in the `authorized_keys` format. Without it, the key must be in `~/.ssh/known_hosts`.",
+						Description:         "The public key of the bastion, in the authorized_keys format. Without it, the key must be in ~/.ssh/known_hosts.",
+						Optional:            true,
+					},
+				},
+			},
 			"api_timeouts": api.TimeoutSchemaAttribute,
+			"confirmed_apply": schema.SingleNestedAttribute{
+				MarkdownDescription: "Apply the UCI changes the way LuCI does, to avoid being locked out of the router by a bad network or firewall change. " +
+					"Instead of committing them, the changes are applied through ubus `uci apply` with a rollback timer. " +
+					"They are confirmed with `uci confirm` only if the provider can authenticate again afterwards, otherwise the router restores the previous configuration by itself. " +
+					"`openwrt_configfile` stages the new content as UCI changes instead of writing the file, so that it is covered by the rollback.",
+				Description: "Apply the UCI changes the way LuCI does, with a rollback timer that is only cancelled if the provider can authenticate again afterwards.",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"rollback_timeout": schema.StringAttribute{
+						MarkdownDescription: "Time after which the router rolls the changes back if they are not confirmed. (Default: 90s)",
+						Description:         "Time after which the router rolls the changes back if they are not confirmed. (Default: 90s)",
+						Optional:            true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -92,7 +237,6 @@ The JSON RPC API requires a couple of packages to be used. Please see [Using the
 func (p *OpenWRTProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var (
 		data OpenWRTProviderModel
-		c    api.Client
 		err  error
 	)
 
@@ -106,41 +250,131 @@ func (p *OpenWRTProvider) Configure(ctx context.Context, req provider.ConfigureR
 		remoteUrl = openWRTRemoteEnv
 	}
 
+	username, password := data.User.ValueString(), data.Password.ValueString()
+	if openWRTUserEnvSet && openWRTPasswordEnvSet {
+		username = openWRTUserEnv
+		password = openWRTPasswordEnv
+	}
+
 	apiTimeouts, err := p.clientFactory.ParseTimeouts(ctx, data.ApiTimeouts)
 	if err != nil {
 		resp.Diagnostics.AddError("failed to parse timeouts", err.Error())
 		return
 	}
-	c, err = p.clientFactory.Get(ctx, remoteUrl, apiTimeouts)
-	if err != nil {
-		resp.Diagnostics.AddError("failed to instantiate remote client", err.Error())
-		return
+
+	rollbackTimeout := time.Duration(0)
+	if data.ConfirmedApply != nil {
+		rollbackTimeout = defaultRollbackTimeout
+		if !data.ConfirmedApply.RollbackTimeout.IsNull() {
+			rollbackTimeout, err = time.ParseDuration(data.ConfirmedApply.RollbackTimeout.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddError("failed to parse rollback timeout", err.Error())
+				return
+			}
+		}
 	}
 
-	username, password := data.User.ValueString(), data.Password.ValueString()
-	if openWRTUserEnvSet && openWRTPasswordEnvSet {
-		username = openWRTUserEnv
-		password = openWRTPasswordEnv
+	devices := &devices{
+		devices: map[string]*device{},
+		connect: func(ctx context.Context, settings deviceSettings) (api.Client, error) {
+			return p.connect(ctx, data, apiTimeouts, rollbackTimeout, settings)
+		},
+	}
+	// the remote of the provider is the default device, unless only the
+	// devices are set
+	if remoteUrl != "" || len(data.Devices) == 0 {
+		devices.devices[""] = &device{settings: deviceSettings{remoteUrl, username, password}}
+	}
+	for name, d := range data.Devices {
+		settings := deviceSettings{d.Remote.ValueString(), username, password}
+		if !d.User.IsNull() {
+			settings.username = d.User.ValueString()
+		}
+		if !d.Password.IsNull() {
+			settings.password = d.Password.ValueString()
+		}
+		devices.devices[name] = &device{settings: settings}
 	}
-	err = c.Auth(ctx, username, password)
+
+	// the default device is connected at once, to report any error here
+	if devices.HasDevice("") {
+		if _, err := devices.Device(ctx, ""); err != nil {
+			resp.Diagnostics.AddError("failed to connect to the router", err.Error())
+			return
+		}
+	}
+
+	resp.DataSourceData = devices
+	resp.ResourceData = devices
+}
+
+// connect returns the authenticated client of a device.
+func (p *OpenWRTProvider) connect(
+	ctx context.Context, data OpenWRTProviderModel, apiTimeouts api.Timeouts, rollbackTimeout time.Duration, settings deviceSettings,
+) (api.Client, error) {
+	c, err := p.clientFactory.Get(ctx, settings.remote, apiTimeouts)
 	if err != nil {
-		resp.Diagnostics.AddError("failed to auth towards openwrt API", err.Error())
-		return
+		return nil, fmt.Errorf("failed to instantiate remote client: %w", err)
 	}
 
-	err = c.UpdatePackages(ctx)
+	if !data.ProxyUrl.IsNull() {
+		err = c.UseProxy(data.ProxyUrl.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure the proxy: %w", err)
+		}
+	}
+
+	if data.Bastion != nil {
+		err = c.UseBastion(ctx, api.Bastion{
+			Host:       data.Bastion.Host.ValueString(),
+			User:       data.Bastion.User.ValueString(),
+			PrivateKey: []byte(data.Bastion.PrivateKey.ValueString()),
+			HostKey:    data.Bastion.HostKey.ValueString(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the bastion: %w", err)
+		}
+	}
+
+	viaUbus := data.Transport.ValueString() == transportUbus
+	if viaUbus {
+		c.UseUbusTransport()
+	}
+
+	err = c.Auth(ctx, settings.username, settings.password)
 	if err != nil {
-		resp.Diagnostics.AddError("packages update in error", err.Error())
-		return
+		return nil, fmt.Errorf("failed to auth towards openwrt API: %w", err)
 	}
 
-	resp.DataSourceData = c
-	resp.ResourceData = c
+	if rollbackTimeout > 0 {
+		c.EnableConfirmedApply(rollbackTimeout)
+	}
+
+	if !viaUbus {
+		err = c.UpdatePackages(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("packages update in error: %w", err)
+		}
+	}
+
+	return c, nil
 }
 
 func (p *OpenWRTProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		system.NewSystemResource,
+		system.NewNtpResource,
+		system.NewLedResource,
+		user.NewUserResource,
+		user.NewPasswordResource,
+		dropbear.NewDropbearResource,
+		dropbear.NewAuthorizedKeyResource,
+		rpcd.NewLoginResource,
+		rpcd.NewAclResource,
+		uhttpd.NewUhttpdResource,
+		uhttpd.NewCertificateResource,
+		cron.NewJobResource,
+		exec.NewExecResource,
 		fs.NewConfigFileResource,
 		fs.NewFileResource,
 		opkg.NewOpkgResource,
@@ -153,9 +387,15 @@ func (p *OpenWRTProvider) EphemeralResources(ctx context.Context) []func() ephem
 }
 
 func (p *OpenWRTProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		system.NewNtpDataSource,
+		system.NewLedsDataSource,
+	}
 }
 
 func (p *OpenWRTProvider) Functions(ctx context.Context) []func() function.Function {
-	return []func() function.Function{}
+	return []func() function.Function{
+		functions.NewUCIDecodeFunction,
+		functions.NewPosixTZFunction,
+	}
 }
@@ -0,0 +1,485 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package cron
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	crontabsDir = "/etc/crontabs"
+	cronService = "cron"
+
+	// markerPrefix starts the comment line preceding each managed job.
+	markerPrefix = "# openwrt_cron_job: "
+)
+
+var (
+	jobNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// cronUserRegexp follows the default NAME_REGEX of useradd.
+	cronUserRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+)
+
+type jobModel struct {
+	Name     types.String `tfsdk:"name"`
+	User     types.String `tfsdk:"user"`
+	Schedule types.String `tfsdk:"schedule"`
+	Command  types.String `tfsdk:"command"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type jobResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*jobResource)(nil)
+	_ resource.ResourceWithImportState = (*jobResource)(nil)
+)
+
+// NewJobResource return new cron job resource.
+func NewJobResource() resource.Resource {
+	return &jobResource{}
+}
+
+func (j jobResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_cron_job", req.ProviderTypeName)
+}
+
+func (j jobResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a job of a crontab in `/etc/crontabs`. The job line is preceded by a `" + markerPrefix + "<name>` comment, which identifies it; the other lines of the crontab are left untouched. `cron` is restarted after each change.",
+		Description:         "Manage a job of a crontab in /etc/crontabs. The job line is preceded by a \"" + markerPrefix + "<name>\" comment, which identifies it; the other lines of the crontab are left untouched. cron is restarted after each change.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the job, unique within the crontab, e.g. `logrotate`.",
+				Description:         "Name of the job, unique within the crontab, e.g. logrotate.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(jobNameRegexp, "must only contain letters, digits, dots, dashes and underscores"),
+				},
+			},
+			"user": schema.StringAttribute{
+				MarkdownDescription: "Account the job runs as, i.e. the name of the crontab. (Default: `root`)",
+				Description:         "Account the job runs as, i.e. the name of the crontab. (Default: root)",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("root"),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(cronUserRegexp, "must be a valid user name"),
+				},
+			},
+			"schedule": schema.StringAttribute{
+				MarkdownDescription: "Schedule of the job, i.e. the minute, hour, day of month, month and day of week fields separated by single spaces, e.g. `30 3 * * mon-fri`.",
+				Description:         "Schedule of the job, i.e. the minute, hour, day of month, month and day of week fields separated by single spaces, e.g. 30 3 * * mon-fri.",
+				Required:            true,
+				Validators: []validator.String{
+					scheduleValidator{},
+				},
+			},
+			"command": schema.StringAttribute{
+				MarkdownDescription: "Command run by the shell, on a single line.",
+				Description:         "Command run by the shell, on a single line.",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[^\n]*\S[^\n]*$`), "must be a non-empty single line"),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (j *jobResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	j.devices = devices
+}
+
+func (j jobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan jobModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	j.provider, diags = api.DeviceClient(ctx, j.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := crontab(plan.User.ValueString())
+	defer api.LockFile(j.provider, file)()
+
+	lines, exists, err := api.ReadLines(ctx, j.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	if indexOfJob(lines, plan.Name.ValueString()) >= 0 {
+		resp.Diagnostics.AddError(fmt.Sprintf("Job %q is already in %s", plan.Name.ValueString(), file),
+			"Import it to manage it with this resource.")
+		return
+	}
+
+	resp.Diagnostics.Append(j.writeLines(ctx, file, append(lines, plan.lines()...), exists)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (j jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state jobModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	j.provider, diags = api.DeviceClient(ctx, j.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := crontab(state.User.ValueString())
+	defer api.LockFile(j.provider, file)()
+
+	lines, _, err := api.ReadLines(ctx, j.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	i := indexOfJob(lines, state.Name.ValueString())
+	if i < 0 || i+1 >= len(lines) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	schedule, command, err := parseJob(lines[i+1])
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Invalid job %q in %s", state.Name.ValueString(), file), err.Error())
+		return
+	}
+	state.Schedule = types.StringValue(schedule)
+	state.Command = types.StringValue(command)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (j jobResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan jobModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	j.provider, diags = api.DeviceClient(ctx, j.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := crontab(plan.User.ValueString())
+	defer api.LockFile(j.provider, file)()
+
+	lines, exists, err := api.ReadLines(ctx, j.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	if i := indexOfJob(lines, plan.Name.ValueString()); i >= 0 {
+		lines = removeJob(lines, i)
+	}
+
+	resp.Diagnostics.Append(j.writeLines(ctx, file, append(lines, plan.lines()...), exists)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (j jobResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state jobModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	j.provider, diags = api.DeviceClient(ctx, j.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := crontab(state.User.ValueString())
+	defer api.LockFile(j.provider, file)()
+
+	lines, exists, err := api.ReadLines(ctx, j.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	i := indexOfJob(lines, state.Name.ValueString())
+	if i < 0 {
+		return
+	}
+
+	resp.Diagnostics.Append(j.writeLines(ctx, file, removeJob(lines, i), exists)...)
+}
+
+// ImportState accepts the name of the job, prefixed by the user and a comma
+// when it is not in the crontab of root, e.g. backup,rsync.
+func (j jobResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, j.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	j.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("device"), device)...)
+
+	user, name, found := strings.Cut(id, ",")
+	if !found {
+		user, name = "root", id
+	}
+	if !cronUserRegexp.MatchString(user) || !jobNameRegexp.MatchString(name) {
+		resp.Diagnostics.AddError("Failed to import state",
+			fmt.Sprintf("expected the name of a job, optionally prefixed by a user and a comma, got %q", id))
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("user"), user)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("name"), name)...)
+}
+
+func crontab(user string) string {
+	return path.Join(crontabsDir, user)
+}
+
+// lines returns the marker and the job lines.
+func (m jobModel) lines() []string {
+	return []string{
+		markerPrefix + m.Name.ValueString(),
+		m.Schedule.ValueString() + " " + m.Command.ValueString(),
+	}
+}
+
+// writeLines writes the crontab back and restarts cron to load it. A new
+// crontab is only readable by root, as the ones of crontab -e.
+func (j jobResource) writeLines(ctx context.Context, file string, lines []string, exists bool) (diags diag.Diagnostics) {
+	if err := api.WriteLines(ctx, j.provider, file, lines, exists, "0600"); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to write %s", file), err.Error())
+		return diags
+	}
+
+	if err := j.provider.RestartService(ctx, cronService); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to restart %s", cronService), err.Error())
+	}
+	return diags
+}
+
+// indexOfJob returns the index of the marker line of the named job, or -1.
+func indexOfJob(lines []string, name string) int {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == markerPrefix+name {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeJob removes the marker line at i, and the job line following it
+// unless it is a comment or missing.
+func removeJob(lines []string, i int) []string {
+	end := i + 1
+	if end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "#") {
+		end++
+	}
+	return append(lines[:i:i], lines[end:]...)
+}
+
+// parseJob splits a job line into its schedule and its command.
+func parseJob(line string) (string, string, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 || strings.HasPrefix(fields[0], "#") {
+		return "", "", fmt.Errorf("%q is not a job line", line)
+	}
+
+	command := strings.TrimSpace(line)
+	for range 5 {
+		command = strings.TrimLeft(command, " \t")
+		command = command[strings.IndexAny(command, " \t"):]
+	}
+	return strings.Join(fields[:5], " "), strings.TrimLeft(command, " \t"), nil
+}
+
+// scheduleField is a field of the schedule, with its bounds and the names it
+// accepts, the first name standing for the lower bound.
+type scheduleField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// value parses a single value of the field, a number or a name.
+func (f scheduleField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%q is not a valid %s, expected a value between %d and %d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// check parses the field, a comma separated list of *, values or ranges, the
+// last two optionally followed by a step.
+func (f scheduleField) check(s string) error {
+	for _, item := range strings.Split(s, ",") {
+		rangePart, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			if v, err := strconv.Atoi(step); err != nil || v < 1 {
+				return fmt.Errorf("%q is not a valid step of the %s", step, f.name)
+			}
+		}
+		if rangePart == "*" {
+			continue
+		}
+
+		low, high, isRange := strings.Cut(rangePart, "-")
+		lowValue, err := f.value(low)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+		highValue, err := f.value(high)
+		if err != nil {
+			return err
+		}
+		if highValue < lowValue {
+			return fmt.Errorf("%q is not a valid range of the %s", rangePart, f.name)
+		}
+	}
+	return nil
+}
+
+// scheduleValidator checks that the value is a 5-field cron expression.
+type scheduleValidator struct{}
+
+func (v scheduleValidator) Description(_ context.Context) string {
+	return "value must be a cron expression of 5 fields separated by single spaces"
+}
+
+func (v scheduleValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v scheduleValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	fields := strings.Split(value, " ")
+	if len(fields) != len(scheduleFields) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid schedule",
+			fmt.Sprintf("%q is not made of %d fields separated by single spaces", value, len(scheduleFields)))
+		return
+	}
+	for i, field := range scheduleFields {
+		if err := field.check(fields[i]); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path, "Invalid schedule", err.Error())
+		}
+	}
+}
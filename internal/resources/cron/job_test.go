@@ -0,0 +1,265 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package cron_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+)
+
+// expectRestarts makes the client count the restarts of cron.
+func expectRestarts(client *mocks.MockClient, restarts *testutil.Restarts) {
+	client.
+		EXPECT().
+		RestartService(gomock.Any(), "cron").
+		DoAndReturn(restarts.RestartService).
+		AnyTimes()
+}
+
+func TestAccCronJob(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	restarts := testutil.NewRestarts("cron")
+	expectRestarts(client, restarts)
+	crontabs := testutil.NewFakeFiles(client, map[string]string{
+		"/etc/crontabs/root": "# clean up\n0 4 * * * rm -rf /tmp/cache\n# openwrt_cron_job: reboot\n0 5 * * sun reboot\n",
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_cron_job" "backup" {
+					name     = "backup"
+					schedule = "0 4 * *"
+					command  = "/usr/bin/backup"
+				}`,
+				ExpectError: regexp.MustCompile(`is not made of 5 fields separated by single spaces`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_cron_job" "backup" {
+					name     = "backup"
+					schedule = "0 24 * foo 1-9"
+					command  = "/usr/bin/backup"
+				}`,
+				ExpectError: regexp.MustCompile(`(?s)"24" is not a valid hour.*"foo" is not a valid month.*"9" is not a valid\s+day of week`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_cron_job" "reboot" {
+					name     = "reboot"
+					schedule = "0 5 * * sun"
+					command  = "reboot"
+				}`,
+				ExpectError: regexp.MustCompile(`Job "reboot" is already in /etc/crontabs/root`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_cron_job" "backup" {
+					name     = "backup"
+					schedule = "*/15 0-6,22-23 * jan-mar mon-fri"
+					command  = "/usr/bin/backup --quiet"
+				}
+
+				resource "openwrt_cron_job" "sync" {
+					name     = "sync"
+					user     = "backup"
+					schedule = "@reboot"
+					command  = "/usr/bin/sync"
+				}`,
+				ExpectError: regexp.MustCompile(`"@reboot" is not made of 5 fields`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_cron_job" "backup" {
+					name     = "backup"
+					schedule = "*/15 0-6,22-23 * jan-mar mon-fri"
+					command  = "/usr/bin/backup --quiet"
+				}
+
+				resource "openwrt_cron_job" "sync" {
+					name     = "sync"
+					user     = "backup"
+					schedule = "0 1 * * *"
+					command  = "/usr/bin/sync"
+				}
+
+				resource "openwrt_cron_job" "ping" {
+					name     = "ping"
+					schedule = "* * * * *"
+					command  = "ping -c 1 192.168.1.1"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_cron_job.backup", "user", "root"),
+					crontabs.CheckFile("/etc/crontabs/backup",
+						"# openwrt_cron_job: sync\n0 1 * * * /usr/bin/sync\n", "0600"),
+					restarts.Check(3),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_cron_job" "backup" {
+					name     = "backup"
+					schedule = "30 2 * * *"
+					command  = "/usr/bin/backup  --verbose"
+				}
+
+				resource "openwrt_cron_job" "sync" {
+					name     = "sync"
+					user     = "backup"
+					schedule = "0 1 * * *"
+					command  = "/usr/bin/sync"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					crontabs.CheckFile("/etc/crontabs/root",
+						"# clean up\n0 4 * * * rm -rf /tmp/cache\n# openwrt_cron_job: reboot\n0 5 * * sun reboot\n"+
+							"# openwrt_cron_job: backup\n30 2 * * * /usr/bin/backup  --verbose\n", ""),
+					restarts.Check(5),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_cron_job.backup", plancheck.ResourceActionUpdate),
+						plancheck.ExpectResourceAction("openwrt_cron_job.ping", plancheck.ResourceActionDestroy),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_cron_job.backup",
+				ImportState:                          true,
+				ImportStateId:                        "backup",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+			{
+				ResourceName:                         "openwrt_cron_job.sync",
+				ImportState:                          true,
+				ImportStateId:                        "backup,sync",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			crontabs.CheckFile("/etc/crontabs/root",
+				"# clean up\n0 4 * * * rm -rf /tmp/cache\n# openwrt_cron_job: reboot\n0 5 * * sun reboot\n", ""),
+			crontabs.CheckFile("/etc/crontabs/backup", "", "0600"),
+			restarts.Check(7),
+		),
+	})
+}
+
+func TestAccCronJobDevices(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	crontabs := testutil.NewFakeFiles(client, map[string]string{})
+	expectRestarts(client, testutil.NewRestarts("cron"))
+
+	// the user of the provider is used along with the password of the device
+	branch := testutil.RouterMocks(ctrl, "branch")
+	branchCrontabs := testutil.NewFakeFiles(branch, map[string]string{})
+	expectRestarts(branch, testutil.NewRestarts("cron"))
+	clientFactory.
+		EXPECT().
+		Get(gomock.Any(), "http://branch.lan", gomock.Any()).
+		Return(branch, nil).
+		AnyTimes()
+
+	const devicesConfig = `
+	provider "openwrt" {
+		user     = "root"
+		password = "test"
+		remote   = "http://test.lan:8080"
+		devices = {
+			branch = {
+				remote   = "http://branch.lan"
+				password = "branch"
+			}
+		}
+	}
+	`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: devicesConfig + `
+				resource "openwrt_cron_job" "backup" {
+					device   = "nowhere"
+					name     = "backup"
+					schedule = "0 4 * * *"
+					command  = "/usr/bin/backup"
+				}`,
+				ExpectError: regexp.MustCompile(`unknown device "nowhere"`),
+			},
+			{
+				Config: devicesConfig + `
+				resource "openwrt_cron_job" "backup" {
+					name     = "backup"
+					schedule = "0 4 * * *"
+					command  = "/usr/bin/backup"
+				}
+
+				resource "openwrt_cron_job" "branch_sync" {
+					device   = "branch"
+					name     = "sync"
+					schedule = "0 5 * * *"
+					command  = "/usr/bin/sync"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					crontabs.CheckFile("/etc/crontabs/root", "# openwrt_cron_job: backup\n0 4 * * * /usr/bin/backup\n", "0600"),
+					branchCrontabs.CheckFile("/etc/crontabs/root", "# openwrt_cron_job: sync\n0 5 * * * /usr/bin/sync\n", "0600"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_cron_job.branch_sync",
+				ImportState:                          true,
+				ImportStateId:                        "branch:sync",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			crontabs.CheckFile("/etc/crontabs/root", "", "0600"),
+			branchCrontabs.CheckFile("/etc/crontabs/root", "", "0600"),
+		),
+	})
+}
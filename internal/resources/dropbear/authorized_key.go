@@ -0,0 +1,461 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package dropbear
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+)
+
+const authorizedKeysFile = "/etc/dropbear/authorized_keys"
+
+type authorizedKeyModel struct {
+	Fingerprint types.String `tfsdk:"fingerprint"`
+	Key         types.String `tfsdk:"key"`
+	Options     types.String `tfsdk:"options"`
+	File        types.String `tfsdk:"file"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type authorizedKeyResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure      = (*authorizedKeyResource)(nil)
+	_ resource.ResourceWithImportState    = (*authorizedKeyResource)(nil)
+	_ resource.ResourceWithModifyPlan     = (*authorizedKeyResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*authorizedKeyResource)(nil)
+)
+
+// NewAuthorizedKeyResource return new ssh authorized key resource.
+func NewAuthorizedKeyResource() resource.Resource {
+	return &authorizedKeyResource{}
+}
+
+func (a authorizedKeyResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_ssh_authorized_key", req.ProviderTypeName)
+}
+
+func (a authorizedKeyResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a line of an `authorized_keys` file of dropbear. The other lines of the file are left untouched, the keys are matched by their fingerprint.",
+		Description:         "Manage a line of an authorized_keys file of dropbear. The other lines of the file are left untouched, the keys are matched by their fingerprint.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"fingerprint": schema.StringAttribute{
+				MarkdownDescription: "SHA256 fingerprint of the key, e.g. `SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8`.",
+				Description:         "SHA256 fingerprint of the key, e.g. SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "Public key in the OpenSSH format, i.e. its type, its base64 encoding and an optional comment, e.g. `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI... admin@example.com`.",
+				Description:         "Public key in the OpenSSH format, i.e. its type, its base64 encoding and an optional comment, e.g. ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI... admin@example.com.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					publicKeyValidator{},
+				},
+			},
+			"options": schema.StringAttribute{
+				MarkdownDescription: "Comma separated options preceding the key, e.g. `no-port-forwarding,command=\"/usr/bin/backup\"`.",
+				Description:         "Comma separated options preceding the key, e.g. no-port-forwarding,command=\"/usr/bin/backup\".",
+				Optional:            true,
+			},
+			"file": schema.StringAttribute{
+				MarkdownDescription: "Absolute path of the `authorized_keys` file. (Default: `" + authorizedKeysFile + "`)",
+				Description:         "Absolute path of the authorized_keys file. (Default: " + authorizedKeysFile + ")",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(authorizedKeysFile),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (a *authorizedKeyResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	a.devices = devices
+}
+
+// ValidateConfig checks the line made of the options and the key, as the
+// options can only be parsed along with a key.
+func (a authorizedKeyResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config authorizedKeyModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.File.IsNull() && !config.File.IsUnknown() && !path.IsAbs(config.File.ValueString()) {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("file"), "Invalid file",
+			fmt.Sprintf("expected an absolute file path, got %q", config.File.ValueString()))
+	}
+
+	if config.Options.IsNull() || config.Options.IsUnknown() || config.Key.IsUnknown() {
+		return
+	}
+	// the options may hold quoted spaces, e.g. command="rsync --server",
+	// ssh.ParseAuthorizedKey tells where they end
+	k, err := parseAuthorizedKey(config.line())
+	if err != nil || len(k.options) == 0 || strings.Contains(config.Options.ValueString(), "\n") {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("options"), "Invalid options",
+			fmt.Sprintf("%q is not a comma separated list of options", config.Options.ValueString()))
+	}
+}
+
+// ModifyPlan computes the fingerprint of a new key.
+func (a authorizedKeyResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan authorizedKeyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || !plan.Fingerprint.IsUnknown() || plan.Key.IsUnknown() {
+		return
+	}
+
+	if pub, err := parseAuthorizedKey(plan.Key.ValueString()); err == nil {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, fwpath.Root("fingerprint"), ssh.FingerprintSHA256(pub.key))...)
+	}
+}
+
+func (a authorizedKeyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan authorizedKeyModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pub, err := parseAuthorizedKey(plan.Key.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("key"), "Invalid public key", err.Error())
+		return
+	}
+	plan.Fingerprint = types.StringValue(ssh.FingerprintSHA256(pub.key))
+
+	file := plan.File.ValueString()
+	defer api.LockFile(a.provider, file)()
+
+	lines, exists, err := api.ReadLines(ctx, a.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	if indexOfKey(lines, plan.Fingerprint.ValueString()) >= 0 {
+		resp.Diagnostics.AddError(fmt.Sprintf("Key %s is already in %s", plan.Fingerprint.ValueString(), file),
+			"Import it to manage it with this resource.")
+		return
+	}
+
+	resp.Diagnostics.Append(a.writeLines(ctx, file, append(lines, plan.line()), exists)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read refreshes the options of the key. The key itself is only read back
+// after an import, so that its formatting is kept.
+func (a authorizedKeyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state authorizedKeyModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := state.File.ValueString()
+	defer api.LockFile(a.provider, file)()
+
+	lines, _, err := api.ReadLines(ctx, a.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	i := indexOfKey(lines, state.Fingerprint.ValueString())
+	if i < 0 {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	pub, err := parseAuthorizedKey(lines[i])
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	if state.Key.IsNull() {
+		state.Key = types.StringValue(pub.String())
+	}
+	state.Options = types.StringNull()
+	if len(pub.options) > 0 {
+		state.Options = types.StringValue(strings.Join(pub.options, ","))
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// Update rewrites the line of the key with the new options.
+func (a authorizedKeyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan authorizedKeyModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := plan.File.ValueString()
+	defer api.LockFile(a.provider, file)()
+
+	lines, exists, err := api.ReadLines(ctx, a.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	if i := indexOfKey(lines, plan.Fingerprint.ValueString()); i >= 0 {
+		lines[i] = plan.line()
+	} else {
+		lines = append(lines, plan.line())
+	}
+
+	resp.Diagnostics.Append(a.writeLines(ctx, file, lines, exists)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (a authorizedKeyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state authorizedKeyModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	file := state.File.ValueString()
+	defer api.LockFile(a.provider, file)()
+
+	lines, exists, err := api.ReadLines(ctx, a.provider, file)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", file), err.Error())
+		return
+	}
+	i := indexOfKey(lines, state.Fingerprint.ValueString())
+	if i < 0 {
+		return
+	}
+
+	resp.Diagnostics.Append(a.writeLines(ctx, file, append(lines[:i], lines[i+1:]...), exists)...)
+}
+
+// ImportState accepts the fingerprint of the key, prefixed by the file and a
+// comma when it is not in the default file, e.g.
+// /root/.ssh/authorized_keys,SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8.
+func (a authorizedKeyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, a.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	a.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("device"), device)...)
+
+	file, fingerprint, found := strings.Cut(id, ",")
+	if !found {
+		file, fingerprint = authorizedKeysFile, id
+	}
+	if !path.IsAbs(file) || !strings.HasPrefix(fingerprint, "SHA256:") {
+		resp.Diagnostics.AddError("Failed to import state",
+			fmt.Sprintf("expected a SHA256 fingerprint, optionally prefixed by an absolute file path and a comma, got %q", id))
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("file"), file)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("fingerprint"), fingerprint)...)
+}
+
+func (m authorizedKeyModel) line() string {
+	if m.Options.IsNull() || m.Options.ValueString() == "" {
+		return m.Key.ValueString()
+	}
+	return m.Options.ValueString() + " " + m.Key.ValueString()
+}
+
+// writeLines writes the file back, a new file is created only readable by
+// its owner as dropbear ignores it otherwise.
+func (a authorizedKeyResource) writeLines(ctx context.Context, file string, lines []string, exists bool) (diags diag.Diagnostics) {
+	if err := api.WriteLines(ctx, a.provider, file, lines, exists, "0600"); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to write %s", file), err.Error())
+	}
+	return diags
+}
+
+// authorizedKey is a parsed line of an authorized_keys file.
+type authorizedKey struct {
+	key     ssh.PublicKey
+	comment string
+	options []string
+}
+
+// String returns the key in the OpenSSH format, without the options.
+func (k authorizedKey) String() string {
+	s := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(k.key)), "\n")
+	if k.comment != "" {
+		s += " " + k.comment
+	}
+	return s
+}
+
+func parseAuthorizedKey(line string) (*authorizedKey, error) {
+	key, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("expected a single line")
+	}
+	return &authorizedKey{key: key, comment: comment, options: options}, nil
+}
+
+// indexOfKey returns the index of the line of the key with the given
+// fingerprint, or -1. Comments and invalid lines are skipped.
+func indexOfKey(lines []string, fingerprint string) int {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, err := parseAuthorizedKey(line); err == nil && ssh.FingerprintSHA256(k.key) == fingerprint {
+			return i
+		}
+	}
+	return -1
+}
+
+// publicKeyValidator checks that the value is a public key in the OpenSSH
+// format, without options.
+type publicKeyValidator struct{}
+
+func (v publicKeyValidator) Description(_ context.Context) string {
+	return "value must be a public key in the OpenSSH format"
+}
+
+func (v publicKeyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v publicKeyValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	k, err := parseAuthorizedKey(value)
+	switch {
+	case err != nil:
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid public key",
+			fmt.Sprintf("%q is not a public key in the OpenSSH format: %s", value, err))
+	case len(k.options) > 0:
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid public key",
+			"Set the options preceding the key with the options attribute.")
+	}
+}
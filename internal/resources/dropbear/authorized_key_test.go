@@ -0,0 +1,195 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package dropbear_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+// checkKeys verifies the lines of the file, in any order.
+func checkKeys(files *testutil.FakeFiles, path string, lines ...string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		content, _ := files.File(path)
+		if len(lines) == 0 && content == "" {
+			return nil
+		}
+		got := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		if len(got) != len(lines) {
+			return fmt.Errorf("expected %s to hold %q, got %q", path, lines, got)
+		}
+		for _, line := range lines {
+			if !slices.Contains(got, line) {
+				return fmt.Errorf("expected %s to hold %q, got %q", path, line, got)
+			}
+		}
+		return nil
+	}
+}
+
+// testKey returns a deterministic ed25519 public key in the OpenSSH format,
+// and its fingerprint.
+func testKey(t *testing.T, seed byte, comment string) (string, string) {
+	t.Helper()
+
+	private := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	pub, err := ssh.NewPublicKey(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(pub)), "\n")
+	if comment != "" {
+		key += " " + comment
+	}
+	return key, ssh.FingerprintSHA256(pub)
+}
+
+func TestAccSSHAuthorizedKey(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	other, _ := testKey(t, 1, "other@example.com")
+	admin, adminFingerprint := testKey(t, 2, "admin@example.com")
+	backup, _ := testKey(t, 3, "")
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	files := testutil.NewFakeFiles(client, map[string]string{
+		"/etc/dropbear/authorized_keys": "# added by hand\n" + other + "\n",
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_ssh_authorized_key" "admin" {
+					key = "ssh-ed25519 AAAAinvalid admin@example.com"
+				}`,
+				ExpectError: regexp.MustCompile(`is not a public key in the\s+OpenSSH format`),
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_ssh_authorized_key" "admin" {
+					key = "no-pty %s"
+				}`, admin),
+				ExpectError: regexp.MustCompile(`Set the options preceding the key with the options\s+attribute`),
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_ssh_authorized_key" "admin" {
+					key     = %q
+					options = "no-pty bogus"
+				}`, admin),
+				ExpectError: regexp.MustCompile(`is not a comma separated list of options`),
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_ssh_authorized_key" "other" {
+					key = %q
+				}`, other),
+				ExpectError: regexp.MustCompile(`Key SHA256:\S+ is already in /etc/dropbear/authorized_keys`),
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_ssh_authorized_key" "admin" {
+					key = %q
+				}
+
+				resource "openwrt_ssh_authorized_key" "backup" {
+					key     = %q
+					options = "no-port-forwarding,command=\"rsync --server --sender\",from=\"10.0.0.1, 10.0.0.2\""
+				}
+
+				resource "openwrt_ssh_authorized_key" "root" {
+					key  = %q
+					file = "/root/.ssh/authorized_keys"
+				}`, admin, backup, admin),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_ssh_authorized_key.admin", "fingerprint", adminFingerprint),
+					resource.TestCheckResourceAttr("openwrt_ssh_authorized_key.admin", "file", "/etc/dropbear/authorized_keys"),
+					checkKeys(files, "/etc/dropbear/authorized_keys",
+						"# added by hand",
+						other,
+						admin,
+						`no-port-forwarding,command="rsync --server --sender",from="10.0.0.1, 10.0.0.2" `+backup,
+					),
+					checkKeys(files, "/root/.ssh/authorized_keys", admin),
+					files.CheckMode("/etc/dropbear/authorized_keys", ""),
+					files.CheckMode("/root/.ssh/authorized_keys", "0600"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("openwrt_ssh_authorized_key.admin",
+							tfjsonpath.New("fingerprint"), knownvalue.StringExact(adminFingerprint)),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_ssh_authorized_key" "admin" {
+					key     = %q
+					options = "no-agent-forwarding"
+				}
+
+				resource "openwrt_ssh_authorized_key" "backup" {
+					key = %q
+				}`, admin, backup),
+				Check: resource.ComposeTestCheckFunc(
+					checkKeys(files, "/etc/dropbear/authorized_keys",
+						"# added by hand",
+						other,
+						"no-agent-forwarding "+admin,
+						backup,
+					),
+					checkKeys(files, "/root/.ssh/authorized_keys"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_ssh_authorized_key.admin", plancheck.ResourceActionUpdate),
+						plancheck.ExpectResourceAction("openwrt_ssh_authorized_key.backup", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_ssh_authorized_key.admin",
+				ImportState:                          true,
+				ImportStateId:                        adminFingerprint,
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "fingerprint",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			checkKeys(files, "/etc/dropbear/authorized_keys", "# added by hand", other),
+		),
+	})
+}
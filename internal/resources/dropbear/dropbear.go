@@ -0,0 +1,430 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package dropbear
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	dropbearConfig  = "dropbear"
+	dropbearService = "dropbear"
+)
+
+// anonymousSectionRegexp matches the extended syntax of the position of a
+// dropbear section.
+var anonymousSectionRegexp = regexp.MustCompile(`^@dropbear\[\d+\]$`)
+
+type dropbearModel struct {
+	Name             types.String `tfsdk:"name"`
+	Port             types.Int64  `tfsdk:"port"`
+	Interface        types.String `tfsdk:"interface"`
+	PasswordAuth     types.Bool   `tfsdk:"password_auth"`
+	RootPasswordAuth types.Bool   `tfsdk:"root_password_auth"`
+	GatewayPorts     types.Bool   `tfsdk:"gateway_ports"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type dropbearResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*dropbearResource)(nil)
+	_ resource.ResourceWithImportState = (*dropbearResource)(nil)
+)
+
+// NewDropbearResource return new dropbear resource.
+func NewDropbearResource() resource.Resource {
+	return &dropbearResource{}
+}
+
+func (d dropbearResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_dropbear", req.ProviderTypeName)
+}
+
+func (d dropbearResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a `dropbear` section of the dropbear configuration, i.e. an instance of the SSH server. The default instance is anonymous, manage it with `name = \"@dropbear[0]\"` once imported with `@dropbear[0]`; destroying the resource removes the instance. `dropbear` is restarted after each change.",
+		Description:         "Manage a dropbear section of the dropbear configuration, i.e. an instance of the SSH server. The default instance is anonymous, manage it with name = \"@dropbear[0]\" once imported with @dropbear[0]; destroying the resource removes the instance. dropbear is restarted after each change.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the section, e.g. `wan`, or its position among the dropbear sections for an anonymous one, e.g. `@dropbear[0]`. An anonymous section can only be imported.",
+				Description:         "Name of the section, e.g. wan, or its position among the dropbear sections for an anonymous one, e.g. @dropbear[0]. An anonymous section can only be imported.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.Any(
+						customtypes.SectionNameValidator("dropbear"),
+						stringvalidator.RegexMatches(anonymousSectionRegexp, "must be the position of an anonymous section, e.g. @dropbear[0]"),
+					),
+				},
+			},
+			"port": schema.Int64Attribute{
+				MarkdownDescription: "Port the SSH server listens on. (Default: 22)",
+				Description:         "Port the SSH server listens on. (Default: 22)",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+			},
+			"interface": schema.StringAttribute{
+				MarkdownDescription: "Logical interface the SSH server listens on, e.g. `lan`. (Default: all the interfaces)",
+				Description:         "Logical interface the SSH server listens on, e.g. lan. (Default: all the interfaces)",
+				Optional:            true,
+			},
+			"password_auth": schema.BoolAttribute{
+				MarkdownDescription: "Whether the users may log in with a password. (Default: true)",
+				Description:         "Whether the users may log in with a password. (Default: true)",
+				Optional:            true,
+			},
+			"root_password_auth": schema.BoolAttribute{
+				MarkdownDescription: "Whether root may log in with a password. (Default: true)",
+				Description:         "Whether root may log in with a password. (Default: true)",
+				Optional:            true,
+			},
+			"gateway_ports": schema.BoolAttribute{
+				MarkdownDescription: "Whether remote hosts may connect to the forwarded ports. (Default: false)",
+				Description:         "Whether remote hosts may connect to the forwarded ports. (Default: false)",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (d *dropbearResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	d.devices = devices
+}
+
+func (d dropbearResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan dropbearModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.provider, diags = api.DeviceClient(ctx, d.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := plan.Name.ValueString()
+	_, err := d.provider.GetDropbear(ctx, name)
+	if err == nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Dropbear section %q already exists", name),
+			"Import it to manage it with this resource.")
+		return
+	}
+	if !errors.Is(err, api.ErrSectionNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read dropbear section %q", name), err.Error())
+		return
+	}
+	if anonymousSectionRegexp.MatchString(name) {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), fmt.Sprintf("Dropbear section %q not found", name),
+			"An anonymous section can not be added, name the section instead.")
+		return
+	}
+
+	resp.Diagnostics.Append(d.commit(ctx, fmt.Sprintf("Failed to add dropbear section %q", name), func(ctx context.Context) error {
+		return d.provider.AddSection(ctx, dropbearConfig, "dropbear", name, plan.options())
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (d dropbearResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state dropbearModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.provider, diags = api.DeviceClient(ctx, d.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dropbear, err := d.provider.GetDropbear(ctx, state.Name.ValueString())
+	if errors.Is(err, api.ErrSectionNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read dropbear section %q", state.Name.ValueString()), err.Error())
+		return
+	}
+
+	refreshed, err := newDropbearModel(dropbear)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read dropbear section %q", dropbear.Id), err.Error())
+		return
+	}
+	refreshed.Name = state.Name
+	refreshed.Device = state.Device
+	refreshed.Timeouts = state.Timeouts
+	resp.Diagnostics.Append(resp.State.Set(ctx, refreshed)...)
+}
+
+func (d dropbearResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state dropbearModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan dropbearModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.provider, diags = api.DeviceClient(ctx, d.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name, diags := d.sectionName(ctx, plan.Name.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(d.commit(ctx, fmt.Sprintf("Failed to update dropbear section %q", name), func(ctx context.Context) error {
+		options := plan.options()
+		if len(options) > 0 {
+			if err := d.provider.TSet(ctx, options, dropbearConfig, name); err != nil {
+				return err
+			}
+		}
+
+		for option := range state.options() {
+			if _, ok := options[option]; ok {
+				continue
+			}
+			if err := d.provider.Delete(ctx, dropbearConfig, name, option); err != nil {
+				return fmt.Errorf("failed to remove option %q: %w", option, err)
+			}
+		}
+		return nil
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (d dropbearResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state dropbearModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.provider, diags = api.DeviceClient(ctx, d.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name, diags := d.sectionName(ctx, state.Name.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(d.commit(ctx, fmt.Sprintf("Failed to delete dropbear section %q", name), func(ctx context.Context) error {
+		return d.provider.Delete(ctx, dropbearConfig, name)
+	})...)
+}
+
+// ImportState accepts the name of the section or its extended syntax, e.g.
+// @dropbear[0], which is kept as the name: the generated name of an anonymous
+// section is not known to the configuration.
+func (d dropbearResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, d.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	d.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	dropbear, err := d.provider.GetDropbear(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+
+	state, err := newDropbearModel(dropbear)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+	state.Name = types.StringValue(id)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// sectionName resolves the position of an anonymous section to its name,
+// for the changes to go to the section read.
+func (d dropbearResource) sectionName(ctx context.Context, name string) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if !anonymousSectionRegexp.MatchString(name) {
+		return name, diags
+	}
+	dropbear, err := d.provider.GetDropbear(ctx, name)
+	if err != nil {
+		diags.AddError(fmt.Sprintf("Failed to read dropbear section %q", name), err.Error())
+		return "", diags
+	}
+	return dropbear.Id, diags
+}
+
+// commit stages the changes of the dropbear configuration with stage,
+// commits them, then restarts dropbear. summary describes the change if it
+// fails.
+func (d dropbearResource) commit(ctx context.Context, summary string, stage func(ctx context.Context) error) (diags diag.Diagnostics) {
+	if err := d.provider.Transaction(ctx, dropbearConfig, stage); err != nil {
+		diags.AddError(summary, err.Error())
+		return diags
+	}
+
+	if err := d.provider.RestartService(ctx, dropbearService); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to restart %s", dropbearService), err.Error())
+	}
+	return diags
+}
+
+// options returns the options set in the model, by their UCI name.
+func (m dropbearModel) options() map[string]any {
+	options := map[string]any{}
+	if !m.Port.IsNull() {
+		options["Port"] = strconv.FormatInt(m.Port.ValueInt64(), 10)
+	}
+	if !m.Interface.IsNull() {
+		options["Interface"] = m.Interface.ValueString()
+	}
+	for name, value := range map[string]types.Bool{
+		"PasswordAuth":     m.PasswordAuth,
+		"RootPasswordAuth": m.RootPasswordAuth,
+		"GatewayPorts":     m.GatewayPorts,
+	} {
+		if !value.IsNull() {
+			options[name] = onOff(value.ValueBool())
+		}
+	}
+	return options
+}
+
+func newDropbearModel(dropbear *api.Dropbear) (dropbearModel, error) {
+	m := dropbearModel{
+		Name:             types.StringValue(dropbear.Id),
+		Port:             types.Int64Null(),
+		Interface:        types.StringNull(),
+		PasswordAuth:     customtypes.UCIBool(dropbear.PasswordAuth),
+		RootPasswordAuth: customtypes.UCIBool(dropbear.RootPasswordAuth),
+		GatewayPorts:     customtypes.UCIBool(dropbear.GatewayPorts),
+	}
+
+	if dropbear.Port != "" {
+		i, err := strconv.ParseInt(dropbear.Port, 10, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid Port: %w", err)
+		}
+		m.Port = types.Int64Value(i)
+	}
+	if dropbear.Interface != "" {
+		m.Interface = types.StringValue(dropbear.Interface)
+	}
+	return m, nil
+}
+
+// onOff follows the on/off convention of the default dropbear
+// configuration.
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,237 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package dropbear_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// newFakeDropbear keeps the dropbear configuration in memory, starting with
+// the anonymous section of the default instance.
+func newFakeDropbear(client *mocks.MockClient) *testutil.FakeUCI {
+	uci := testutil.NewFakeUCI(client, "dropbear", map[string]any{
+		".name":            "cfg014dd4",
+		".type":            "dropbear",
+		"PasswordAuth":     "on",
+		"RootPasswordAuth": "on",
+		"Port":             "22",
+	})
+
+	client.
+		EXPECT().
+		GetDropbear(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) (*api.Dropbear, error) {
+			return testutil.GetSection[api.Dropbear](uci, name)
+		}).
+		AnyTimes()
+
+	return uci
+}
+
+// checkDropbear verifies the options of the named section, or that it is
+// missing if options is nil. dropbear must have been restarted after the last
+// commit.
+func checkDropbear(uci *testutil.FakeUCI, name string, options map[string]any) resource.TestCheckFunc {
+	return resource.ComposeTestCheckFunc(
+		uci.CheckRestarted("dropbear"),
+		uci.CheckSection(name, options),
+	)
+}
+
+func TestAccDropbear(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	dropbear := newFakeDropbear(client)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name = "wan-ssh"
+				}`,
+				ExpectError: regexp.MustCompile(`Invalid section name`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name = "wan"
+					port = 65536
+				}`,
+				ExpectError: regexp.MustCompile(`value must be between 1 and 65535`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name               = "wan"
+					port               = 2222
+					interface          = "wan"
+					password_auth      = false
+					root_password_auth = false
+					gateway_ports      = true
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkDropbear(dropbear, "wan", map[string]any{
+						"Port":             "2222",
+						"Interface":        "wan",
+						"PasswordAuth":     "off",
+						"RootPasswordAuth": "off",
+						"GatewayPorts":     "on",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name      = "wan"
+					port      = 2222
+					interface = "wan"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkDropbear(dropbear, "wan", map[string]any{
+						"Port":      "2222",
+						"Interface": "wan",
+					}),
+					checkDropbear(dropbear, "cfg014dd4", map[string]any{
+						"PasswordAuth":     "on",
+						"RootPasswordAuth": "on",
+						"Port":             "22",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_dropbear.wan", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_dropbear.wan",
+				ImportState:                          true,
+				ImportStateId:                        "wan",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name      = "wan"
+					port      = 2222
+					interface = "wan"
+				}
+
+				resource "openwrt_dropbear" "lan" {
+					name = "@dropbear[0]"
+				}`,
+				ResourceName:  "openwrt_dropbear.lan",
+				ImportState:   true,
+				ImportStateId: "@dropbear[0]",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 {
+						return fmt.Errorf("expected a single state, got %d", len(states))
+					}
+					for name, value := range map[string]string{
+						"name":               "@dropbear[0]",
+						"port":               "22",
+						"password_auth":      "true",
+						"root_password_auth": "true",
+					} {
+						if got := states[0].Attributes[name]; got != value {
+							return fmt.Errorf("expected %q to be %q, got %q", name, value, got)
+						}
+					}
+					return nil
+				},
+			},
+			// the anonymous default instance is managed by its position,
+			// without being replaced
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name      = "wan"
+					port      = 2222
+					interface = "wan"
+				}
+
+				import {
+					to = openwrt_dropbear.lan
+					id = "@dropbear[0]"
+				}
+
+				resource "openwrt_dropbear" "lan" {
+					name               = "@dropbear[0]"
+					port               = 22
+					password_auth      = true
+					root_password_auth = true
+				}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_dropbear.lan", plancheck.ResourceActionNoop),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_dropbear" "wan" {
+					name      = "wan"
+					port      = 2222
+					interface = "wan"
+				}
+
+				resource "openwrt_dropbear" "lan" {
+					name               = "@dropbear[0]"
+					port               = 2200
+					password_auth      = true
+					root_password_auth = true
+				}`,
+				Check: checkDropbear(dropbear, "cfg014dd4", map[string]any{
+					"PasswordAuth":     "on",
+					"RootPasswordAuth": "on",
+					"Port":             "2200",
+				}),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_dropbear.lan", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			checkDropbear(dropbear, "wan", nil),
+			checkDropbear(dropbear, "cfg014dd4", nil),
+		),
+	})
+}
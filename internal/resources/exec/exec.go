@@ -0,0 +1,243 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type execModel struct {
+	Command        types.String `tfsdk:"command"`
+	DestroyCommand types.String `tfsdk:"destroy_command"`
+	Triggers       types.Map    `tfsdk:"triggers"`
+	IgnoreExitCode types.Bool   `tfsdk:"ignore_exit_code"`
+	Stdout         types.String `tfsdk:"stdout"`
+	Stderr         types.String `tfsdk:"stderr"`
+	ExitCode       types.Int64  `tfsdk:"exit_code"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type execResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var _ resource.ResourceWithConfigure = (*execResource)(nil)
+
+// NewExecResource return new exec resource.
+func NewExecResource() resource.Resource {
+	return &execResource{}
+}
+
+func (e execResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_exec", req.ProviderTypeName)
+}
+
+func (e execResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Run a shell command on the router when the resource is created, e.g. `wifi reload`, and optionally another one when it is destroyed. " +
+			"The command is run again when it or the `triggers` change. Through LuCI the commands run as root with `sys.exec`; " +
+			"through ubus they run as `/bin/sh -c <command>` with `file.exec`, which requires the `exec` permission on `/bin/sh` in the ACL of the user.",
+		Description: "Run a shell command on the router when the resource is created, e.g. wifi reload, and optionally another one when it is destroyed. " +
+			"The command is run again when it or the triggers change. Through LuCI the commands run as root with sys.exec; " +
+			"through ubus they run as /bin/sh -c <command> with file.exec, which requires the exec permission on /bin/sh in the ACL of the user.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"command": schema.StringAttribute{
+				MarkdownDescription: "Command run by the shell when the resource is created.",
+				Description:         "Command run by the shell when the resource is created.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"destroy_command": schema.StringAttribute{
+				MarkdownDescription: "Command run by the shell when the resource is destroyed, including before it is replaced.",
+				Description:         "Command run by the shell when the resource is destroyed, including before it is replaced.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values which run the command again when they change.",
+				Description:         "Arbitrary values which run the command again when they change.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"ignore_exit_code": schema.BoolAttribute{
+				MarkdownDescription: "Whether a non-zero exit code of the commands is not an error. (Default: `false`)",
+				Description:         "Whether a non-zero exit code of the commands is not an error. (Default: false)",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"stdout": schema.StringAttribute{
+				MarkdownDescription: "Standard output of the command.",
+				Description:         "Standard output of the command.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"stderr": schema.StringAttribute{
+				MarkdownDescription: "Standard error of the command.",
+				Description:         "Standard error of the command.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"exit_code": schema.Int64Attribute{
+				MarkdownDescription: "Exit code of the command.",
+				Description:         "Exit code of the command.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (e *execResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	e.devices = devices
+}
+
+func (e execResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan execModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	e.provider, diags = api.DeviceClient(ctx, e.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	result, diags := e.run(ctx, plan.Command.ValueString(), plan.IgnoreExitCode.ValueBool())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Stdout = types.StringValue(result.Stdout)
+	plan.Stderr = types.StringValue(result.Stderr)
+	plan.ExitCode = types.Int64Value(int64(result.ExitCode))
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read keeps the state, the outcome of the command being only known when it
+// runs.
+func (e execResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state execModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// Update only changes the attributes which do not run the command again.
+func (e execResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan execModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (e execResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state execModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if state.DestroyCommand.IsNull() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	e.provider, diags = api.DeviceClient(ctx, e.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, diags = e.run(ctx, state.DestroyCommand.ValueString(), state.IgnoreExitCode.ValueBool())
+	resp.Diagnostics.Append(diags...)
+}
+
+// run runs the command, a non-zero exit code being an error unless ignored.
+func (e execResource) run(ctx context.Context, command string, ignoreExitCode bool) (*api.ExecResult, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	result, err := e.provider.Exec(ctx, command)
+	if err != nil {
+		diags.AddError(fmt.Sprintf("Failed to run %q", command), err.Error())
+		return nil, diags
+	}
+	if result.ExitCode != 0 && !ignoreExitCode {
+		diags.AddError(fmt.Sprintf("%q exited with %d", command, result.ExitCode),
+			fmt.Sprintf("stderr: %s\nstdout: %s", strings.TrimSpace(result.Stderr), strings.TrimSpace(result.Stdout)))
+		return nil, diags
+	}
+	return result, diags
+}
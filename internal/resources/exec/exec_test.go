@@ -0,0 +1,161 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package exec_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+)
+
+func TestAccExec(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+
+	gomock.InOrder(
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "wg genkey > /etc/wg.key").
+			Return(&api.ExecResult{Stdout: "", Stderr: "", ExitCode: 0}, nil),
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "rm /etc/wg.key").
+			Return(&api.ExecResult{ExitCode: 0}, nil),
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "wg genkey > /etc/wg.key").
+			Return(&api.ExecResult{Stdout: "", Stderr: "", ExitCode: 0}, nil),
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "rm /etc/wg.key").
+			Return(&api.ExecResult{Stderr: "rm: can't remove '/etc/wg.key': No such file or directory\n", ExitCode: 1}, nil),
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "rm /etc/wg.key").
+			Return(&api.ExecResult{Stderr: "rm: can't remove '/etc/wg.key': No such file or directory\n", ExitCode: 1}, nil),
+	)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_exec" "key" {
+					command         = "wg genkey > /etc/wg.key"
+					destroy_command = "rm /etc/wg.key"
+					triggers = {
+						generation = "1"
+					}
+				}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_exec.key", "exit_code", "0"),
+					resource.TestCheckResourceAttr("openwrt_exec.key", "stdout", ""),
+					resource.TestCheckResourceAttr("openwrt_exec.key", "ignore_exit_code", "false"),
+				),
+			},
+			// changing the triggers runs the commands again
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_exec" "key" {
+					command         = "wg genkey > /etc/wg.key"
+					destroy_command = "rm /etc/wg.key"
+					triggers = {
+						generation = "2"
+					}
+				}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_exec.key", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+			},
+			// a failing destroy command keeps the resource
+			{
+				Config:      testutil.ProviderConfig,
+				ExpectError: regexp.MustCompile(`exited with 1`),
+			},
+			// unless its exit code is ignored
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_exec" "key" {
+					command          = "wg genkey > /etc/wg.key"
+					destroy_command  = "rm /etc/wg.key"
+					ignore_exit_code = true
+					triggers = {
+						generation = "2"
+					}
+				}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_exec.key", plancheck.ResourceActionUpdate),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccExecFailure(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+
+	gomock.InOrder(
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "sysctl -w net.ipv4.ip_forward=2").
+			Return(&api.ExecResult{Stderr: "sysctl: error setting key 'net.ipv4.ip_forward': Invalid argument\n", ExitCode: 255}, nil),
+		client.
+			EXPECT().
+			Exec(gomock.Any(), "sysctl -w net.ipv4.ip_forward=2").
+			Return(&api.ExecResult{Stderr: "sysctl: error setting key 'net.ipv4.ip_forward': Invalid argument\n", ExitCode: 255}, nil),
+	)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_exec" "forward" {
+					command = "sysctl -w net.ipv4.ip_forward=2"
+				}`,
+				ExpectError: regexp.MustCompile(`exited with 255`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_exec" "forward" {
+					command          = "sysctl -w net.ipv4.ip_forward=2"
+					ignore_exit_code = true
+				}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_exec.forward", "exit_code", "255"),
+					resource.TestCheckResourceAttr("openwrt_exec.forward", "stderr", "sysctl: error setting key 'net.ipv4.ip_forward': Invalid argument\n"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const backupSuffix = ".tf-bak"
+
+var backupSchemaAttribute = schema.BoolAttribute{
+	MarkdownDescription: "Keep a copy of the file found on the router in `<file>.tf-bak`. The copy is restored when the resource is destroyed, instead of removing the file. (Default: false)",
+	Description:         "Keep a copy of the file found on the router in <file>.tf-bak. The copy is restored when the resource is destroyed, instead of removing the file. (Default: false)",
+	Optional:            true,
+	Computed:            true,
+	Default:             booldefault.StaticBool(false),
+}
+
+func backupPath(filePath string) string {
+	return filePath + backupSuffix
+}
+
+func exists(ctx context.Context, fsFacade api.FsFacade, filePath string) (bool, error) {
+	_, err := fsFacade.Stat(ctx, filePath)
+	if errors.Is(err, api.ErrFileNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// takeBackup copies the remote file aside. An already existing backup is
+// kept untouched, so that it always holds the content found before Terraform
+// started to manage the file.
+func takeBackup(ctx context.Context, fsFacade api.FsFacade, filePath string) error {
+	found, err := exists(ctx, fsFacade, backupPath(filePath))
+	if err != nil || found {
+		return err
+	}
+
+	found, err = exists(ctx, fsFacade, filePath)
+	if err != nil || !found {
+		return err
+	}
+
+	if err := fsFacade.Copy(ctx, filePath, backupPath(filePath)); err != nil {
+		return fmt.Errorf("failed to backup %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// updateBackup takes or drops the backup when the backup attribute changes.
+func updateBackup(ctx context.Context, fsFacade api.FsFacade, filePath string, before, after types.Bool) error {
+	switch {
+	case after.ValueBool() && !before.ValueBool():
+		return takeBackup(ctx, fsFacade, filePath)
+	case !after.ValueBool() && before.ValueBool():
+		return dropBackup(ctx, fsFacade, filePath)
+	default:
+		return nil
+	}
+}
+
+// restoreBackup moves the backup back in place. When no backup exists the
+// file did not exist before, so it is removed.
+func restoreBackup(ctx context.Context, fsFacade api.FsFacade, filePath string) error {
+	found, err := exists(ctx, fsFacade, backupPath(filePath))
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fsFacade.RemoveFile(ctx, filePath)
+	}
+
+	if err := fsFacade.Rename(ctx, backupPath(filePath), filePath); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// revertToBackup copies the backup back in place after a failed change,
+// keeping it for the next changes. It returns false when there is no backup.
+func revertToBackup(ctx context.Context, fsFacade api.FsFacade, filePath string) (bool, error) {
+	found, err := exists(ctx, fsFacade, backupPath(filePath))
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := fsFacade.Copy(ctx, backupPath(filePath), filePath); err != nil {
+		return true, fmt.Errorf("failed to restore %s: %w", filePath, err)
+	}
+	return true, nil
+}
+
+// dropBackup removes the backup, if any.
+func dropBackup(ctx context.Context, fsFacade api.FsFacade, filePath string) error {
+	found, err := exists(ctx, fsFacade, backupPath(filePath))
+	if err != nil || !found {
+		return err
+	}
+
+	return fsFacade.RemoveFile(ctx, backupPath(filePath))
+}
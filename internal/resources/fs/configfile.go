@@ -4,29 +4,50 @@
 package fs
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"path"
 
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	tfpath "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-var etcConfig = "/etc/config"
+var (
+	_ resource.ResourceWithValidateConfig   = (*configFileResource)(nil)
+	_ resource.ResourceWithConfigValidators = (*configFileResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*configFileResource)(nil)
+
+	etcConfig = "/etc/config"
+)
 
 type configFileModel struct {
-	Name    types.String `tfsdk:"name"`
-	Content types.String `tfsdk:"content"`
-	Commit  types.Bool   `tfsdk:"commit"`
+	Name                 types.String         `tfsdk:"name"`
+	Content              customtypes.UCIValue `tfsdk:"content"`
+	Sections             types.List           `tfsdk:"sections"`
+	Commit               types.Bool           `tfsdk:"commit"`
+	Backup               types.Bool           `tfsdk:"backup"`
+	ValidateSectionTypes types.Bool           `tfsdk:"validate_section_types"`
+	PostApplyRemote      types.String         `tfsdk:"post_apply_remote"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // configFileResource represent Incus project resource.
 type configFileResource struct {
 	provider api.Client
+	devices  api.Devices
 }
 
 // NewProjectResource return new project resource.
@@ -39,20 +60,54 @@ func (c configFileResource) Metadata(_ context.Context, req resource.MetadataReq
 	resp.TypeName = fmt.Sprintf("%s_configfile", req.ProviderTypeName)
 }
 
-func (c configFileResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (c configFileResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Write configuration files to `/etc/config` on the OpenWRT router.",
 		Description:         "Write configuration files to /etc/config on the OpenWRT router.",
 		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
 			"name": schema.StringAttribute{
 				MarkdownDescription: "Name of the configuration file.",
 				Description:         "Name of the configuration file.",
 				Required:            true,
 			},
 			"content": schema.StringAttribute{
-				MarkdownDescription: "The content of the configuration file.",
-				Description:         "The content of the configuration file.",
-				Required:            true,
+				MarkdownDescription: "The content of the configuration file. It is compared with the file on the router as parsed UCI, so quoting, indentation, comments and option order rewritten by LuCI or `uci commit` do not show up as changes.",
+				Description:         "The content of the configuration file. It is compared with the file on the router as parsed UCI, so quoting, indentation, comments and option order rewritten by LuCI or uci commit do not show up as changes.",
+				CustomType:          customtypes.UCIType{},
+				Optional:            true,
+				Computed:            true,
+			},
+			"sections": schema.ListNestedAttribute{
+				MarkdownDescription: "The sections of the configuration file, rendered as canonical UCI text in `content`. Options and lists are written sorted by name. The `provider::openwrt::uci_decode` function returns existing configuration in this format.",
+				Description:         "The sections of the configuration file, rendered as canonical UCI text in content. Options and lists are written sorted by name. The provider::openwrt::uci_decode function returns existing configuration in this format.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"type": schema.StringAttribute{
+							MarkdownDescription: "Type of the section, e.g. `interface`.",
+							Description:         "Type of the section, e.g. interface.",
+							Required:            true,
+						},
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Name of the section. Anonymous sections have no name.",
+							Description:         "Name of the section. Anonymous sections have no name.",
+							Optional:            true,
+						},
+						"options": schema.MapAttribute{
+							MarkdownDescription: "The `option` values of the section.",
+							Description:         "The option values of the section.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
+						"lists": schema.MapAttribute{
+							MarkdownDescription: "The `list` values of the section, in order.",
+							Description:         "The list values of the section, in order.",
+							ElementType:         types.ListType{ElemType: types.StringType},
+							Optional:            true,
+						},
+					},
+				},
 			},
 			"commit": schema.BoolAttribute{
 				MarkdownDescription: "If we should tell `uci` to run `commit` on the configuration file. (Default: true)",
@@ -61,21 +116,190 @@ func (c configFileResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Default:             booldefault.StaticBool(true),
 				Computed:            true,
 			},
+			"backup": backupSchemaAttribute,
+			"post_apply_remote": schema.StringAttribute{
+				MarkdownDescription: "The URL of the router once the configuration is committed, when it moves the management address (e.g. a new LAN IP). The provider waits for the router to answer there, and uses it for the rest of the apply. Requires `commit`.",
+				Description:         "The URL of the router once the configuration is committed, when it moves the management address (e.g. a new LAN IP). The provider waits for the router to answer there, and uses it for the rest of the apply. Requires commit.",
+				Optional:            true,
+			},
+			"validate_section_types": schema.BoolAttribute{
+				MarkdownDescription: "If the section types of well known configuration files (e.g. `network`, `firewall`, `dhcp`) have to be checked at plan time. (Default: false)",
+				Description:         "If the section types of well known configuration files (e.g. network, firewall, dhcp) have to be checked at plan time. (Default: false)",
+				Optional:            true,
+				Default:             booldefault.StaticBool(false),
+				Computed:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
 		},
 	}
 }
 
+// ValidateConfig parses the content as UCI, so that syntax errors are
+// reported at plan time instead of when the daemon reloads its configuration.
+func (c configFileResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config configFileModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.PostApplyRemote.IsNull() && !config.Commit.IsNull() && !config.Commit.IsUnknown() && !config.Commit.ValueBool() {
+		resp.Diagnostics.AddAttributeError(tfpath.Root("post_apply_remote"),
+			"Invalid post apply remote", "the management address only moves when the configuration is committed")
+	}
+
+	if !config.Sections.IsNull() {
+		c.validateSections(ctx, config, &resp.Diagnostics)
+		return
+	}
+
+	if config.Content.IsNull() || config.Content.IsUnknown() {
+		return
+	}
+
+	parsed, err := uci.Parse(config.Content.ValueString())
+	addSyntaxErrors(&resp.Diagnostics, "Invalid UCI syntax", err)
+
+	if !validateSectionTypes(config, &resp.Diagnostics) || err != nil {
+		return
+	}
+	addSyntaxErrors(&resp.Diagnostics, "Invalid UCI section type", uci.ValidateSectionTypes(config.Name.ValueString(), parsed))
+}
+
+func (c configFileResource) validateSections(ctx context.Context, config configFileModel, diags *diag.Diagnostics) {
+	if !fullyKnown(ctx, config.Sections) {
+		return
+	}
+
+	parsed, d := customtypes.UCIConfigFromSections(ctx, config.Sections)
+	diags.Append(d...)
+	if diags.HasError() {
+		return
+	}
+
+	checkTypes := validateSectionTypes(config, diags)
+	for i, section := range parsed.Sections {
+		at := tfpath.Root("sections").AtListIndex(i)
+		if err := uci.CheckSection(section); err != nil {
+			diags.AddAttributeError(at, "Invalid UCI section", err.Error())
+			continue
+		}
+		if checkTypes {
+			if err := uci.ValidateSectionType(config.Name.ValueString(), section); err != nil {
+				diags.AddAttributeError(at.AtName("type"), "Invalid UCI section type", err.Error())
+			}
+		}
+	}
+}
+
+// validateSectionTypes reports if the section types can be checked, warning
+// when they are asked to but the configuration is not a known one.
+func validateSectionTypes(config configFileModel, diags *diag.Diagnostics) bool {
+	if !config.ValidateSectionTypes.ValueBool() || config.Name.IsUnknown() {
+		return false
+	}
+
+	name := config.Name.ValueString()
+	if !uci.KnownConfig(name) {
+		diags.AddAttributeWarning(tfpath.Root("validate_section_types"),
+			"Section types not validated", fmt.Sprintf("no section types are known for the %q configuration", name))
+		return false
+	}
+	return true
+}
+
+func addSyntaxErrors(diags *diag.Diagnostics, summary string, err error) {
+	if err == nil {
+		return
+	}
+
+	var syntaxErrs uci.SyntaxErrors
+	if !errors.As(err, &syntaxErrs) {
+		diags.AddAttributeError(tfpath.Root("content"), summary, err.Error())
+		return
+	}
+
+	for _, syntaxErr := range syntaxErrs {
+		diags.AddAttributeError(tfpath.Root("content"), summary, syntaxErr.Error())
+	}
+}
+
+func (c configFileResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			tfpath.MatchRoot("content"),
+			tfpath.MatchRoot("sections"),
+		),
+	}
+}
+
+// ModifyPlan renders the sections as UCI text. The content in the state is
+// kept when it has the same meaning, so that a file rewritten by `uci commit`
+// is not planned as an update.
+func (c configFileResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan configFileModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Sections.IsNull() {
+		return
+	}
+	if !fullyKnown(ctx, plan.Sections) {
+		plan.Content = customtypes.NewUCIUnknown()
+		resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+		return
+	}
+
+	wanted, diags := customtypes.UCIConfigFromSections(ctx, plan.Sections)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.Content = customtypes.NewUCIValue(uci.Format(wanted))
+
+	if !req.State.Raw.IsNull() {
+		var state configFileModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if current, err := uci.Parse(state.Content.ValueString()); err == nil && uci.Equal(current, wanted) {
+			plan.Content = state.Content
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
+func fullyKnown(ctx context.Context, v attr.Value) bool {
+	tfValue, err := v.ToTerraformValue(ctx)
+	return err == nil && tfValue.IsFullyKnown()
+}
+
 func (c *configFileResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	data := req.ProviderData
 	if data == nil {
 		return
 	}
-	provider, ok := data.(api.Client)
+	devices, ok := api.DevicesOf(data)
 	if !ok {
 		resp.Diagnostics.AddError("Failed to get api client", "")
 		return
 	}
-	c.provider = provider
+	c.devices = devices
 }
 
 func (c configFileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -86,16 +310,44 @@ func (c configFileResource) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
-	path := path.Join(etcConfig, plan.Name.ValueString())
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if err := c.provider.Writefile(ctx, path, []byte(plan.Content.ValueString())); err != nil {
-		resp.Diagnostics.AddError("Failed to write file", err.Error())
+	c.provider, diags = api.DeviceClient(ctx, c.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if plan.Commit.ValueBool() {
-		if err := c.provider.CommitOrRevert(ctx, plan.Name.ValueString()); err != nil {
+	path := path.Join(etcConfig, plan.Name.ValueString())
+
+	if plan.Backup.ValueBool() {
+		if err := takeBackup(ctx, c.provider, path); err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed to backup config %q", plan.Name.ValueString()), err.Error())
+			return
+		}
+	}
+
+	if !plan.Commit.ValueBool() {
+		if err := c.write(ctx, path, plan); err != nil {
+			resp.Diagnostics.AddError("Failed to write file", err.Error())
+			return
+		}
+	} else {
+		err := c.provider.TransactionTo(ctx, plan.Name.ValueString(), plan.PostApplyRemote.ValueString(), func(ctx context.Context) error {
+			return c.write(ctx, path, plan)
+		})
+		if err != nil {
 			resp.Diagnostics.AddError("failed to commit or revert", err.Error())
+			if plan.Backup.ValueBool() {
+				if err := restoreBackup(ctx, c.provider, path); err != nil {
+					resp.Diagnostics.AddError(fmt.Sprintf("Failed to restore config %q", plan.Name.ValueString()), err.Error())
+				}
+			}
 			return
 		}
 	}
@@ -103,6 +355,35 @@ func (c configFileResource) Create(ctx context.Context, req resource.CreateReque
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
+// write puts the content in place. With confirmed apply, the content is
+// staged as UCI changes instead, so that the router can roll it back.
+// Otherwise, the transaction cannot revert the file along with the changes
+// of its batch: it is written again when the stage is retried, and put back
+// from the backup when the commit fails.
+func (c configFileResource) write(ctx context.Context, path string, m configFileModel) error {
+	if !m.Commit.ValueBool() || !c.provider.ConfirmedApply() {
+		return c.provider.Writefile(ctx, path, []byte(m.Content.ValueString()))
+	}
+
+	parsed, err := uci.Parse(m.Content.ValueString())
+	if err != nil {
+		return err
+	}
+
+	// uci can only stage changes of an existing configuration
+	found, err := exists(ctx, c.provider, path)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if err := c.provider.Writefile(ctx, path, nil); err != nil {
+			return err
+		}
+	}
+
+	return c.provider.ReplaceConfig(ctx, m.Name.ValueString(), parsed)
+}
+
 func (c configFileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	var state configFileModel
 	diags := req.State.Get(ctx, &state)
@@ -111,37 +392,107 @@ func (c configFileResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.provider, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	path := path.Join(etcConfig, state.Name.ValueString())
 	b, err := c.provider.ReadFile(ctx, path)
+	if errors.Is(err, api.ErrFileNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read config %q", state.Name.ValueString()), err.Error())
 		return
 	}
 
-	// Logic taken from the local_file provider
-	if !bytes.Equal(b, []byte(state.Content.ValueString())) {
-		resp.State.RemoveResource(ctx)
-		return
+	// The remote content is kept in the state, so that a drift is planned
+	// as an in-place update of the configuration file.
+	state.Content = customtypes.NewUCIValue(string(b))
+
+	// the sections are only refreshed when the meaning of the file changed,
+	// so that options left out as empty maps don't show up as a drift
+	if !state.Sections.IsNull() {
+		current, err := uci.Parse(string(b))
+		if err != nil {
+			resp.Diagnostics.AddWarning(fmt.Sprintf("Failed to parse config %q", state.Name.ValueString()), err.Error())
+		} else if known, diags := customtypes.UCIConfigFromSections(ctx, state.Sections); !diags.HasError() && !uci.Equal(current, known) {
+			state.Sections, diags = customtypes.NewUCISections(ctx, current)
+			resp.Diagnostics.Append(diags...)
+		}
 	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
 }
 
 func (c configFileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state configFileModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan configFileModel
-	diags := req.Plan.Get(ctx, &plan)
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.provider, diags = api.DeviceClient(ctx, c.devices, plan.Device)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	path := path.Join(etcConfig, plan.Name.ValueString())
-	if err := c.provider.Writefile(ctx, path, []byte(plan.Content.ValueString())); err != nil {
-		resp.Diagnostics.AddError("Failed to write file", err.Error())
+	if err := updateBackup(ctx, c.provider, path, state.Backup, plan.Backup); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update backup of config %q", plan.Name.ValueString()), err.Error())
+		return
 	}
 
-	if plan.Commit.ValueBool() {
-		if err := c.provider.CommitOrRevert(ctx, plan.Name.ValueString()); err != nil {
+	if !plan.Commit.ValueBool() {
+		if err := c.write(ctx, path, plan); err != nil {
+			resp.Diagnostics.AddError("Failed to write file", err.Error())
+			return
+		}
+	} else {
+		err := c.provider.TransactionTo(ctx, plan.Name.ValueString(), plan.PostApplyRemote.ValueString(), func(ctx context.Context) error {
+			return c.write(ctx, path, plan)
+		})
+		if err != nil {
 			resp.Diagnostics.AddError("failed to commit or revert", err.Error())
+			// the backup is put back, unless the router already rolled the
+			// changes back. Without one, the file did not exist before the
+			// resource, and the previous content as refreshed from the router
+			// is put back instead.
+			if plan.Backup.ValueBool() && !errors.Is(err, api.ErrRolledBack) {
+				found, err := revertToBackup(ctx, c.provider, path)
+				if err == nil && !found {
+					err = c.provider.Writefile(ctx, path, []byte(state.Content.ValueString()))
+				}
+				if err != nil {
+					resp.Diagnostics.AddError(fmt.Sprintf("Failed to restore config %q", plan.Name.ValueString()), err.Error())
+				}
+			}
 			return
 		}
 	}
@@ -158,34 +509,70 @@ func (c configFileResource) Delete(ctx context.Context, req resource.DeleteReque
 		return
 	}
 
-	path := path.Join(etcConfig, state.Name.ValueString())
-	err := c.provider.RemoveFile(ctx, path)
-	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed to delete config file %q", state.Name.ValueString()), err.Error())
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if state.Commit.ValueBool() {
-		if err := c.provider.CommitOrRevert(ctx, state.Name.ValueString()); err != nil {
-			resp.Diagnostics.AddError("failed to commit or revert", err.Error())
-			return
+	c.provider, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	path := path.Join(etcConfig, state.Name.ValueString())
+	remove := func(ctx context.Context) error {
+		if state.Backup.ValueBool() {
+			if err := restoreBackup(ctx, c.provider, path); err != nil {
+				return fmt.Errorf("failed to restore config file %q: %w", state.Name.ValueString(), err)
+			}
+			return nil
+		}
+		if err := c.provider.RemoveFile(ctx, path); err != nil {
+			return fmt.Errorf("failed to delete config file %q: %w", state.Name.ValueString(), err)
 		}
+		return nil
+	}
+
+	if !state.Commit.ValueBool() {
+		if err := remove(ctx); err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed to delete config file %q", state.Name.ValueString()), err.Error())
+		}
+		return
+	}
+	if err := c.provider.Transaction(ctx, state.Name.ValueString(), remove); err != nil {
+		resp.Diagnostics.AddError("failed to commit or revert", err.Error())
 	}
 }
 
-func (c *configFileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+func (c configFileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, c.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, tfpath.Root("device"), device)...)
+
 	var state configFileModel
 
-	path := path.Join(etcConfig, req.ID)
+	path := path.Join(etcConfig, id)
 	b, err := c.provider.ReadFile(ctx, path)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read config %q", state.Name.ValueString()), err.Error())
 		return
 	}
 
-	state.Name = types.StringValue(req.ID)
-	state.Content = types.StringValue(string(b))
+	state.Name = types.StringValue(id)
+	state.Content = customtypes.NewUCIValue(string(b))
 	state.Commit = types.BoolValue(true)
+	state.Backup = types.BoolValue(false)
+	state.ValidateSectionTypes = types.BoolValue(false)
+	state.Sections = types.ListNull(types.ObjectType{AttrTypes: customtypes.UCISectionAttrTypes})
 
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, tfpath.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, tfpath.Root("timeouts"), &state.Timeouts)...)
 	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
 }
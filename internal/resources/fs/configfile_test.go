@@ -0,0 +1,556 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	tfjson "github.com/hashicorp/terraform-json"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccConfigFile_Drift(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	client.
+		EXPECT().
+		CommitOrRevert(gomock.Any(), "dropbear").
+		Return(nil).
+		AnyTimes()
+
+	config := providerConfig + `
+	resource "openwrt_configfile" "dropbear" {
+		name    = "dropbear"
+		content = <<-EOT
+		config dropbear
+			option Port '22'
+		EOT
+	}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+			{
+				// uci commit rewrites the file without changing its meaning
+				PreConfig: func() {
+					remote.write("/etc/config/dropbear", []byte("\nconfig dropbear\n        option Port \"22\" # ssh\n\n"))
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				PreConfig: func() {
+					remote.write("/etc/config/dropbear", []byte("config dropbear\n\toption Port '2222'\n"))
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_configfile.dropbear",
+							Action: tfjson.ActionUpdate,
+						},
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				PreConfig: func() {
+					remote.remove("/etc/config/dropbear")
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_configfile.dropbear",
+							Action: tfjson.ActionCreate,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccConfigFile_Backup(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	original := []byte("config interface 'lan'\n\toption proto 'dhcp'\n")
+	remote.write("/etc/config/network", original)
+
+	var failCommit atomic.Bool
+	client.
+		EXPECT().
+		CommitOrRevert(gomock.Any(), "network").
+		DoAndReturn(func(_ context.Context, _ ...any) error {
+			if failCommit.Load() {
+				return errors.New("uci commit not ok")
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	config := providerConfig + `
+	resource "openwrt_configfile" "network" {
+		name    = "network"
+		backup  = true
+		content = <<-EOT
+		config interface 'lan'
+			option proto 'static'
+		EOT
+	}`
+
+	restored := func(_ *terraform.State) error {
+		if got := remote.content("/etc/config/network"); string(got) != string(original) {
+			return fmt.Errorf("original content not restored, got %q", got)
+		}
+		if remote.exists("/etc/config/network.tf-bak") {
+			return fmt.Errorf("backup file left behind")
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		CheckDestroy:             restored,
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					failCommit.Store(true)
+				},
+				Config:      config,
+				ExpectError: regexp.MustCompile("failed to commit or revert"),
+			},
+			{
+				PreConfig: func() {
+					if err := restored(nil); err != nil {
+						t.Fatal(err)
+					}
+					failCommit.Store(false)
+				},
+				Config: config,
+				Check: func(_ *terraform.State) error {
+					if got := remote.content("/etc/config/network.tf-bak"); string(got) != string(original) {
+						return fmt.Errorf("unexpected backup content %q", got)
+					}
+					return nil
+				},
+			},
+			// a failing update puts the backup back, and keeps it
+			{
+				PreConfig: func() {
+					failCommit.Store(true)
+				},
+				Config: providerConfig + `
+				resource "openwrt_configfile" "network" {
+					name    = "network"
+					backup  = true
+					content = <<-EOT
+					config interface 'lan'
+						option proto 'pppoe'
+					EOT
+				}`,
+				ExpectError: regexp.MustCompile("failed to commit or revert"),
+			},
+			{
+				PreConfig: func() {
+					if got := remote.content("/etc/config/network"); string(got) != string(original) {
+						t.Fatalf("backup not restored, got %q", got)
+					}
+					if got := remote.content("/etc/config/network.tf-bak"); string(got) != string(original) {
+						t.Fatalf("backup not kept, got %q", got)
+					}
+					failCommit.Store(false)
+				},
+				Config: config,
+			},
+		},
+	})
+}
+
+func TestAccConfigFile_InvalidSyntax(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	providerMocks(t, ctrl, clientFactory)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				resource "openwrt_configfile" "system" {
+					name    = "system"
+					content = <<-EOT
+					config system
+						option hostname 'router
+					EOT
+				}`,
+				ExpectError: regexp.MustCompile(`line 2, column 18: unterminated single quote`),
+			},
+			{
+				Config: providerConfig + `
+				resource "openwrt_configfile" "system" {
+					name    = "system"
+					content = <<-EOT
+					option hostname 'router'
+					config system
+						optoin timezone 'UTC'
+					EOT
+				}`,
+				ExpectError: regexp.MustCompile(`(?s)line 1, column 1: option "hostname" outside of a section.*line 3, column 2: unknown keyword "optoin"`),
+			},
+			{
+				Config: providerConfig + `
+				resource "openwrt_configfile" "system" {
+					name                   = "system"
+					validate_section_types = true
+					content = <<-EOT
+					config system
+					config interface 'lan'
+					EOT
+				}`,
+				ExpectError: regexp.MustCompile(`line 2, column 1: unknown section type "interface" for system`),
+			},
+		},
+	})
+}
+
+func TestAccConfigFile_Sections(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	client.
+		EXPECT().
+		CommitOrRevert(gomock.Any(), "firewall").
+		Return(nil).
+		AnyTimes()
+
+	config := providerConfig + `
+	locals {
+		ports = { ssh = 22, https = 443 }
+	}
+
+	resource "openwrt_configfile" "firewall" {
+		name                   = "firewall"
+		validate_section_types = true
+		sections = concat([
+			{
+				type    = "defaults"
+				options = { input = "REJECT", forward = "REJECT" }
+			},
+			{
+				type    = "zone"
+				name    = "lan"
+				options = { name = "lan" }
+				lists   = { network = ["lan", "lan6"] }
+			},
+		], [for name, port in local.ports : {
+			type    = "rule"
+			options = { name = "Allow-${name}", dest_port = tostring(port), target = "ACCEPT" }
+		}])
+	}`
+
+	expected := "config defaults\n" +
+		"\toption forward 'REJECT'\n" +
+		"\toption input 'REJECT'\n" +
+		"\n" +
+		"config zone 'lan'\n" +
+		"\toption name 'lan'\n" +
+		"\tlist network 'lan'\n" +
+		"\tlist network 'lan6'\n" +
+		"\n" +
+		"config rule\n" +
+		"\toption dest_port '443'\n" +
+		"\toption name 'Allow-https'\n" +
+		"\toption target 'ACCEPT'\n" +
+		"\n" +
+		"config rule\n" +
+		"\toption dest_port '22'\n" +
+		"\toption name 'Allow-ssh'\n" +
+		"\toption target 'ACCEPT'\n"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				resource "openwrt_configfile" "firewall" {
+					name     = "firewall"
+					sections = [{ type = "rule", name = "my-rule", lists = { proto = [] } }]
+				}`,
+				ExpectError: regexp.MustCompile(`(?s)invalid section name "my-rule".*list "proto" has no values`),
+			},
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_configfile.firewall", "content", expected),
+					func(_ *terraform.State) error {
+						if got := string(remote.content("/etc/config/firewall")); got != expected {
+							return fmt.Errorf("unexpected remote content %q", got)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// uci commit rewrites the file without changing its meaning
+				PreConfig: func() {
+					remote.write("/etc/config/firewall", []byte(
+						"config defaults\n\toption input REJECT\n\toption forward REJECT\n"+
+							"config zone lan\n\toption name lan\n\tlist network lan\n\tlist network lan6\n"+
+							"config rule\n\toption name Allow-https\n\toption dest_port 443\n\toption target ACCEPT\n"+
+							"config rule\n\toption name Allow-ssh\n\toption dest_port 22\n\toption target ACCEPT\n"))
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				PreConfig: func() {
+					remote.write("/etc/config/firewall", []byte("config defaults\n\toption input 'ACCEPT'\n"))
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_configfile.firewall",
+							Action: tfjson.ActionUpdate,
+						},
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+				Check: func(_ *terraform.State) error {
+					if got := string(remote.content("/etc/config/firewall")); got != expected {
+						return fmt.Errorf("unexpected remote content %q", got)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestAccConfigFile_ConfirmedApply(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	// the staged changes only reach the file when they are applied
+	var (
+		mu       sync.Mutex
+		staged   *uci.Config
+		rollback atomic.Bool
+	)
+	client.
+		EXPECT().
+		ReplaceConfig(gomock.Any(), "network", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, c *uci.Config) error {
+			mu.Lock()
+			defer mu.Unlock()
+			staged = c
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		CommitOrRevert(gomock.Any(), "network").
+		DoAndReturn(func(_ context.Context, _ ...any) error {
+			mu.Lock()
+			defer mu.Unlock()
+			defer func() { staged = nil }()
+			if rollback.Load() {
+				return errors.Join(api.ErrRolledBack, errors.New("router not reachable"))
+			}
+			if staged != nil {
+				remote.write("/etc/config/network", []byte(uci.Format(staged)))
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	config := `
+	provider "openwrt" {
+		user     = "root"
+		password = "test"
+		remote   = "http://test.lan:8080"
+
+		confirmed_apply = {
+			rollback_timeout = "60s"
+		}
+	}
+
+	resource "openwrt_configfile" "network" {
+		name    = "network"
+		content = <<-EOT
+		config interface 'lan'
+			option proto 'static'
+			option ipaddr "192.168.2.1" # moved
+		EOT
+	}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					rollback.Store(true)
+				},
+				Config:      config,
+				ExpectError: regexp.MustCompile("changes rolled back by the router"),
+			},
+			{
+				PreConfig: func() {
+					if got := remote.content("/etc/config/network"); len(got) != 0 {
+						t.Fatalf("rolled back changes written to the router: %q", got)
+					}
+					rollback.Store(false)
+				},
+				Config: config,
+				Check: func(_ *terraform.State) error {
+					expected := "config interface 'lan'\n\toption proto 'static'\n\toption ipaddr '192.168.2.1'\n"
+					if got := string(remote.content("/etc/config/network")); got != expected {
+						return fmt.Errorf("unexpected remote content %q", got)
+					}
+					return nil
+				},
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccConfigFile_PostApplyRemote(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	newFakeFs(client)
+
+	// the new address has to be known before committing, as the commit
+	// is what moves the router
+	var commits []string
+	client.
+		EXPECT().
+		TransactionTo(gomock.Any(), "network", "http://192.168.2.1:8080", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, config, remoteUrl string, stage func(ctx context.Context) error) error {
+			commits = append(commits, remoteUrl)
+			if err := stage(ctx); err != nil {
+				return err
+			}
+			return client.CommitOrRevert(ctx, config)
+		}).
+		Times(1)
+
+	client.
+		EXPECT().
+		CommitOrRevert(gomock.Any(), "network").
+		DoAndReturn(func(_ context.Context, _ ...any) error {
+			commits = append(commits, "commit")
+			return nil
+		}).
+		AnyTimes()
+
+	config := `
+	resource "openwrt_configfile" "network" {
+		name              = "network"
+		commit            = %t
+		post_apply_remote = "http://192.168.2.1:8080"
+		content = <<-EOT
+		config interface 'lan'
+			option proto 'static'
+			option ipaddr '192.168.2.1'
+		EOT
+	}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config:      providerConfig + fmt.Sprintf(config, false),
+				ExpectError: regexp.MustCompile("only moves when the configuration is committed"),
+			},
+			{
+				Config: providerConfig + fmt.Sprintf(config, true),
+				Check: func(_ *terraform.State) error {
+					if len(commits) < 2 || commits[0] != "http://192.168.2.1:8080" || commits[1] != "commit" {
+						return fmt.Errorf("unexpected calls %v", commits)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
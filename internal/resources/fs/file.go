@@ -4,25 +4,58 @@
 package fs
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
 	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	tfpath "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
+var (
+	_ resource.ResourceWithConfigValidators = (*fileResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*fileResource)(nil)
+
+	fileModeRegexp = regexp.MustCompile(`^[0-7]{4}$`)
+)
+
 type fileModel struct {
-	Path    types.String `tfsdk:"path"`
-	Name    types.String `tfsdk:"name"`
-	Content types.String `tfsdk:"content"`
+	Path          types.String `tfsdk:"path"`
+	Name          types.String `tfsdk:"name"`
+	Content       types.String `tfsdk:"content"`
+	ContentBase64 types.String `tfsdk:"content_base64"`
+	Source        types.String `tfsdk:"source"`
+	Sha256        types.String `tfsdk:"sha256"`
+	Mode          types.String `tfsdk:"mode"`
+	Owner         types.String `tfsdk:"owner"`
+	Group         types.String `tfsdk:"group"`
+	Backup        types.Bool   `tfsdk:"backup"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 type fileResource struct {
 	fsFacade api.FsFacade
+	devices  api.Devices
 }
 
 func NewFileResource() resource.Resource {
@@ -33,11 +66,12 @@ func (c fileResource) Metadata(_ context.Context, req resource.MetadataRequest,
 	resp.TypeName = fmt.Sprintf("%s_file", req.ProviderTypeName)
 }
 
-func (c fileResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (c fileResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Write configuration files to an arbitray path on the OpenWRT router.",
 		Description:         "Write configuration files to an arbitray path on the OpenWRT router.",
 		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
 			"path": schema.StringAttribute{
 				MarkdownDescription: "Path where file has to be.",
 				Description:         "Path where file has to be.",
@@ -49,12 +83,111 @@ func (c fileResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 				Required:            true,
 			},
 			"content": schema.StringAttribute{
-				MarkdownDescription: "The content of the file.",
-				Description:         "The content of the file.",
-				Required:            true,
+				MarkdownDescription: "The content of the file. Exactly one of `content`, `content_base64` and `source` must be set.",
+				Description:         "The content of the file. Exactly one of content, content_base64 and source must be set.",
+				Optional:            true,
+			},
+			"content_base64": schema.StringAttribute{
+				MarkdownDescription: "The base64 encoded content of the file, to be used for binary content (e.g. DER certificates). Exactly one of `content`, `content_base64` and `source` must be set.",
+				Description:         "The base64 encoded content of the file, to be used for binary content (e.g. DER certificates). Exactly one of content, content_base64 and source must be set.",
+				Optional:            true,
+				Validators: []validator.String{
+					base64Validator{},
+				},
+			},
+			"source": schema.StringAttribute{
+				MarkdownDescription: "Path of a local file to upload. Only its checksum is kept in the state. Exactly one of `content`, `content_base64` and `source` must be set.",
+				Description:         "Path of a local file to upload. Only its checksum is kept in the state. Exactly one of content, content_base64 and source must be set.",
+				Optional:            true,
+			},
+			"backup": backupSchemaAttribute,
+			"sha256": schema.StringAttribute{
+				MarkdownDescription: "The hex encoded SHA-256 checksum of the file content.",
+				Description:         "The hex encoded SHA-256 checksum of the file content.",
+				Computed:            true,
+			},
+			"mode": schema.StringAttribute{
+				MarkdownDescription: "The permissions of the file as four octal digits (e.g. `0755`). When omitted the remote mode is left untouched.",
+				Description:         "The permissions of the file as four octal digits (e.g. 0755). When omitted the remote mode is left untouched.",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(fileModeRegexp, "must be four octal digits, e.g. 0644"),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"owner": schema.StringAttribute{
+				MarkdownDescription: "The user owning the file, either as name or numeric uid. When omitted the remote owner is left untouched.",
+				Description:         "The user owning the file, either as name or numeric uid. When omitted the remote owner is left untouched.",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"group": schema.StringAttribute{
+				MarkdownDescription: "The group owning the file, either as name or numeric gid. When omitted the remote group is left untouched.",
+				Description:         "The group owning the file, either as name or numeric gid. When omitted the remote group is left untouched.",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (c fileResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			tfpath.MatchRoot("content"),
+			tfpath.MatchRoot("content_base64"),
+			tfpath.MatchRoot("source"),
+		),
+	}
+}
+
+// ModifyPlan computes the checksum of the wanted content, so that a change
+// in a local source file is planned as an update of the resource.
+func (c fileResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan fileModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Content.IsUnknown() || plan.ContentBase64.IsUnknown() || plan.Source.IsUnknown() {
+		return
 	}
+
+	data, err := plan.data()
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read file content", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, tfpath.Root("sha256"), checksum(data))...)
 }
 
 func (c *fileResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -62,12 +195,12 @@ func (c *fileResource) Configure(_ context.Context, req resource.ConfigureReques
 	if data == nil {
 		return
 	}
-	fsFacade, ok := data.(api.FsFacade)
+	devices, ok := api.DevicesOf(data)
 	if !ok {
 		resp.Diagnostics.AddError("Failed to get fs facade", "")
 		return
 	}
-	c.fsFacade = fsFacade
+	c.devices = devices
 }
 
 func (c fileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -78,12 +211,44 @@ func (c fileResource) Create(ctx context.Context, req resource.CreateRequest, re
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.fsFacade, diags = api.DeviceClient(ctx, c.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	path := path.Join(plan.Path.ValueString(), plan.Name.ValueString())
 
-	if err := c.fsFacade.Writefile(ctx, path, []byte(plan.Content.ValueString())); err != nil {
+	data, err := plan.data()
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read file content", err.Error())
+		return
+	}
+
+	if plan.Backup.ValueBool() {
+		if err := takeBackup(ctx, c.fsFacade, path); err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed to backup file %q", path), err.Error())
+			return
+		}
+	}
+
+	if err := c.fsFacade.Writefile(ctx, path, data); err != nil {
 		resp.Diagnostics.AddError("Failed to write file", err.Error())
 		return
 	}
+	plan.Sha256 = checksum(data)
+
+	if err := c.applyPermissions(ctx, path, &plan); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to set permissions on file %q", path), err.Error())
+		return
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
@@ -95,32 +260,94 @@ func (c fileResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.fsFacade, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 	path := path.Join(state.Path.ValueString(), state.Name.ValueString())
 	b, err := c.fsFacade.ReadFile(ctx, path)
+	if errors.Is(err, api.ErrFileNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read config %q", state.Name.ValueString()), err.Error())
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read file %q", path), err.Error())
 		return
 	}
 
-	// Logic taken from the local_file provider
-	if !bytes.Equal(b, []byte(state.Content.ValueString())) {
-		resp.State.RemoveResource(ctx)
+	// The remote content is kept in the state, so that a drift is planned
+	// as an in-place update of the file.
+	state.setContent(b)
+
+	info, err := c.fsFacade.Stat(ctx, path)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to stat file %q", path), err.Error())
 		return
 	}
+	setPermissions(&state, info)
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
 }
 
 func (c fileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state fileModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan fileModel
-	diags := req.Plan.Get(ctx, &plan)
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.fsFacade, diags = api.DeviceClient(ctx, c.devices, plan.Device)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	path := path.Join(plan.Path.ValueString(), plan.Name.ValueString())
-	if err := c.fsFacade.Writefile(ctx, path, []byte(plan.Content.ValueString())); err != nil {
+
+	data, err := plan.data()
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read file content", err.Error())
+		return
+	}
+
+	if err := updateBackup(ctx, c.fsFacade, path, state.Backup, plan.Backup); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update backup of file %q", path), err.Error())
+		return
+	}
+
+	if err := c.fsFacade.Writefile(ctx, path, data); err != nil {
 		resp.Diagnostics.AddError("Failed to write file", err.Error())
+		return
+	}
+	plan.Sha256 = checksum(data)
+
+	if err := c.applyPermissions(ctx, path, &plan); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to set permissions on file %q", path), err.Error())
+		return
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
@@ -134,10 +361,194 @@ func (c fileResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.fsFacade, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	path := path.Join(state.Path.ValueString(), state.Name.ValueString())
+	if state.Backup.ValueBool() {
+		if err := restoreBackup(ctx, c.fsFacade, path); err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed to restore file %q", path), err.Error())
+		}
+		return
+	}
+
 	err := c.fsFacade.RemoveFile(ctx, path)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed to delete config file %q", state.Name.ValueString()), err.Error())
 		return
 	}
 }
+
+func (c fileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, c.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c.fsFacade = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, tfpath.Root("device"), device)...)
+
+	filePath := path.Clean(id)
+	if !path.IsAbs(filePath) || strings.HasSuffix(id, "/") {
+		resp.Diagnostics.AddError("Invalid import identifier",
+			fmt.Sprintf("expected an absolute file path (e.g. /etc/test.txt), got %q", id))
+		return
+	}
+
+	b, err := c.fsFacade.ReadFile(ctx, filePath)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read file %q", filePath), err.Error())
+		return
+	}
+
+	info, err := c.fsFacade.Stat(ctx, filePath)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to stat file %q", filePath), err.Error())
+		return
+	}
+
+	dir, name := path.Split(filePath)
+	state := fileModel{
+		Path:          types.StringValue(path.Clean(dir)),
+		Name:          types.StringValue(name),
+		Content:       types.StringNull(),
+		ContentBase64: types.StringNull(),
+		Source:        types.StringNull(),
+		Sha256:        checksum(b),
+		Mode:          types.StringNull(),
+		Owner:         types.StringNull(),
+		Group:         types.StringNull(),
+		Backup:        types.BoolValue(false),
+	}
+	if utf8.Valid(b) {
+		state.Content = types.StringValue(string(b))
+	} else {
+		state.ContentBase64 = types.StringValue(base64.StdEncoding.EncodeToString(b))
+	}
+	setPermissions(&state, info)
+
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, tfpath.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, tfpath.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// applyPermissions sets the configured mode and ownership on the remote file
+// and stores the resulting values, read back from the router, into the model.
+func (c fileResource) applyPermissions(ctx context.Context, filePath string, model *fileModel) error {
+	if isSet(model.Mode) {
+		if err := c.fsFacade.Chmod(ctx, filePath, model.Mode.ValueString()); err != nil {
+			return fmt.Errorf("chmod %s: %w", model.Mode.ValueString(), err)
+		}
+	}
+
+	if isSet(model.Owner) || isSet(model.Group) {
+		owner, group := "", ""
+		if isSet(model.Owner) {
+			owner = model.Owner.ValueString()
+		}
+		if isSet(model.Group) {
+			group = model.Group.ValueString()
+		}
+
+		if err := c.fsFacade.Chown(ctx, filePath, owner, group); err != nil {
+			return fmt.Errorf("chown %s:%s: %w", owner, group, err)
+		}
+	}
+
+	info, err := c.fsFacade.Stat(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	setPermissions(model, info)
+
+	return nil
+}
+
+func isSet(v types.String) bool {
+	return !v.IsNull() && !v.IsUnknown()
+}
+
+// setPermissions copies the remote file permissions into the model. Owner and
+// group keep the representation (name or numeric id) already used in the model.
+func setPermissions(model *fileModel, info *api.FileInfo) {
+	model.Mode = types.StringValue(info.Mode)
+	model.Owner = types.StringValue(idOrName(model.Owner, info.Uid, info.Owner))
+	model.Group = types.StringValue(idOrName(model.Group, info.Gid, info.Group))
+}
+
+func idOrName(current types.String, id int, name string) string {
+	if isSet(current) {
+		if _, err := strconv.Atoi(current.ValueString()); err == nil {
+			return strconv.Itoa(id)
+		}
+	}
+
+	if name == "" {
+		return strconv.Itoa(id)
+	}
+	return name
+}
+
+// data returns the wanted content of the file, from whichever of content,
+// content_base64 or source is set.
+func (m fileModel) data() ([]byte, error) {
+	switch {
+	case isSet(m.ContentBase64):
+		return base64.StdEncoding.DecodeString(m.ContentBase64.ValueString())
+	case isSet(m.Source):
+		return os.ReadFile(m.Source.ValueString())
+	default:
+		return []byte(m.Content.ValueString()), nil
+	}
+}
+
+// setContent stores the remote content into the model. The checksum is
+// always updated, while content and content_base64 are updated only when they
+// are the way the file is managed and can represent the remote bytes.
+func (m *fileModel) setContent(remote []byte) {
+	m.Sha256 = checksum(remote)
+
+	switch {
+	case isSet(m.ContentBase64):
+		m.ContentBase64 = types.StringValue(base64.StdEncoding.EncodeToString(remote))
+	case isSet(m.Content) && utf8.Valid(remote):
+		m.Content = types.StringValue(string(remote))
+	}
+}
+
+func checksum(data []byte) types.String {
+	sum := sha256.Sum256(data)
+	return types.StringValue(hex.EncodeToString(sum[:]))
+}
+
+var _ validator.String = base64Validator{}
+
+type base64Validator struct{}
+
+func (v base64Validator) Description(_ context.Context) string {
+	return "value must be valid standard base64"
+}
+
+func (v base64Validator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v base64Validator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid base64 content", err.Error())
+	}
+}
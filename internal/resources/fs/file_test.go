@@ -0,0 +1,482 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package fs_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	tfjson "github.com/hashicorp/terraform-json"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// fakeFs keeps the remote files in memory so that tests can inspect and
+// alter them between steps.
+type fakeFs struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	infos map[string]*api.FileInfo
+}
+
+func newFakeFs(client *mocks.MockClient) *fakeFs {
+	f := &fakeFs{
+		files: map[string][]byte{},
+		infos: map[string]*api.FileInfo{},
+	}
+
+	client.
+		EXPECT().
+		Writefile(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string, data []byte) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.files[path] = data
+			if _, ok := f.infos[path]; !ok {
+				f.infos[path] = &api.FileInfo{Mode: "0644", Owner: "root", Group: "root"}
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		ReadFile(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string) ([]byte, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			b, ok := f.files[path]
+			if !ok {
+				return nil, api.ErrFileNotFound
+			}
+			return b, nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		RemoveFile(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.files, path)
+			delete(f.infos, path)
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Stat(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string) (*api.FileInfo, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			info, ok := f.infos[path]
+			if !ok {
+				return nil, api.ErrFileNotFound
+			}
+			toReturn := *info
+			return &toReturn, nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Chmod(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path, mode string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.infos[path].Mode = mode
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Chown(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path, owner, group string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if owner != "" {
+				f.infos[path].Owner = owner
+			}
+			if group != "" {
+				f.infos[path].Group = group
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Rename(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, src, dst string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.files[dst], f.infos[dst] = f.files[src], f.infos[src]
+			delete(f.files, src)
+			delete(f.infos, src)
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Copy(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, src, dst string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			info := *f.infos[src]
+			f.files[dst], f.infos[dst] = f.files[src], &info
+			return nil
+		}).
+		AnyTimes()
+
+	return f
+}
+
+func (f *fakeFs) setMode(path, mode string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.infos[path].Mode = mode
+}
+
+func (f *fakeFs) write(path string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = data
+	if _, ok := f.infos[path]; !ok {
+		f.infos[path] = &api.FileInfo{Mode: "0644", Owner: "root", Group: "root"}
+	}
+}
+
+func (f *fakeFs) remove(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.files, path)
+	delete(f.infos, path)
+}
+
+func (f *fakeFs) content(path string) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.files[path]
+}
+
+func (f *fakeFs) exists(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.files[path]
+	return ok
+}
+
+func providerMocks(t *testing.T, ctrl *gomock.Controller, clientFactory *mocks.MockClientFactory) *mocks.MockClient {
+	client := mocks.NewMockClient(ctrl)
+	timeouts := mocks.NewMockTimeouts(ctrl)
+
+	testutil.ExpectTransactions(client)
+
+	client.
+		EXPECT().
+		Auth(gomock.Any(), "root", "test").
+		Return(nil).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		UpdatePackages(gomock.Any()).
+		Return(nil).
+		AnyTimes()
+
+	var confirmedApply atomic.Bool
+	client.
+		EXPECT().
+		EnableConfirmedApply(gomock.Any()).
+		Do(func(_ time.Duration) {
+			confirmedApply.Store(true)
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		ConfirmedApply().
+		DoAndReturn(confirmedApply.Load).
+		AnyTimes()
+
+	clientFactory.
+		EXPECT().
+		ParseTimeouts(gomock.Any(), gomock.Any()).
+		Return(timeouts, nil).
+		AnyTimes()
+
+	clientFactory.
+		EXPECT().
+		Get(gomock.Any(), "http://test.lan:8080", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ api.Timeouts) (api.Client, error) {
+			t.Logf("Get method called")
+			return client, nil
+		}).
+		AnyTimes()
+
+	return client
+}
+
+const providerConfig = `
+provider "openwrt" {
+	user     = "root"
+	password = "test"
+	remote   = "http://test.lan:8080"
+}
+`
+
+func TestAccFile_Permissions(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				resource "openwrt_file" "script" {
+					path    = "/usr/bin"
+					name    = "hello"
+					content = "#!/bin/sh\necho hello\n"
+					mode    = "755"
+				}`,
+				ExpectError: regexp.MustCompile("must be four octal digits"),
+			},
+			{
+				Config: providerConfig + `
+				resource "openwrt_file" "script" {
+					path    = "/usr/bin"
+					name    = "hello"
+					content = "#!/bin/sh\necho hello\n"
+					mode    = "0755"
+					group   = "0"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_file.script", "mode", "0755"),
+					resource.TestCheckResourceAttr("openwrt_file.script", "owner", "root"),
+					resource.TestCheckResourceAttr("openwrt_file.script", "group", "0"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				PreConfig: func() {
+					remote.setMode("/usr/bin/hello", "0644")
+				},
+				Config: providerConfig + `
+				resource "openwrt_file" "script" {
+					path    = "/usr/bin"
+					name    = "hello"
+					content = "#!/bin/sh\necho hello\n"
+					mode    = "0755"
+					group   = "0"
+				}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_file.script",
+							Action: tfjson.ActionUpdate,
+						},
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_file.script",
+				ImportState:                          true,
+				ImportStateId:                        "/usr/bin/hello",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+				// the imported group is resolved to its name
+				ImportStateVerifyIgnore: []string{"group"},
+			},
+		},
+	})
+}
+
+func TestAccFile_BinaryContent(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	source := filepath.Join(t.TempDir(), "firmware.bin")
+	if err := os.WriteFile(source, []byte{0x00, 0xff, 0xfe}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				resource "openwrt_file" "cert" {
+					path    = "/etc"
+					name    = "cert.der"
+					content = "text"
+					source  = "/tmp/cert.der"
+				}`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: providerConfig + `
+				resource "openwrt_file" "cert" {
+					path           = "/etc"
+					name           = "cert.der"
+					content_base64 = "MIIB/w=="
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_file.cert", "sha256",
+						"2e24ac9eb90a6e49a6c1c031b722f4abb7218559f264347e8bcb820905a2365e"),
+					func(_ *terraform.State) error {
+						if got := remote.content("/etc/cert.der"); !bytes.Equal(got, []byte{0x30, 0x82, 0x01, 0xff}) {
+							return fmt.Errorf("unexpected remote content %x", got)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: providerConfig + `
+				resource "openwrt_file" "firmware" {
+					path   = "/tmp"
+					name   = "firmware.bin"
+					source = "` + source + `"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_file.firmware", "sha256",
+						"d590f90f7944340fb253f0c59cb89fd41d4ec255ff246f524f8f7c94f0a233e5"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				PreConfig: func() {
+					if err := os.WriteFile(source, []byte{0x01}, 0o600); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: providerConfig + `
+				resource "openwrt_file" "firmware" {
+					path   = "/tmp"
+					name   = "firmware.bin"
+					source = "` + source + `"
+				}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_file.firmware",
+							Action: tfjson.ActionUpdate,
+						},
+					},
+				},
+				Check: func(_ *terraform.State) error {
+					if got := remote.content("/tmp/firmware.bin"); !bytes.Equal(got, []byte{0x01}) {
+						return fmt.Errorf("unexpected remote content %x", got)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestAccFile_Drift(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	client := providerMocks(t, ctrl, clientFactory)
+	remote := newFakeFs(client)
+
+	config := providerConfig + `
+	resource "openwrt_file" "motd" {
+		path    = "/etc"
+		name    = "banner"
+		content = "welcome\n"
+	}`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testutil.TestAccFactories(clientFactory),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+			{
+				PreConfig: func() {
+					remote.write("/etc/banner", []byte("edited by hand\n"))
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_file.motd",
+							Action: tfjson.ActionUpdate,
+						},
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+				Check: resource.TestCheckResourceAttr("openwrt_file.motd", "content", "welcome\n"),
+			},
+			{
+				PreConfig: func() {
+					remote.remove("/etc/banner")
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						&testutil.ActionPlanChecker{
+							Addr:   "openwrt_file.motd",
+							Action: tfjson.ActionCreate,
+						},
+					},
+				},
+			},
+		},
+	})
+}
@@ -6,9 +6,12 @@ package opkg
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -17,10 +20,14 @@ import (
 
 type opkgModel struct {
 	Packages types.List `tfsdk:"packages"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 type opkgResource struct {
 	opkgFacade api.OpkgFacade
+	devices    api.Devices
 }
 
 func NewOpkgResource() resource.Resource {
@@ -31,11 +38,12 @@ func (c opkgResource) Metadata(_ context.Context, req resource.MetadataRequest,
 	resp.TypeName = fmt.Sprintf("%s_opkg", req.ProviderTypeName)
 }
 
-func (c opkgResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (c opkgResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Install packages on the router",
 		Description:         "Install packages on the router",
 		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
 			"packages": schema.ListAttribute{
 				MarkdownDescription: "The list of packages to install via opkg package manager",
 				Description:         "The list of packages to install via opkg package manager",
@@ -43,6 +51,14 @@ func (c opkgResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 				Required:            true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -51,12 +67,12 @@ func (c *opkgResource) Configure(_ context.Context, req resource.ConfigureReques
 	if data == nil {
 		return
 	}
-	opkgFacade, ok := data.(api.OpkgFacade)
+	devices, ok := api.DevicesOf(data)
 	if !ok {
 		resp.Diagnostics.AddError("failed to get opkg facade", "")
 		return
 	}
-	c.opkgFacade = opkgFacade
+	c.devices = devices
 }
 
 func (c opkgResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -67,6 +83,19 @@ func (c opkgResource) Create(ctx context.Context, req resource.CreateRequest, re
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.opkgFacade, diags = api.DeviceClient(ctx, c.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	for _, aPackage := range plan.Packages.Elements() {
 		value, err := aPackage.ToTerraformValue(ctx)
 		if err != nil {
@@ -104,6 +133,19 @@ func (c opkgResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.opkgFacade, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	result := make([]attr.Value, 0, len(state.Packages.Elements()))
 	for _, packageElm := range state.Packages.Elements() {
 		packageValue, err := packageElm.ToTerraformValue(ctx)
@@ -149,6 +191,19 @@ func (c opkgResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.opkgFacade, diags = api.DeviceClient(ctx, c.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	planSet := make(map[string]struct{})
 	for _, aPackage := range plan.Packages.Elements() {
 		value, err := aPackage.ToTerraformValue(ctx)
@@ -216,6 +271,19 @@ func (c opkgResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.opkgFacade, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	for _, aPackage := range state.Packages.Elements() {
 		value, err := aPackage.ToTerraformValue(ctx)
 		if err != nil {
@@ -236,3 +304,47 @@ func (c opkgResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		}
 	}
 }
+
+func (c opkgResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, c.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c.opkgFacade = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	packages := make([]attr.Value, 0)
+	for _, aPackage := range strings.Split(id, ",") {
+		aPackage = strings.TrimSpace(aPackage)
+		if aPackage == "" {
+			continue
+		}
+
+		re, err := c.opkgFacade.CheckPackage(ctx, aPackage)
+		if err != nil {
+			resp.Diagnostics.AddError("checking package went in error", fmt.Sprintf("%s: %v", aPackage, err))
+			return
+		}
+
+		if !re.Status.Installed {
+			resp.Diagnostics.AddError("package not installed", fmt.Sprintf("%s can not be imported because it is not installed", aPackage))
+			return
+		}
+
+		packages = append(packages, types.StringValue(aPackage))
+	}
+
+	if len(packages) == 0 {
+		resp.Diagnostics.AddError("Invalid import identifier",
+			fmt.Sprintf("expected a comma-separated list of packages (e.g. luci,tcpdump), got %q", id))
+		return
+	}
+
+	state := opkgModel{
+		Packages: basetypes.NewListValueMust(types.StringType, packages),
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
@@ -928,3 +928,94 @@ func TestAccOpkg_CheckPackageInUpdateIsFailing(t *testing.T) {
 		},
 	})
 }
+
+func TestAccOpkg_Import(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	timeouts := mocks.NewMockTimeouts(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck: func() {
+			client := mocks.NewMockClient(ctrl)
+
+			client.
+				EXPECT().
+				Auth(gomock.Any(), "root", "test").
+				Return(nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				UpdatePackages(gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			clientFactory.
+				EXPECT().
+				ParseTimeouts(gomock.Any(), gomock.Any()).
+				Return(timeouts, nil).
+				AnyTimes()
+
+			clientFactory.
+				EXPECT().
+				Get(gomock.Any(), "http://test.lan:8080", gomock.Any()).
+				Return(client, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				CheckPackage(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, s string) (*api.PackageInfo, error) {
+					t.Logf("CheckPackage method called for %s", s)
+					return &api.PackageInfo{
+						Version: "test",
+						Status: api.Status{
+							Installed: s != "tcpdump",
+						},
+					}, nil
+				}).
+				AnyTimes()
+
+			//Teardown resource
+			client.
+				EXPECT().
+				RemovePackages(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				provider "openwrt" {
+					user     = "root"
+					password = "test"
+					remote   = "http://test.lan:8080"
+				}
+
+				resource "openwrt_opkg" "test" {
+					packages = ["curl", "luci"]
+				}`,
+			},
+			{
+				ResourceName:                         "openwrt_opkg.test",
+				ImportState:                          true,
+				ImportStateId:                        "curl, luci",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "packages.0",
+			},
+			{
+				ResourceName:  "openwrt_opkg.test",
+				ImportState:   true,
+				ImportStateId: "curl,tcpdump",
+				ExpectError:   regexp.MustCompile("package not installed"),
+			},
+		},
+	})
+}
@@ -0,0 +1,441 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package rpcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// aclDir is the directory rpcd loads the ACL groups from when a session is
+// opened.
+const aclDir = "/usr/share/rpcd/acl.d"
+
+var aclNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type aclModel struct {
+	Name        types.String      `tfsdk:"name"`
+	Description types.String      `tfsdk:"description"`
+	Read        *permissionsModel `tfsdk:"read"`
+	Write       *permissionsModel `tfsdk:"write"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type permissionsModel struct {
+	Ubus types.Map  `tfsdk:"ubus"`
+	Uci  types.List `tfsdk:"uci"`
+	File types.Map  `tfsdk:"file"`
+}
+
+// aclGroup is an ACL group in the format of the files of aclDir.
+type aclGroup struct {
+	Description string       `json:"description,omitempty"`
+	Read        *permissions `json:"read,omitempty"`
+	Write       *permissions `json:"write,omitempty"`
+}
+
+type permissions struct {
+	Ubus map[string][]string `json:"ubus,omitempty"`
+	Uci  []string            `json:"uci,omitempty"`
+	File map[string][]string `json:"file,omitempty"`
+}
+
+type aclResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*aclResource)(nil)
+	_ resource.ResourceWithImportState = (*aclResource)(nil)
+)
+
+// NewAclResource return new rpcd ACL resource.
+func NewAclResource() resource.Resource {
+	return &aclResource{}
+}
+
+func (a aclResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_rpcd_acl", req.ProviderTypeName)
+}
+
+func (a aclResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	permissionsAttribute := func(access string) schema.SingleNestedAttribute {
+		return schema.SingleNestedAttribute{
+			MarkdownDescription: fmt.Sprintf("What the group grants to the logins it is a %s group of.", access),
+			Description:         fmt.Sprintf("What the group grants to the logins it is a %s group of.", access),
+			Optional:            true,
+			Attributes: map[string]schema.Attribute{
+				"ubus": schema.MapAttribute{
+					MarkdownDescription: "Methods allowed by ubus object, e.g. `{ \"file\" = [\"read\", \"stat\"] }`. Both support `*` wildcards.",
+					Description:         "Methods allowed by ubus object, e.g. { \"file\" = [\"read\", \"stat\"] }. Both support * wildcards.",
+					ElementType:         types.ListType{ElemType: types.StringType},
+					Optional:            true,
+					Validators: []validator.Map{
+						mapvalidator.SizeAtLeast(1),
+						mapvalidator.ValueListsAre(listvalidator.SizeAtLeast(1)),
+					},
+				},
+				"uci": schema.ListAttribute{
+					MarkdownDescription: "UCI configurations allowed, e.g. `[\"network\", \"firewall\"]`, or `[\"*\"]` for all of them.",
+					Description:         "UCI configurations allowed, e.g. [\"network\", \"firewall\"], or [\"*\"] for all of them.",
+					ElementType:         types.StringType,
+					Optional:            true,
+					Validators: []validator.List{
+						listvalidator.SizeAtLeast(1),
+					},
+				},
+				"file": schema.MapAttribute{
+					MarkdownDescription: "Operations allowed by path of the `file` object, e.g. `{ \"/etc/dropbear/*\" = [\"read\", \"write\"] }`. The `exec` operation allows to run the command at that path.",
+					Description:         "Operations allowed by path of the file object, e.g. { \"/etc/dropbear/*\" = [\"read\", \"write\"] }. The exec operation allows to run the command at that path.",
+					ElementType:         types.ListType{ElemType: types.StringType},
+					Optional:            true,
+					Validators: []validator.Map{
+						mapvalidator.SizeAtLeast(1),
+						mapvalidator.ValueListsAre(listvalidator.SizeAtLeast(1)),
+					},
+				},
+			},
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage an ACL group of rpcd, in its own file of `/usr/share/rpcd/acl.d`. " +
+			"The group is granted to the logins listing it in their `read` or `write` groups, e.g. with `openwrt_rpcd_login`. Changes apply to the sessions opened afterwards.",
+		Description: "Manage an ACL group of rpcd, in its own file of /usr/share/rpcd/acl.d. " +
+			"The group is granted to the logins listing it in their read or write groups, e.g. with openwrt_rpcd_login. Changes apply to the sessions opened afterwards.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the group, e.g. `terraform-dns`. The group is written to `/usr/share/rpcd/acl.d/<name>.json`.",
+				Description:         "Name of the group, e.g. terraform-dns. The group is written to /usr/share/rpcd/acl.d/<name>.json.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(aclNameRegexp, "must only contain letters, digits, dots, dashes and underscores"),
+				},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Description of the group, displayed by LuCI.",
+				Description:         "Description of the group, displayed by LuCI.",
+				Optional:            true,
+			},
+			"read":  permissionsAttribute("read"),
+			"write": permissionsAttribute("write"),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (a *aclResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	a.devices = devices
+}
+
+func (a aclResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan aclModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filePath := aclPath(plan.Name.ValueString())
+	_, err := a.provider.ReadFile(ctx, filePath)
+	if err == nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("File %s already exists", filePath),
+			"Import it to manage it with this resource.")
+		return
+	}
+	if !errors.Is(err, api.ErrFileNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read %s", filePath), err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(a.write(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (a aclResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state aclModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	newState, diags := a.read(ctx, state.Name.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if newState == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	newState.Device = state.Device
+	newState.Timeouts = state.Timeouts
+	resp.Diagnostics.Append(resp.State.Set(ctx, newState)...)
+}
+
+func (a aclResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan aclModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(a.write(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (a aclResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state aclModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	a.provider, diags = api.DeviceClient(ctx, a.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filePath := aclPath(state.Name.ValueString())
+	if err := a.provider.RemoveFile(ctx, filePath); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to remove %s", filePath), err.Error())
+	}
+}
+
+func (a aclResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, a.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	a.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("device"), device)...)
+
+	state, diags := a.read(ctx, id)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if state == nil {
+		resp.Diagnostics.AddError("Failed to import state", fmt.Sprintf("%s does not exist", aclPath(id)))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, fwpath.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, fwpath.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func aclPath(name string) string {
+	return path.Join(aclDir, name+".json")
+}
+
+// read returns the model of the named group, or nil when its file does not
+// exist.
+func (a aclResource) read(ctx context.Context, name string) (*aclModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	filePath := aclPath(name)
+
+	content, err := a.provider.ReadFile(ctx, filePath)
+	if errors.Is(err, api.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		diags.AddError(fmt.Sprintf("Failed to read %s", filePath), err.Error())
+		return nil, diags
+	}
+
+	var groups map[string]aclGroup
+	if err := json.Unmarshal(content, &groups); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to parse %s", filePath), err.Error())
+		return nil, diags
+	}
+	group, ok := groups[name]
+	if !ok || len(groups) != 1 {
+		diags.AddError(fmt.Sprintf("Unexpected content of %s", filePath),
+			fmt.Sprintf("The file must only hold the %q group.", name))
+		return nil, diags
+	}
+
+	m := &aclModel{
+		Name:        types.StringValue(name),
+		Description: types.StringNull(),
+	}
+	if group.Description != "" {
+		m.Description = types.StringValue(group.Description)
+	}
+	if m.Read, diags = newPermissionsModel(ctx, group.Read); diags.HasError() {
+		return m, diags
+	}
+	m.Write, diags = newPermissionsModel(ctx, group.Write)
+	return m, diags
+}
+
+// write replaces the file of the group with the model.
+func (a aclResource) write(ctx context.Context, m aclModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	group := aclGroup{Description: m.Description.ValueString()}
+	if group.Read, diags = m.Read.permissions(ctx); diags.HasError() {
+		return diags
+	}
+	if group.Write, diags = m.Write.permissions(ctx); diags.HasError() {
+		return diags
+	}
+
+	content, err := json.MarshalIndent(map[string]aclGroup{m.Name.ValueString(): group}, "", "\t")
+	if err != nil {
+		diags.AddError("Failed to encode the ACL group", err.Error())
+		return diags
+	}
+
+	filePath := aclPath(m.Name.ValueString())
+	if err := a.provider.Writefile(ctx, filePath, append(content, '\n')); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to write %s", filePath), err.Error())
+	}
+	return diags
+}
+
+func (m *permissionsModel) permissions(ctx context.Context) (*permissions, diag.Diagnostics) {
+	if m == nil {
+		return nil, nil
+	}
+
+	var diags diag.Diagnostics
+	p := &permissions{}
+	if !m.Ubus.IsNull() {
+		diags.Append(m.Ubus.ElementsAs(ctx, &p.Ubus, false)...)
+	}
+	if !m.Uci.IsNull() {
+		diags.Append(m.Uci.ElementsAs(ctx, &p.Uci, false)...)
+	}
+	if !m.File.IsNull() {
+		diags.Append(m.File.ElementsAs(ctx, &p.File, false)...)
+	}
+	return p, diags
+}
+
+func newPermissionsModel(ctx context.Context, p *permissions) (*permissionsModel, diag.Diagnostics) {
+	if p == nil {
+		return nil, nil
+	}
+
+	var diags, d diag.Diagnostics
+	listType := types.ListType{ElemType: types.StringType}
+	m := &permissionsModel{
+		Ubus: types.MapNull(listType),
+		Uci:  types.ListNull(types.StringType),
+		File: types.MapNull(listType),
+	}
+	if len(p.Ubus) > 0 {
+		m.Ubus, d = types.MapValueFrom(ctx, listType, p.Ubus)
+		diags.Append(d...)
+	}
+	if len(p.Uci) > 0 {
+		m.Uci, d = types.ListValueFrom(ctx, types.StringType, p.Uci)
+		diags.Append(d...)
+	}
+	if len(p.File) > 0 {
+		m.File, d = types.MapValueFrom(ctx, listType, p.File)
+		diags.Append(d...)
+	}
+	return m, diags
+}
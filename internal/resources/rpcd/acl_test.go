@@ -0,0 +1,160 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package rpcd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// checkAcl verifies the JSON content of the file, or that it is missing if
+// expected is empty.
+func checkAcl(files *testutil.FakeFiles, path, expected string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		content, ok := files.File(path)
+		if expected == "" {
+			if ok {
+				return fmt.Errorf("%s still exists", path)
+			}
+			return nil
+		}
+		if !ok {
+			return fmt.Errorf("%s not found", path)
+		}
+
+		var got, want any
+		if err := json.Unmarshal([]byte(content), &got); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(expected), &want); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("expected %s to be %s, got %s", path, expected, content)
+		}
+		return nil
+	}
+}
+
+func TestAccRpcdAcl(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	files := testutil.NewFakeFiles(client, map[string]string{
+		"/usr/share/rpcd/acl.d/unauthenticated.json": `{"unauthenticated": {"read": {"ubus": {"session": ["access", "login"]}}}}`,
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_acl" "dns" {
+					name = "../dns"
+				}`,
+				ExpectError: regexp.MustCompile(`must only contain letters, digits, dots, dashes and\s+underscores`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_acl" "unauthenticated" {
+					name = "unauthenticated"
+				}`,
+				ExpectError: regexp.MustCompile(`File /usr/share/rpcd/acl.d/unauthenticated.json already exists`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_acl" "dns" {
+					name        = "terraform-dns"
+					description = "DNS records"
+					read = {
+						ubus = {
+							uci = ["get"]
+						}
+						uci = ["dhcp"]
+					}
+					write = {
+						ubus = {
+							uci = ["add", "set", "delete", "commit"]
+						}
+						uci  = ["dhcp"]
+						file = {
+							"/bin/chmod" = ["exec"]
+						}
+					}
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkAcl(files, "/usr/share/rpcd/acl.d/terraform-dns.json", `{
+						"terraform-dns": {
+							"description": "DNS records",
+							"read": {"ubus": {"uci": ["get"]}, "uci": ["dhcp"]},
+							"write": {
+								"ubus": {"uci": ["add", "set", "delete", "commit"]},
+								"uci": ["dhcp"],
+								"file": {"/bin/chmod": ["exec"]}
+							}
+						}
+					}`),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_acl" "dns" {
+					name = "terraform-dns"
+					read = {
+						uci = ["dhcp", "network"]
+					}
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkAcl(files, "/usr/share/rpcd/acl.d/terraform-dns.json", `{
+						"terraform-dns": {"read": {"uci": ["dhcp", "network"]}}
+					}`),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_rpcd_acl.dns", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_rpcd_acl.dns",
+				ImportState:                          true,
+				ImportStateId:                        "terraform-dns",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			checkAcl(files, "/usr/share/rpcd/acl.d/terraform-dns.json", ""),
+			checkAcl(files, "/usr/share/rpcd/acl.d/unauthenticated.json",
+				`{"unauthenticated": {"read": {"ubus": {"session": ["access", "login"]}}}}`),
+		),
+	})
+}
@@ -0,0 +1,444 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package rpcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const rpcdConfig = "rpcd"
+
+var (
+	// loginUsernameRegexp rejects the characters rpcd can not match a
+	// login against.
+	loginUsernameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// passwordHashRegexp matches the crypt(3) hashes rpcd checks the
+	// password against, or the $p$ reference to a system account.
+	passwordHashRegexp = regexp.MustCompile(`^\$(p\$[a-z_][a-z0-9_-]*|(1|5|6|2[aby])\$\S+)$`)
+	// aclGroupRegexp matches the name of an ACL group, or * for all of them.
+	aclGroupRegexp = regexp.MustCompile(`^(\*|[A-Za-z0-9_.-]+)$`)
+)
+
+type loginModel struct {
+	Username     types.String `tfsdk:"username"`
+	PasswordHash types.String `tfsdk:"password_hash"`
+	Timeout      types.Int64  `tfsdk:"timeout"`
+	Read         types.Set    `tfsdk:"read"`
+	Write        types.Set    `tfsdk:"write"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type loginResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*loginResource)(nil)
+	_ resource.ResourceWithImportState = (*loginResource)(nil)
+)
+
+// NewLoginResource return new rpcd login resource.
+func NewLoginResource() resource.Resource {
+	return &loginResource{}
+}
+
+func (l loginResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_rpcd_login", req.ProviderTypeName)
+}
+
+func (l loginResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	aclGroups := func(access string) schema.SetAttribute {
+		return schema.SetAttribute{
+			MarkdownDescription: fmt.Sprintf("ACL groups granting %s access to the login, e.g. the `name` of an `openwrt_rpcd_acl`, or `*` for all of them.", access),
+			Description:         fmt.Sprintf("ACL groups granting %s access to the login, e.g. the name of an openwrt_rpcd_acl, or * for all of them.", access),
+			ElementType:         types.StringType,
+			Optional:            true,
+			Validators: []validator.Set{
+				setvalidator.SizeAtLeast(1),
+				setvalidator.ValueStringsAre(
+					stringvalidator.RegexMatches(aclGroupRegexp, "must be the name of an ACL group or *"),
+				),
+			},
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a `login` section of the rpcd configuration, i.e. an account of the ubus JSON RPC API, limited to the ACL groups it is granted. " +
+			"The provider can authenticate with it using the `ubus` transport. Changes apply to the sessions opened afterwards.",
+		Description: "Manage a login section of the rpcd configuration, i.e. an account of the ubus JSON RPC API, limited to the ACL groups it is granted. " +
+			"The provider can authenticate with it using the ubus transport. Changes apply to the sessions opened afterwards.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"username": schema.StringAttribute{
+				MarkdownDescription: "Name of the login, e.g. `terraform`. It doesn't need to be a system account.",
+				Description:         "Name of the login, e.g. terraform. It doesn't need to be a system account.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(loginUsernameRegexp, "must only contain letters, digits, dots, dashes and underscores"),
+				},
+			},
+			"password_hash": schema.StringAttribute{
+				MarkdownDescription: "crypt(3) hash of the password, e.g. the output of `openssl passwd -6`, or `$p$<account>` to use the password of a system account.",
+				Description:         "crypt(3) hash of the password, e.g. the output of openssl passwd -6, or $p$<account> to use the password of a system account.",
+				Required:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(passwordHashRegexp, "must be a $1$, $5$, $6$ or $2y$ crypt hash, or $p$ followed by an account name"),
+				},
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Inactivity timeout of the sessions, in seconds. (Default: `300`)",
+				Description:         "Inactivity timeout of the sessions, in seconds. (Default: 300)",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"read":  aclGroups("read"),
+			"write": aclGroups("write"),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (l *loginResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	l.devices = devices
+}
+
+func (l loginResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan loginModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	username := plan.Username.ValueString()
+	_, err := l.provider.GetRpcdLogin(ctx, username)
+	if err == nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Login %q already exists", username),
+			"Import it to manage it with this resource.")
+		return
+	}
+	if !errors.Is(err, api.ErrSectionNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read login %q", username), err.Error())
+		return
+	}
+
+	options, diags := plan.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(l.commit(ctx, fmt.Sprintf("Failed to add login %q", username), func(ctx context.Context) error {
+		// login sections are anonymous, they are told apart by their username
+		section, err := l.provider.Add(ctx, rpcdConfig, "login")
+		if err != nil {
+			return err
+		}
+		return l.provider.TSet(ctx, options, rpcdConfig, section)
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (l loginResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state loginModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	login, err := l.provider.GetRpcdLogin(ctx, state.Username.ValueString())
+	if errors.Is(err, api.ErrSectionNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read login %q", state.Username.ValueString()), err.Error())
+		return
+	}
+
+	refreshed, diags := newLoginModel(ctx, login)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	refreshed.Device = state.Device
+	refreshed.Timeouts = state.Timeouts
+	resp.Diagnostics.Append(resp.State.Set(ctx, refreshed)...)
+}
+
+func (l loginResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state loginModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan loginModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	username := plan.Username.ValueString()
+	login, err := l.provider.GetRpcdLogin(ctx, username)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read login %q", username), err.Error())
+		return
+	}
+
+	options, diags := plan.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	stateOptions, diags := state.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(l.commit(ctx, fmt.Sprintf("Failed to update login %q", username), func(ctx context.Context) error {
+		if err := l.provider.TSet(ctx, options, rpcdConfig, login.Id); err != nil {
+			return err
+		}
+
+		for option := range stateOptions {
+			if _, ok := options[option]; ok {
+				continue
+			}
+			if err := l.provider.Delete(ctx, rpcdConfig, login.Id, option); err != nil {
+				return fmt.Errorf("failed to remove option %q: %w", option, err)
+			}
+		}
+		return nil
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (l loginResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state loginModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	username := state.Username.ValueString()
+	login, err := l.provider.GetRpcdLogin(ctx, username)
+	if errors.Is(err, api.ErrSectionNotFound) {
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read login %q", username), err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(l.commit(ctx, fmt.Sprintf("Failed to delete login %q", username), func(ctx context.Context) error {
+		return l.provider.Delete(ctx, rpcdConfig, login.Id)
+	})...)
+}
+
+func (l loginResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, l.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	l.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	login, err := l.provider.GetRpcdLogin(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+
+	state, diags := newLoginModel(ctx, login)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// commit stages the changes of the rpcd configuration with stage, and
+// commits them. summary describes the change if it fails. rpcd reads the
+// logins when a session is opened, so it doesn't need to be restarted.
+func (l loginResource) commit(ctx context.Context, summary string, stage func(ctx context.Context) error) (diags diag.Diagnostics) {
+	if err := l.provider.Transaction(ctx, rpcdConfig, stage); err != nil {
+		diags.AddError(summary, err.Error())
+	}
+	return diags
+}
+
+// options returns the options set in the model, by their UCI name.
+func (m loginModel) options(ctx context.Context) (map[string]any, diag.Diagnostics) {
+	options := map[string]any{
+		"username": m.Username.ValueString(),
+		"password": m.PasswordHash.ValueString(),
+	}
+	if !m.Timeout.IsNull() {
+		options["timeout"] = strconv.FormatInt(m.Timeout.ValueInt64(), 10)
+	}
+	for name, value := range map[string]types.Set{
+		"read":  m.Read,
+		"write": m.Write,
+	} {
+		if value.IsNull() {
+			continue
+		}
+		var groups []string
+		if diags := value.ElementsAs(ctx, &groups, false); diags.HasError() {
+			return nil, diags
+		}
+		slices.Sort(groups)
+		options[name] = groups
+	}
+	return options, nil
+}
+
+func newLoginModel(ctx context.Context, login *api.RpcdLogin) (loginModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	m := loginModel{
+		Username:     types.StringValue(login.Username),
+		PasswordHash: types.StringValue(login.Password),
+		Timeout:      types.Int64Null(),
+		Read:         types.SetNull(types.StringType),
+		Write:        types.SetNull(types.StringType),
+	}
+
+	if login.Timeout != "" {
+		timeout, err := strconv.ParseInt(login.Timeout, 10, 64)
+		if err != nil {
+			diags.AddError(fmt.Sprintf("Invalid timeout of login %q", login.Username), err.Error())
+			return m, diags
+		}
+		m.Timeout = types.Int64Value(timeout)
+	}
+
+	for _, groups := range []struct {
+		value  *types.Set
+		groups []string
+	}{
+		{&m.Read, login.Read},
+		{&m.Write, login.Write},
+	} {
+		if len(groups.groups) == 0 {
+			continue
+		}
+		value, d := types.SetValueFrom(ctx, types.StringType, groups.groups)
+		diags.Append(d...)
+		*groups.value = value
+	}
+	return m, diags
+}
@@ -0,0 +1,168 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package rpcd_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+const testHash = "$6$Ab9sJbqk$Bn7n0dWjvd8zK3EvYj5Nh0Sdth1Pyn3q2DIVRYfuuHHi1T3Vu7K9X5LRIzAM5T5vP5dG4.zAtVu3zi2Ar.5hn/"
+
+// newFakeRpcd keeps the rpcd configuration in memory, starting with the
+// login of root.
+func newFakeRpcd(client *mocks.MockClient) *testutil.FakeUCI {
+	uci := testutil.NewFakeUCI(client, "rpcd", map[string]any{
+		".name":      "cfg01e48a",
+		".type":      "login",
+		".anonymous": true,
+		"username":   "root",
+		"password":   "$p$root",
+		"read":       []string{"*"},
+		"write":      []string{"*"},
+	})
+
+	client.
+		EXPECT().
+		GetRpcdLogin(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, username string) (*api.RpcdLogin, error) {
+			name := uci.Lookup("login", "username", username)
+			if name == "" {
+				return nil, errors.Join(api.ErrSectionNotFound, fmt.Errorf("login %q", username))
+			}
+			return testutil.GetSection[api.RpcdLogin](uci, name)
+		}).
+		AnyTimes()
+
+	return uci
+}
+
+// checkLogin verifies the options of the login, or that it is missing if
+// options is nil.
+func checkLogin(uci *testutil.FakeUCI, username string, options map[string]any) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		name := uci.Lookup("login", "username", username)
+		switch {
+		case options == nil && name != "":
+			return fmt.Errorf("login %q still exists", username)
+		case options == nil:
+			return nil
+		case name == "":
+			return fmt.Errorf("login %q not found", username)
+		}
+		return uci.CheckSection(name, options)(state)
+	}
+}
+
+func TestAccRpcdLogin(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	rpcd := newFakeRpcd(client)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_login" "terraform" {
+					username      = "terraform"
+					password_hash = "secret"
+				}`,
+				ExpectError: regexp.MustCompile(`must be a \$1\$, \$5\$, \$6\$ or \$2y\$ crypt hash`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_login" "root" {
+					username      = "root"
+					password_hash = "$p$root"
+				}`,
+				ExpectError: regexp.MustCompile(`Login "root" already exists`),
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_rpcd_login" "terraform" {
+					username      = "terraform"
+					password_hash = %q
+					timeout       = 600
+					read          = ["terraform-dns", "unauthenticated"]
+					write         = ["terraform-dns"]
+				}`, testHash),
+				Check: resource.ComposeTestCheckFunc(
+					checkLogin(rpcd, "terraform", map[string]any{
+						"username": "terraform",
+						"password": testHash,
+						"timeout":  "600",
+						"read":     []string{"terraform-dns", "unauthenticated"},
+						"write":    []string{"terraform-dns"},
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_rpcd_login" "terraform" {
+					username      = "terraform"
+					password_hash = "$p$terraform"
+					read          = ["terraform-dns"]
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkLogin(rpcd, "terraform", map[string]any{
+						"username": "terraform",
+						"password": "$p$terraform",
+						"read":     []string{"terraform-dns"},
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_rpcd_login.terraform", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_rpcd_login.terraform",
+				ImportState:                          true,
+				ImportStateId:                        "terraform",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "username",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			checkLogin(rpcd, "terraform", nil),
+			checkLogin(rpcd, "root", map[string]any{
+				"username": "root",
+				"password": "$p$root",
+				"read":     []string{"*"},
+				"write":    []string{"*"},
+			}),
+		),
+	})
+}
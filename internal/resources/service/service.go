@@ -8,6 +8,8 @@ import (
 	"fmt"
 
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -17,10 +19,14 @@ type serviceModel struct {
 	Name     types.String `tfsdk:"name"`
 	Enabled  types.Bool   `tfsdk:"enabled"`
 	Triggers types.Map    `tfsdk:"triggers"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 type serviceResource struct {
 	initFacade api.ServiceFacade
+	devices    api.Devices
 }
 
 func NewServiceResource() resource.Resource {
@@ -31,11 +37,12 @@ func (s serviceResource) Metadata(_ context.Context, req resource.MetadataReques
 	resp.TypeName = fmt.Sprintf("%s_service", req.ProviderTypeName)
 }
 
-func (s serviceResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (s serviceResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Enable/Disable specific services on the router, verifing conditions that trigger the restart on the router",
 		Description:         "Enable/Disable specific services on the router, verifing conditions that trigger the restart on the router",
 		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
 			"name": schema.StringAttribute{
 				MarkdownDescription: "The service name to operate with",
 				Description:         "The service name to operate with",
@@ -54,6 +61,14 @@ func (s serviceResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 				Optional:            true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -62,12 +77,12 @@ func (s *serviceResource) Configure(_ context.Context, req resource.ConfigureReq
 	if data == nil {
 		return
 	}
-	initFacade, ok := data.(api.ServiceFacade)
+	devices, ok := api.DevicesOf(data)
 	if !ok {
 		resp.Diagnostics.AddError("failed to get init facace", "")
 		return
 	}
-	s.initFacade = initFacade
+	s.devices = devices
 }
 
 func (s serviceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -78,6 +93,19 @@ func (s serviceResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s.initFacade, diags = api.DeviceClient(ctx, s.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	if plan.Enabled.IsUnknown() || plan.Enabled.IsNull() {
 		plan.Enabled = types.BoolValue(true)
 	}
@@ -101,6 +129,19 @@ func (s serviceResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s.initFacade, diags = api.DeviceClient(ctx, s.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	serviceName := state.Name.ValueString()
 	enabled, err := s.initFacade.IsEnabled(ctx, serviceName)
 	if err != nil {
@@ -128,6 +169,19 @@ func (s serviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s.initFacade, diags = api.DeviceClient(ctx, s.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	if err := s.enableDisableService(ctx,
 		plan.Enabled, state.Enabled,
 		plan.Triggers, state.Triggers,
@@ -148,6 +202,19 @@ func (s serviceResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s.initFacade, diags = api.DeviceClient(ctx, s.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	serviceName := state.Name.ValueString()
 	toEnable := state.Enabled.ValueBool()
 
@@ -164,6 +231,33 @@ func (s serviceResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 }
 
+func (s serviceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, s.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	s.initFacade = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	serviceName := id
+	enabled, err := s.initFacade.IsEnabled(ctx, serviceName)
+	if err != nil {
+		resp.Diagnostics.AddError("checking if service is enabled in error", fmt.Sprintf("%s: %v", serviceName, err))
+		return
+	}
+
+	state := serviceModel{
+		Name:     types.StringValue(serviceName),
+		Enabled:  types.BoolValue(enabled),
+		Triggers: types.MapNull(types.StringType),
+	}
+
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
 func (s serviceResource) enableDisableService(ctx context.Context,
 	planEnabledValue, stateEnabledValue types.Bool,
 	planTriggersValue, stateTriggersValue types.Map,
@@ -5,6 +5,7 @@ package service_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
 func TestAccService_CheckServiceEnabledIfOmitted(t *testing.T) {
@@ -585,3 +587,92 @@ func TestAccService_CheckServiceEnableDisable(t *testing.T) {
 		},
 	})
 }
+
+func TestAccService_Import(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	timeouts := mocks.NewMockTimeouts(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck: func() {
+			client := mocks.NewMockClient(ctrl)
+
+			client.
+				EXPECT().
+				Auth(gomock.Any(), "root", "test").
+				Return(nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				UpdatePackages(gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				IsEnabled(gomock.Any(), "dnsmasq").
+				DoAndReturn(func(_ context.Context, _ string) (bool, error) {
+					t.Log("IsEnabled method called")
+					return false, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				DisableService(gomock.Any(), "dnsmasq").
+				Return(nil).
+				AnyTimes()
+
+			clientFactory.
+				EXPECT().
+				ParseTimeouts(gomock.Any(), gomock.Any()).
+				Return(timeouts, nil).
+				AnyTimes()
+
+			clientFactory.
+				EXPECT().
+				Get(gomock.Any(), "http://test.lan:8080", gomock.Any()).
+				Return(client, nil).
+				AnyTimes()
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				provider "openwrt" {
+					user     = "root"
+					password = "test"
+					remote   = "http://test.lan:8080"
+				}
+
+				resource "openwrt_service" "a_service" {
+					name    = "dnsmasq"
+					enabled = false
+				}`,
+			},
+			{
+				ResourceName:                         "openwrt_service.a_service",
+				ImportState:                          true,
+				ImportStateId:                        "dnsmasq",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 {
+						return fmt.Errorf("expected 1 imported state, got %d", len(states))
+					}
+					if enabled := states[0].Attributes["enabled"]; enabled != "false" {
+						return fmt.Errorf("expected imported service to be disabled, got %q", enabled)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
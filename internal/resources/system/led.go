@@ -0,0 +1,516 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	ledsDir    = "/sys/class/leds"
+	ledService = "led"
+)
+
+// ledModeRegexp matches the events of the netdev trigger.
+var ledModeRegexp = regexp.MustCompile(`^(link|tx|rx)( (link|tx|rx))*$`)
+
+type ledModel struct {
+	Name        types.String `tfsdk:"name"`
+	Description types.String `tfsdk:"description"`
+	Sysfs       types.String `tfsdk:"sysfs"`
+	Trigger     types.String `tfsdk:"trigger"`
+	Dev         types.String `tfsdk:"dev"`
+	Mode        types.String `tfsdk:"mode"`
+	DelayOn     types.Int64  `tfsdk:"delayon"`
+	DelayOff    types.Int64  `tfsdk:"delayoff"`
+	Default     types.Bool   `tfsdk:"default"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type ledResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure      = (*ledResource)(nil)
+	_ resource.ResourceWithImportState    = (*ledResource)(nil)
+	_ resource.ResourceWithModifyPlan     = (*ledResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*ledResource)(nil)
+)
+
+// NewLedResource return new led resource.
+func NewLedResource() resource.Resource {
+	return &ledResource{}
+}
+
+func (l ledResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_system_led", req.ProviderTypeName)
+}
+
+func (l ledResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a `led` section of the system configuration, that drives a LED of the router. The LED and its trigger are checked against `/sys/class/leds` at plan time. The `led` service is restarted after each change.",
+		Description:         "Manage a led section of the system configuration, that drives a LED of the router. The LED and its trigger are checked against /sys/class/leds at plan time. The led service is restarted after each change.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the section, e.g. `led_wan`.",
+				Description:         "Name of the section, e.g. led_wan.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					customtypes.SectionNameValidator("led"),
+				},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Name of the LED displayed by LuCI, i.e. the `name` option.",
+				Description:         "Name of the LED displayed by LuCI, i.e. the name option.",
+				Optional:            true,
+			},
+			"sysfs": schema.StringAttribute{
+				MarkdownDescription: "Name of the LED in `/sys/class/leds`, e.g. `green:wan`. The available names are listed by the `openwrt_system_leds` data source.",
+				Description:         "Name of the LED in /sys/class/leds, e.g. green:wan. The available names are listed by the openwrt_system_leds data source.",
+				Required:            true,
+			},
+			"trigger": schema.StringAttribute{
+				MarkdownDescription: "Trigger driving the LED, e.g. `netdev`, `timer`, `heartbeat`, `default-on` or `phy0rx`. The available triggers depend on the LED and the kernel modules.",
+				Description:         "Trigger driving the LED, e.g. netdev, timer, heartbeat, default-on or phy0rx. The available triggers depend on the LED and the kernel modules.",
+				Optional:            true,
+			},
+			"dev": schema.StringAttribute{
+				MarkdownDescription: "Network device watched by the `netdev` trigger, e.g. `wan`.",
+				Description:         "Network device watched by the netdev trigger, e.g. wan.",
+				Optional:            true,
+			},
+			"mode": schema.StringAttribute{
+				MarkdownDescription: "Events of the network device the `netdev` trigger blinks on, a space separated list of `link`, `tx` and `rx`.",
+				Description:         "Events of the network device the netdev trigger blinks on, a space separated list of link, tx and rx.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(ledModeRegexp, "value must be a space separated list of link, tx and rx"),
+				},
+			},
+			"delayon": schema.Int64Attribute{
+				MarkdownDescription: "Time in milliseconds the LED is on with the `timer` trigger.",
+				Description:         "Time in milliseconds the LED is on with the timer trigger.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"delayoff": schema.Int64Attribute{
+				MarkdownDescription: "Time in milliseconds the LED is off with the `timer` trigger.",
+				Description:         "Time in milliseconds the LED is off with the timer trigger.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"default": schema.BoolAttribute{
+				MarkdownDescription: "State of the LED before the trigger applies.",
+				Description:         "State of the LED before the trigger applies.",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (l *ledResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	l.devices = devices
+}
+
+// ValidateConfig rejects the options of a trigger set along another one.
+func (l ledResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config ledModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() || config.Trigger.IsUnknown() {
+		return
+	}
+
+	for trigger, attributes := range map[string]map[string]bool{
+		"netdev": {"dev": !config.Dev.IsNull(), "mode": !config.Mode.IsNull()},
+		"timer":  {"delayon": !config.DelayOn.IsNull(), "delayoff": !config.DelayOff.IsNull()},
+	} {
+		if config.Trigger.ValueString() == trigger {
+			continue
+		}
+		for attribute, set := range attributes {
+			if set {
+				resp.Diagnostics.AddAttributeError(fwpath.Root(attribute), "Option of another trigger",
+					fmt.Sprintf("%s is only used by the %s trigger.", attribute, trigger))
+			}
+		}
+	}
+}
+
+// ModifyPlan checks that the LED and its trigger exist on the router, typos
+// being silently ignored otherwise.
+func (l ledResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || l.devices == nil {
+		return
+	}
+
+	var plan ledModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.Sysfs.IsUnknown() || plan.Device.IsUnknown() {
+		return
+	}
+
+	var diags diag.Diagnostics
+	l.provider, diags = api.DeviceClient(ctx, l.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	entries, err := l.provider.ListDir(ctx, ledsDir)
+	if err != nil {
+		resp.Diagnostics.AddWarning(fmt.Sprintf("Failed to list %s, the LED is not checked", ledsDir), err.Error())
+		return
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	if !slices.Contains(names, plan.Sysfs.ValueString()) {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("sysfs"), "Unknown LED",
+			fmt.Sprintf("%q is not found in %s, the available LEDs are: %s.", plan.Sysfs.ValueString(), ledsDir, strings.Join(names, ", ")))
+		return
+	}
+
+	if plan.Trigger.IsNull() || plan.Trigger.IsUnknown() {
+		return
+	}
+	triggers, err := l.triggers(ctx, plan.Sysfs.ValueString())
+	if err != nil {
+		tflog.Debug(ctx, "led - unable to read the available triggers", map[string]interface{}{
+			"led":   plan.Sysfs.ValueString(),
+			"error": err.Error(),
+		})
+		return
+	}
+	if !slices.Contains(triggers, plan.Trigger.ValueString()) {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("trigger"), "Unknown trigger",
+			fmt.Sprintf("%q is not a trigger of %s, the available triggers are: %s.", plan.Trigger.ValueString(), plan.Sysfs.ValueString(), strings.Join(triggers, ", ")))
+	}
+}
+
+// triggers returns the triggers available for the LED. The kernel lists them
+// on a single line, the active one being between brackets.
+func (l ledResource) triggers(ctx context.Context, led string) ([]string, error) {
+	content, err := l.provider.ReadFile(ctx, path.Join(ledsDir, led, "trigger"))
+	if err != nil {
+		return nil, err
+	}
+
+	triggers := strings.Fields(string(content))
+	for i, trigger := range triggers {
+		triggers[i] = strings.Trim(trigger, "[]")
+	}
+	return triggers, nil
+}
+
+func (l ledResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan ledModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := plan.Name.ValueString()
+	_, err := l.provider.GetLed(ctx, name)
+	if err == nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Led section %q already exists", name),
+			"Import it to manage it with this resource.")
+		return
+	}
+	if !errors.Is(err, api.ErrSectionNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read led section %q", name), err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(l.commit(ctx, fmt.Sprintf("Failed to add led section %q", name), func(ctx context.Context) error {
+		return l.provider.AddSection(ctx, "system", "led", name, plan.options())
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (l ledResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state ledModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	led, err := l.provider.GetLed(ctx, state.Name.ValueString())
+	if errors.Is(err, api.ErrSectionNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read led section %q", state.Name.ValueString()), err.Error())
+		return
+	}
+
+	refreshed, err := newLedModel(led)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read led section %q", led.Id), err.Error())
+		return
+	}
+	refreshed.Device = state.Device
+	refreshed.Timeouts = state.Timeouts
+	resp.Diagnostics.Append(resp.State.Set(ctx, refreshed)...)
+}
+
+func (l ledResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state ledModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan ledModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := plan.Name.ValueString()
+	resp.Diagnostics.Append(l.commit(ctx, fmt.Sprintf("Failed to update led section %q", name), func(ctx context.Context) error {
+		options := plan.options()
+		if err := l.provider.TSet(ctx, options, "system", name); err != nil {
+			return err
+		}
+
+		for option := range state.options() {
+			if _, ok := options[option]; ok {
+				continue
+			}
+			if err := l.provider.Delete(ctx, "system", name, option); err != nil {
+				return fmt.Errorf("failed to remove option %q: %w", option, err)
+			}
+		}
+		return nil
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (l ledResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state ledModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.provider, diags = api.DeviceClient(ctx, l.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := state.Name.ValueString()
+	resp.Diagnostics.Append(l.commit(ctx, fmt.Sprintf("Failed to delete led section %q", name), func(ctx context.Context) error {
+		return l.provider.Delete(ctx, "system", name)
+	})...)
+}
+
+func (l ledResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, l.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	l.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("device"), device)...)
+
+	led, err := l.provider.GetLed(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+
+	state, err := newLedModel(led)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, fwpath.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, fwpath.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// commit stages the changes of the system configuration with stage, commits
+// them, then restarts the leds. summary describes the change if it fails.
+func (l ledResource) commit(ctx context.Context, summary string, stage func(ctx context.Context) error) (diags diag.Diagnostics) {
+	if err := l.provider.Transaction(ctx, "system", stage); err != nil {
+		diags.AddError(summary, err.Error())
+		return diags
+	}
+
+	if err := l.provider.RestartService(ctx, ledService); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to restart %s", ledService), err.Error())
+	}
+	return diags
+}
+
+// options returns the options set in the model, by their UCI name.
+func (m ledModel) options() map[string]any {
+	options := map[string]any{}
+	for name, value := range map[string]types.String{
+		"name":    m.Description,
+		"sysfs":   m.Sysfs,
+		"trigger": m.Trigger,
+		"dev":     m.Dev,
+		"mode":    m.Mode,
+	} {
+		if !value.IsNull() {
+			options[name] = value.ValueString()
+		}
+	}
+	for name, value := range map[string]types.Int64{
+		"delayon":  m.DelayOn,
+		"delayoff": m.DelayOff,
+	} {
+		if !value.IsNull() {
+			options[name] = strconv.FormatInt(value.ValueInt64(), 10)
+		}
+	}
+	if !m.Default.IsNull() {
+		options["default"] = customtypes.FormatUCIBool(m.Default.ValueBool())
+	}
+	return options
+}
+
+func newLedModel(led *api.Led) (ledModel, error) {
+	m := ledModel{
+		Name:        types.StringValue(led.Id),
+		Description: customtypes.UCIString(led.Name),
+		Sysfs:       customtypes.UCIString(led.Sysfs),
+		Trigger:     customtypes.UCIString(led.Trigger),
+		Dev:         customtypes.UCIString(led.Dev),
+		Mode:        customtypes.UCIString(led.Mode),
+		DelayOn:     types.Int64Null(),
+		DelayOff:    types.Int64Null(),
+		Default:     customtypes.UCIBool(led.Default),
+	}
+
+	if led.DelayOn != "" {
+		i, err := strconv.ParseInt(led.DelayOn, 10, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid delayon: %w", err)
+		}
+		m.DelayOn = types.Int64Value(i)
+	}
+	if led.DelayOff != "" {
+		i, err := strconv.ParseInt(led.DelayOff, 10, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid delayoff: %w", err)
+		}
+		m.DelayOff = types.Int64Value(i)
+	}
+	return m, nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+)
+
+func TestAccSystemLed(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	uci := newFakeSystem(client, []map[string]any{
+		{
+			".name":   "led_lan",
+			".type":   "led",
+			"sysfs":   "green:lan",
+			"trigger": "netdev",
+			"dev":     "br-lan",
+		},
+	})
+
+	client.
+		EXPECT().
+		ListDir(gomock.Any(), "/sys/class/leds").
+		Return([]api.DirEntry{
+			{Name: "green:wan", Type: "symlink"},
+			{Name: "green:lan", Type: "symlink"},
+			{Name: "blue:status", Type: "symlink"},
+		}, nil).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		ReadFile(gomock.Any(), "/sys/class/leds/green:wan/trigger").
+		Return([]byte("none [default-on] timer heartbeat netdev phy0rx phy0tx\n"), nil).
+		AnyTimes()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "wan" {
+					name  = "led_wan"
+					sysfs = "green:wna"
+				}`,
+				ExpectError: regexp.MustCompile(`"green:wna" is not found in /sys/class/leds, the available LEDs are:\s+green:wan, green:lan, blue:status`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "wan" {
+					name    = "led_wan"
+					sysfs   = "green:wan"
+					trigger = "netdve"
+				}`,
+				ExpectError: regexp.MustCompile(`"netdve" is not a trigger of green:wan`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "wan" {
+					name    = "led_wan"
+					sysfs   = "green:wan"
+					trigger = "netdev"
+					delayon = 500
+				}`,
+				ExpectError: regexp.MustCompile(`delayon is only used by the timer trigger`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "wan" {
+					name  = "led-wan"
+					sysfs = "green:wan"
+				}`,
+				ExpectError: regexp.MustCompile(`invalid section name "led-wan"`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "lan" {
+					name  = "led_lan"
+					sysfs = "green:lan"
+				}`,
+				ExpectError: regexp.MustCompile(`Led section "led_lan" already exists`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "wan" {
+					name        = "led_wan"
+					description = "WAN"
+					sysfs       = "green:wan"
+					trigger     = "netdev"
+					dev         = "wan"
+					mode        = "link tx rx"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					uci.CheckSection("led_wan", map[string]any{
+						"name":    "WAN",
+						"sysfs":   "green:wan",
+						"trigger": "netdev",
+						"dev":     "wan",
+						"mode":    "link tx rx",
+					}),
+					uci.CheckRestarted("led"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_led" "wan" {
+					name     = "led_wan"
+					sysfs    = "green:wan"
+					trigger  = "timer"
+					delayon  = 500
+					delayoff = 1500
+					default  = true
+				}
+
+				data "openwrt_system_leds" "leds" {}`,
+				Check: resource.ComposeTestCheckFunc(
+					uci.CheckSection("led_wan", map[string]any{
+						"sysfs":    "green:wan",
+						"trigger":  "timer",
+						"delayon":  "500",
+						"delayoff": "1500",
+						"default":  "1",
+					}),
+					uci.CheckSection("led_lan", map[string]any{
+						"sysfs":   "green:lan",
+						"trigger": "netdev",
+						"dev":     "br-lan",
+					}),
+					uci.CheckRestarted("led"),
+					resource.TestCheckResourceAttr("data.openwrt_system_leds.leds", "names.#", "3"),
+					resource.TestCheckResourceAttr("data.openwrt_system_leds.leds", "names.0", "blue:status"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_system_led.wan",
+				ImportState:                          true,
+				ImportStateId:                        "led_wan",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			uci.CheckSection("led_wan", nil),
+			uci.CheckSection("led_lan", map[string]any{
+				"sysfs":   "green:lan",
+				"trigger": "netdev",
+				"dev":     "br-lan",
+			}),
+			uci.CheckRestarted("led"),
+		),
+	})
+}
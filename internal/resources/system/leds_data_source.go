@@ -0,0 +1,98 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type ledsDataSourceModel struct {
+	Names  types.List   `tfsdk:"names"`
+	Device types.String `tfsdk:"device"`
+}
+
+type ledsDataSource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+// NewLedsDataSource return new leds data source.
+func NewLedsDataSource() datasource.DataSource {
+	return &ledsDataSource{}
+}
+
+func (l ledsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_system_leds", req.ProviderTypeName)
+}
+
+func (l ledsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the LEDs of the router found in `/sys/class/leds`, i.e. the valid values of the `sysfs` attribute of `openwrt_system_led`.",
+		Description:         "List the LEDs of the router found in /sys/class/leds, i.e. the valid values of the sysfs attribute of openwrt_system_led.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceDataSourceSchemaAttribute,
+			"names": schema.ListAttribute{
+				MarkdownDescription: "Names of the LEDs, sorted, e.g. `green:wan`.",
+				Description:         "Names of the LEDs, sorted, e.g. green:wan.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (l *ledsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	l.devices = devices
+}
+
+func (l ledsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state ledsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var diags diag.Diagnostics
+	l.provider, diags = api.DeviceClient(ctx, l.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	entries, err := l.provider.ListDir(ctx, ledsDir)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to list %s", ledsDir), err.Error())
+		return
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	slices.Sort(names)
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
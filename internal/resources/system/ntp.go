@@ -0,0 +1,367 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	ntpSection = "ntp"
+	ntpService = "sysntpd"
+)
+
+type ntpModel struct {
+	Id           types.String `tfsdk:"id"`
+	Enabled      types.Bool   `tfsdk:"enabled"`
+	EnableServer types.Bool   `tfsdk:"enable_server"`
+	Interface    types.String `tfsdk:"interface"`
+	Server       types.List   `tfsdk:"server"`
+
+	Device types.String `tfsdk:"device"`
+}
+
+// ntpResourceModel adds the timeouts of the resource to the options, which
+// are shared with the data source.
+type ntpResourceModel struct {
+	ntpModel
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type ntpResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+// NewNtpResource return new ntp resource.
+func NewNtpResource() resource.Resource {
+	return &ntpResource{}
+}
+
+func (n ntpResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_system_ntp", req.ProviderTypeName)
+}
+
+func (n ntpResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage the NTP client and server of openwrt, i.e. the `timeserver` section `ntp` of the system configuration. Only the options set in the configuration are managed, destroying the resource resets them to their default. `sysntpd` is restarted after each change.",
+		Description:         "Manage the NTP client and server of openwrt, i.e. the timeserver section ntp of the system configuration. Only the options set in the configuration are managed, destroying the resource resets them to their default. sysntpd is restarted after each change.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Name of the timeserver section, always `ntp`, prefixed with the `device` and a colon when it is set.",
+				Description:         "Name of the timeserver section, always ntp, prefixed with the device and a colon when it is set.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the time is synchronized from the NTP servers. (Default: true)",
+				Description:         "Whether the time is synchronized from the NTP servers. (Default: true)",
+				Optional:            true,
+			},
+			"enable_server": schema.BoolAttribute{
+				MarkdownDescription: "Whether the router serves the time to its clients. (Default: false)",
+				Description:         "Whether the router serves the time to its clients. (Default: false)",
+				Optional:            true,
+			},
+			"interface": schema.StringAttribute{
+				MarkdownDescription: "Logical interface the NTP server is bound to, e.g. `lan`. (Default: all the interfaces)",
+				Description:         "Logical interface the NTP server is bound to, e.g. lan. (Default: all the interfaces)",
+				Optional:            true,
+			},
+			"server": schema.ListAttribute{
+				MarkdownDescription: "NTP servers to synchronize the time from, e.g. `0.openwrt.pool.ntp.org`.",
+				Description:         "NTP servers to synchronize the time from, e.g. 0.openwrt.pool.ntp.org.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (n *ntpResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	n.devices = devices
+}
+
+func (n ntpResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan ntpResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	n.provider, diags = api.DeviceClient(ctx, n.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := n.provider.GetNtp(ctx); err != nil {
+		resp.Diagnostics.AddError("Failed to find the ntp section", err.Error())
+		return
+	}
+	plan.Id = types.StringValue(api.DeviceId(plan.Device, ntpSection))
+
+	resp.Diagnostics.Append(n.apply(ctx, plan.ntpModel, ntpModel{})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (n ntpResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state ntpResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	n.provider, diags = api.DeviceClient(ctx, n.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ntp, err := n.provider.GetNtp(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read the ntp section", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(state.refresh(ctx, ntp, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (n ntpResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state ntpResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan ntpResourceModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	n.provider, diags = api.DeviceClient(ctx, n.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.Id = types.StringValue(api.DeviceId(plan.Device, ntpSection))
+
+	resp.Diagnostics.Append(n.apply(ctx, plan.ntpModel, state.ntpModel)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete resets the managed options to their default, the section itself is
+// left in place.
+func (n ntpResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state ntpResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	n.provider, diags = api.DeviceClient(ctx, n.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(n.apply(ctx, ntpModel{}, state.ntpModel)...)
+}
+
+// ImportState takes over all the options found in the ntp section.
+func (n ntpResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, _, client, diags := api.ImportDevice(ctx, n.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	n.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	ntp, err := n.provider.GetNtp(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+
+	state := ntpResourceModel{ntpModel: ntpModel{Server: types.ListNull(types.StringType)}}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(state.refresh(ctx, ntp, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// apply sets the options of the plan, resets the ones only found in the
+// previous state, then commits and restarts sysntpd.
+func (n ntpResource) apply(ctx context.Context, plan, previous ntpModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	options, d := plan.options(ctx)
+	diags.Append(d...)
+	previousOptions, d := previous.options(ctx)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+
+	err := n.provider.Transaction(ctx, "system", func(ctx context.Context) error {
+		if len(options) > 0 {
+			if err := n.provider.TSet(ctx, options, "system", ntpSection); err != nil {
+				return err
+			}
+		}
+
+		for name := range previousOptions {
+			if _, ok := options[name]; ok {
+				continue
+			}
+			if err := n.provider.Delete(ctx, "system", ntpSection, name); err != nil {
+				return fmt.Errorf("failed to reset option %q: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		diags.AddError(fmt.Sprintf("Failed to update config %q", ntpSection), err.Error())
+		return diags
+	}
+
+	if err := n.provider.RestartService(ctx, ntpService); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to restart %s", ntpService), err.Error())
+	}
+	return diags
+}
+
+// options returns the options set in the model, by their UCI name.
+func (m ntpModel) options(ctx context.Context) (map[string]any, diag.Diagnostics) {
+	options := map[string]any{}
+	if !m.Enabled.IsNull() {
+		options["enabled"] = customtypes.FormatUCIBool(m.Enabled.ValueBool())
+	}
+	if !m.EnableServer.IsNull() {
+		options["enable_server"] = customtypes.FormatUCIBool(m.EnableServer.ValueBool())
+	}
+	if !m.Interface.IsNull() {
+		options["interface"] = m.Interface.ValueString()
+	}
+	if !m.Server.IsNull() {
+		var servers []string
+		if diags := m.Server.ElementsAs(ctx, &servers, false); diags.HasError() {
+			return nil, diags
+		}
+		options["server"] = servers
+	}
+	return options, nil
+}
+
+// refresh updates the model with the options of the section. Unless all is
+// set, only the managed options are refreshed.
+func (m *ntpModel) refresh(ctx context.Context, ntp *api.Ntp, all bool) diag.Diagnostics {
+	m.Id = types.StringValue(api.DeviceId(m.Device, ntp.Id))
+
+	if all || !m.Enabled.IsNull() {
+		m.Enabled = customtypes.UCIBool(ntp.Enabled)
+	}
+	if all || !m.EnableServer.IsNull() {
+		m.EnableServer = customtypes.UCIBool(ntp.EnableServer)
+	}
+	if all || !m.Interface.IsNull() {
+		m.Interface = types.StringNull()
+		if ntp.Interface != "" {
+			m.Interface = types.StringValue(ntp.Interface)
+		}
+	}
+	if all || !m.Server.IsNull() {
+		m.Server = types.ListNull(types.StringType)
+		if len(ntp.Server) > 0 {
+			servers, diags := types.ListValueFrom(ctx, types.StringType, ntp.Server)
+			if diags.HasError() {
+				return diags
+			}
+			m.Server = servers
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// ntpStratumFile holds the stratum of the last stratum event of ntpd,
+	// written by a hotplug handler of /etc/hotplug.d/ntp.
+	ntpStratumFile = "/var/state/ntpd-stratum"
+	// unsyncedStratum is the stratum ntpd reports until it is synchronized.
+	unsyncedStratum = 16
+)
+
+type ntpDataSourceModel struct {
+	ntpModel
+	Running      types.Bool  `tfsdk:"running"`
+	Stratum      types.Int64 `tfsdk:"stratum"`
+	Synchronized types.Bool  `tfsdk:"synchronized"`
+}
+
+type ntpDataSource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+// NewNtpDataSource return new ntp data source.
+func NewNtpDataSource() datasource.DataSource {
+	return &ntpDataSource{}
+}
+
+func (n ntpDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_system_ntp", req.ProviderTypeName)
+}
+
+func (n ntpDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Read the NTP configuration of openwrt, and the status of `sysntpd`. " +
+			"`ntpd` only reports its synchronization through the `stratum` hotplug events, which are kept by a handler such as the one of the example, writing the stratum to `" + ntpStratumFile + "`.",
+		Description: "Read the NTP configuration of openwrt, and the status of sysntpd. " +
+			"ntpd only reports its synchronization through the stratum hotplug events, which are kept by a handler such as the one of the example, writing the stratum to " + ntpStratumFile + ".",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceDataSourceSchemaAttribute,
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Name of the timeserver section, always `ntp`, prefixed with the `device` and a colon when it is set.",
+				Description:         "Name of the timeserver section, always ntp, prefixed with the device and a colon when it is set.",
+				Computed:            true,
+			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the time is synchronized from the NTP servers, null if left to its default.",
+				Description:         "Whether the time is synchronized from the NTP servers, null if left to its default.",
+				Computed:            true,
+			},
+			"enable_server": schema.BoolAttribute{
+				MarkdownDescription: "Whether the router serves the time to its clients, null if left to its default.",
+				Description:         "Whether the router serves the time to its clients, null if left to its default.",
+				Computed:            true,
+			},
+			"interface": schema.StringAttribute{
+				MarkdownDescription: "Logical interface the NTP server is bound to.",
+				Description:         "Logical interface the NTP server is bound to.",
+				Computed:            true,
+			},
+			"server": schema.ListAttribute{
+				MarkdownDescription: "NTP servers the time is synchronized from.",
+				Description:         "NTP servers the time is synchronized from.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"running": schema.BoolAttribute{
+				MarkdownDescription: "Whether `ntpd` is running, as reported by procd. Null when the status is not available, e.g. when the session is not allowed to list the services.",
+				Description:         "Whether ntpd is running, as reported by procd. Null when the status is not available, e.g. when the session is not allowed to list the services.",
+				Computed:            true,
+			},
+			"stratum": schema.Int64Attribute{
+				MarkdownDescription: "Stratum of the time of the router, as last reported by `ntpd`, `16` meaning not synchronized. Null when it is not available.",
+				Description:         "Stratum of the time of the router, as last reported by ntpd, 16 meaning not synchronized. Null when it is not available.",
+				Computed:            true,
+			},
+			"synchronized": schema.BoolAttribute{
+				MarkdownDescription: "Whether `ntpd` synchronized the time, according to the `stratum`. Null when it is not available.",
+				Description:         "Whether ntpd synchronized the time, according to the stratum. Null when it is not available.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (n *ntpDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	n.devices = devices
+}
+
+func (n ntpDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state ntpDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var diags diag.Diagnostics
+	n.provider, diags = api.DeviceClient(ctx, n.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ntp, err := n.provider.GetNtp(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read the ntp section", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(state.refresh(ctx, ntp, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Running = types.BoolNull()
+	running, err := n.provider.IsRunning(ctx, ntpService)
+	if err != nil {
+		resp.Diagnostics.AddWarning(fmt.Sprintf("Failed to get the status of %s", ntpService), err.Error())
+	} else {
+		state.Running = types.BoolValue(running)
+	}
+
+	state.Stratum, state.Synchronized = types.Int64Null(), types.BoolNull()
+	stratum, err := n.stratum(ctx)
+	if err != nil {
+		resp.Diagnostics.AddWarning("Failed to get the stratum of ntpd", err.Error())
+	} else if stratum != nil {
+		state.Stratum = types.Int64Value(*stratum)
+		state.Synchronized = types.BoolValue(*stratum < unsyncedStratum)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// stratum returns the stratum kept by the hotplug handler, nil when there
+// is none.
+func (n ntpDataSource) stratum(ctx context.Context) (*int64, error) {
+	content, err := n.provider.ReadFile(ctx, ntpStratumFile)
+	if errors.Is(err, api.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(content))
+	if text == "" {
+		return nil, nil
+	}
+	stratum, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stratum in %s: %w", ntpStratumFile, err)
+	}
+	return &stratum, nil
+}
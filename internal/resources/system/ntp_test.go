@@ -0,0 +1,128 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccSystemNtp(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	uci := newFakeSystem(testutil.ProviderMocks(t, ctrl, clientFactory), []map[string]any{
+		{
+			".name":    "cfg01e48a",
+			".type":    "system",
+			"hostname": "OpenWrt",
+		},
+		{
+			".name":         "ntp",
+			".type":         "timeserver",
+			"enabled":       "1",
+			"enable_server": "0",
+			"server":        []any{"0.openwrt.pool.ntp.org", "1.openwrt.pool.ntp.org"},
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_ntp" "ntp" {
+					enable_server = true
+					interface     = "lan"
+					server        = ["ntp1.example.com", "ntp2.example.com"]
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_system_ntp.ntp", "id", "ntp"),
+					resource.TestCheckNoResourceAttr("openwrt_system_ntp.ntp", "enabled"),
+					checkSingle(uci, "timeserver", map[string]any{
+						"enabled":       "1",
+						"enable_server": "1",
+						"interface":     "lan",
+						"server":        []string{"ntp1.example.com", "ntp2.example.com"},
+					}),
+					uci.CheckRestarted("sysntpd"),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system_ntp" "ntp" {
+					enabled = false
+					server  = ["ntp1.example.com"]
+				}
+
+				data "openwrt_system_ntp" "ntp" {
+					depends_on = [openwrt_system_ntp.ntp]
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkSingle(uci, "timeserver", map[string]any{
+						"enabled":       "0",
+						"enable_server": "",
+						"interface":     "",
+						"server":        []string{"ntp1.example.com"},
+					}),
+					uci.CheckRestarted("sysntpd"),
+					resource.TestCheckResourceAttr("data.openwrt_system_ntp.ntp", "enabled", "false"),
+					resource.TestCheckNoResourceAttr("data.openwrt_system_ntp.ntp", "enable_server"),
+					resource.TestCheckResourceAttr("data.openwrt_system_ntp.ntp", "server.#", "1"),
+					resource.TestCheckResourceAttr("data.openwrt_system_ntp.ntp", "running", "true"),
+					resource.TestCheckResourceAttr("data.openwrt_system_ntp.ntp", "stratum", "2"),
+					resource.TestCheckResourceAttr("data.openwrt_system_ntp.ntp", "synchronized", "true"),
+				),
+			},
+			{
+				ResourceName:  "openwrt_system_ntp.ntp",
+				ImportState:   true,
+				ImportStateId: "ntp",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 {
+						return fmt.Errorf("expected a single state, got %d", len(states))
+					}
+					for name, value := range map[string]string{
+						"id":       "ntp",
+						"enabled":  "false",
+						"server.#": "1",
+						"server.0": "ntp1.example.com",
+					} {
+						if got := states[0].Attributes[name]; got != value {
+							return fmt.Errorf("expected %q to be %q, got %q", name, value, got)
+						}
+					}
+					return nil
+				},
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			checkSingle(uci, "timeserver", map[string]any{
+				"enabled": "",
+				"server":  "",
+			}),
+			uci.CheckRestarted("sysntpd"),
+		),
+	})
+}
@@ -5,48 +5,67 @@ package system
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"math/big"
+	"strconv"
+	"strings"
 
-	"dario.cat/mergo"
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
 	"github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/foxboron/terraform-provider-openwrt/internal/tzdata"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	fwtypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 type systemModel struct {
-	Id        types.StringValue `tfsdk:"id"`
-	Type      types.StringValue `tfsdk:"type"`
-	Anonymous types.BoolValue   `tfsdk:"anonymous"`
-
-	Hostname        types.StringValue `tfsdk:"hostname"`
-	Description     types.StringValue `tfsdk:"description"`
-	Notes           types.StringValue `tfsdk:"notes"`
-	Buffersize      types.StringValue `tfsdk:"buffersize"`
-	ConLogLevel     types.StringValue `tfsdk:"conloglevel"`
-	CronLogLevel    types.StringValue `tfsdk:"cronloglevel"`
-	KlogconLogLevel types.StringValue `tfsdk:"klogconloglevel"`
-	LogBufferSize   types.StringValue `tfsdk:"log_buffer_size"`
-	LogFile         types.StringValue `tfsdk:"log_file"`
-	LogHostname     types.StringValue `tfsdk:"log_hostname"`
-	LogIP           types.StringValue `tfsdk:"log_ip"`
-	LogPort         types.StringValue `tfsdk:"log_port"`
-	LogPrefix       types.StringValue `tfsdk:"log_prefix"`
-	LogProto        types.StringValue `tfsdk:"log_proto"`
-	LogRemote       types.StringValue `tfsdk:"log_remote"`
-	LogSize         types.StringValue `tfsdk:"log_size"`
-	LogTrailerNull  types.StringValue `tfsdk:"log_trailer_null"`
-	LogType         types.StringValue `tfsdk:"log_type"`
-	TTYLogin        types.StringValue `tfsdk:"ttylogin"`
-	UrandomSeed     types.StringValue `tfsdk:"urandom_seed"`
-	Timezone        types.StringValue `tfsdk:"timezone"`
-	ZoneName        types.StringValue `tfsdk:"zonename"`
-	ZramCompAlgo    types.StringValue `tfsdk:"zram_comp_algo"`
-	ZramSizeMb      types.StringValue `tfsdk:"zram_size_mb"`
+	Id        types.StringValue `tfsdk:"id" json:".name"`
+	Type      types.StringValue `tfsdk:"type" json:".type"`
+	Anonymous types.BoolValue   `tfsdk:"anonymous" json:".anonymous"`
+
+	Hostname        types.StringValue `tfsdk:"hostname" json:"hostname"`
+	Description     types.StringValue `tfsdk:"description" json:"description"`
+	Notes           types.StringValue `tfsdk:"notes" json:"notes"`
+	Buffersize      types.StringValue `tfsdk:"buffersize" json:"buffersize"`
+	ConLogLevel     types.Int64Value  `tfsdk:"conloglevel" json:"conloglevel"`
+	CronLogLevel    types.Int64Value  `tfsdk:"cronloglevel" json:"cronloglevel"`
+	KlogconLogLevel types.Int64Value  `tfsdk:"klogconloglevel" json:"klogconloglevel"`
+	LogBufferSize   types.StringValue `tfsdk:"log_buffer_size" json:"log_buffer_size"`
+	LogFile         types.StringValue `tfsdk:"log_file" json:"log_file"`
+	LogHostname     types.StringValue `tfsdk:"log_hostname" json:"log_hostname"`
+	LogIP           types.StringValue `tfsdk:"log_ip" json:"log_ip"`
+	LogPort         types.Int64Value  `tfsdk:"log_port" json:"log_port"`
+	LogPrefix       types.StringValue `tfsdk:"log_prefix" json:"log_prefix"`
+	LogProto        types.StringValue `tfsdk:"log_proto" json:"log_proto"`
+	LogRemote       types.StringValue `tfsdk:"log_remote" json:"log_remote"`
+	LogSize         types.Int64Value  `tfsdk:"log_size" json:"log_size"`
+	LogTrailerNull  types.StringValue `tfsdk:"log_trailer_null" json:"log_trailer_null"`
+	LogType         types.StringValue `tfsdk:"log_type" json:"log_type"`
+	TTYLogin        types.StringValue `tfsdk:"ttylogin" json:"ttylogin"`
+	UrandomSeed     types.StringValue `tfsdk:"urandom_seed" json:"urandom_seed"`
+	Timezone        types.StringValue `tfsdk:"timezone" json:"timezone"`
+	ZoneName        types.StringValue `tfsdk:"zonename" json:"zonename"`
+	ZramCompAlgo    types.StringValue `tfsdk:"zram_comp_algo" json:"zram_comp_algo"`
+	ZramSizeMb      types.StringValue `tfsdk:"zram_size_mb" json:"zram_size_mb"`
+
+	Device   fwtypes.String `tfsdk:"device" json:"-"`
+	Timeouts timeouts.Value `tfsdk:"timeouts" json:"-"`
 }
 
 type systemResource struct {
 	provider api.Client
+	devices  api.Devices
 }
 
 // NewSystemResource return new project resource.
@@ -60,25 +79,39 @@ func (s systemResource) Metadata(_ context.Context, req resource.MetadataRequest
 }
 
 // Schema for system resource.
-func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (s systemResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Manage the system settings in openwrt",
-		Description:         "Manage the system settings in openwrt",
+		Version:             1,
+		MarkdownDescription: "Manage the system settings in openwrt. The resource adopts the existing `system` section (`@system[0]`): only the options set in the configuration are managed, and destroying the resource resets them to their default.",
+		Description:         "Manage the system settings in openwrt. The resource adopts the existing system section (@system[0]): only the options set in the configuration are managed, and destroying the resource resets them to their default.",
 		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
 			"id": schema.StringAttribute{
-				CustomType: types.StringType{},
-				Computed:   true,
-				Optional:   true,
+				MarkdownDescription: "Name of the system section, e.g. `cfg01e48a`, prefixed with the `device` and a colon when it is set.",
+				Description:         "Name of the system section, e.g. cfg01e48a, prefixed with the device and a colon when it is set.",
+				CustomType:          types.StringType{},
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"anonymous": schema.BoolAttribute{
-				CustomType: types.BoolType{},
-				Computed:   true,
-				Optional:   true,
+				MarkdownDescription: "If the system section is anonymous, as it is by default.",
+				Description:         "If the system section is anonymous, as it is by default.",
+				CustomType:          types.BoolType{},
+				Computed:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"type": schema.StringAttribute{
-				CustomType: types.StringType{},
-				Computed:   true,
-				Optional:   true,
+				MarkdownDescription: "Type of the section, always `system`.",
+				Description:         "Type of the section, always system.",
+				CustomType:          types.StringType{},
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 
 			"hostname": schema.StringAttribute{
@@ -86,6 +119,9 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Description:         "The hostname for this system (Default: \"OpenWrt\")",
 				CustomType:          types.StringType{},
 				Optional:            true,
+				Validators: []validator.String{
+					hostnameValidator{},
+				},
 			},
 			"description": schema.StringAttribute{
 				MarkdownDescription: "A short, single-line description for this system. It should be suitable for human consumption in user interfaces, such as LuCI, selector UIs in remote administration applications, or remote UCI (over ubus RPC).",
@@ -105,23 +141,32 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				CustomType:          types.StringType{},
 				Optional:            true,
 			},
-			"conloglevel": schema.StringAttribute{
+			"conloglevel": schema.Int64Attribute{
 				MarkdownDescription: "The maximum log level for kernel messages to be logged to the console. (Default: 7)",
 				Description:         "The maximum log level for kernel messages to be logged to the console. (Default: 7)",
-				CustomType:          types.StringType{},
+				CustomType:          types.Int64Type{},
 				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 8),
+				},
 			},
-			"cronloglevel": schema.StringAttribute{
+			"cronloglevel": schema.Int64Attribute{
 				MarkdownDescription: "The minimum level for cron messages to be logged to syslog. 0 will print all debug messages, 8 will log command executions, and 9 or higher will only log error messages. (Default: 5)",
 				Description:         "The minimum level for cron messages to be logged to syslog. 0 will print all debug messages, 8 will log command executions, and 9 or higher will only log error messages. (Default: 5)",
-				CustomType:          types.StringType{},
+				CustomType:          types.Int64Type{},
 				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
 			},
-			"klogconloglevel": schema.StringAttribute{
+			"klogconloglevel": schema.Int64Attribute{
 				MarkdownDescription: "The maximum log level for kernel messages to be logged to the console. Only messages with a level lower than this will be printed to the console. Identical to conloglevel and will override it. (Default: 7)",
 				Description:         "The maximum log level for kernel messages to be logged to the console. Only messages with a level lower than this will be printed to the console. Identical to conloglevel and will override it. (Default: 7)",
-				CustomType:          types.StringType{},
+				CustomType:          types.Int64Type{},
 				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 8),
+				},
 			},
 			"log_buffer_size": schema.StringAttribute{
 				MarkdownDescription: "Size of the log buffer of the procd based system log, that is accessible via the logread command. Defaults to the value of log_size if unset.",
@@ -146,12 +191,18 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Description:         "IP address of a syslog server to which the log messages should be sent in addition to the local destination.",
 				CustomType:          types.StringType{},
 				Optional:            true,
+				Validators: []validator.String{
+					ipAddressValidator{},
+				},
 			},
-			"log_port": schema.StringAttribute{
+			"log_port": schema.Int64Attribute{
 				MarkdownDescription: "Port number of the remote syslog server specified with log_ip. (Default: 514)",
 				Description:         "Port number of the remote syslog server specified with log_ip. (Default: 514)",
-				CustomType:          types.StringType{},
+				CustomType:          types.Int64Type{},
 				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
 			},
 			"log_prefix": schema.StringAttribute{
 				MarkdownDescription: "Adds a prefix to all log messages send over network.",
@@ -164,6 +215,9 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Description:         "Sets the protocol to use for the connection, either tcp or udp. (Default: \"udp\")",
 				CustomType:          types.StringType{},
 				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("udp", "tcp"),
+				},
 			},
 			"log_remote": schema.StringAttribute{
 				MarkdownDescription: "Enables remote logging. (Default: 1)",
@@ -171,11 +225,14 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				CustomType:          types.StringType{},
 				Optional:            true,
 			},
-			"log_size": schema.StringAttribute{
+			"log_size": schema.Int64Attribute{
 				MarkdownDescription: "Size of the file based log buffer in KiB (see log_file). This value is used as the fallback value for log_buffer_size if the latter is not specified. (Default: 64)",
 				Description:         "Size of the file based log buffer in KiB (see log_file). This value is used as the fallback value for log_buffer_size if the latter is not specified. (Default: 64)",
-				CustomType:          types.StringType{},
+				CustomType:          types.Int64Type{},
 				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
 			},
 			"log_trailer_null": schema.StringAttribute{
 				MarkdownDescription: "Use \\0 instead of \\n as trailer when using TCP. (Default: 0)",
@@ -188,6 +245,9 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Description:         "Either circular or file. The circular option is a fixed size queue in memory, while the file is a dynamically sized file, that can be in memory, or written to disk. Note: If log_type is set to file, then at some point when the log fills, the device may encounter an out-of-space condition. This is especially an issue for devices with limited onboard storage: in memory, or on flash. (Default: \"circular\")",
 				CustomType:          types.StringType{},
 				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("circular", "file"),
+				},
 			},
 			"ttylogin": schema.StringAttribute{
 				MarkdownDescription: "Require authentication for local users to log in the system. Disabled by default. It applies to the access methods listed in /etc/inittab, such as keyboard and serial. (Default: 0)",
@@ -202,26 +262,29 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Optional:            true,
 			},
 			"timezone": schema.StringAttribute{
-				MarkdownDescription: "POSIX.1 time zone string corresponding to the time zone in which date and time should be displayed by default. See [timezone database](https://github.com/openwrt/luci/blob/master/modules/luci-lua-runtime/luasrc/sys/zoneinfo/tzdata.lua) for a mapping between IANA/Olson and POSIX.1 formats. (For London this corresponds to GMT0BST,M3.5.0/1,M10.5.0) (Default: \"UTC\")",
-				Description:         "POSIX.1 time zone string corresponding to the time zone in which date and time should be displayed by default. See [timezone database](https://github.com/openwrt/luci/blob/master/modules/luci-lua-runtime/luasrc/sys/zoneinfo/tzdata.lua) for a mapping between IANA/Olson and POSIX.1 formats. (For London this corresponds to GMT0BST,M3.5.0/1,M10.5.0) (Default: \"UTC\")",
+				MarkdownDescription: "POSIX.1 time zone string corresponding to the time zone in which date and time should be displayed by default, e.g. `GMT0BST,M3.5.0/1,M10.5.0` for London. Computed from `zonename` when only the latter is set, see also the `posix_tz` function. (Default: \"UTC\")",
+				Description:         "POSIX.1 time zone string corresponding to the time zone in which date and time should be displayed by default, e.g. GMT0BST,M3.5.0/1,M10.5.0 for London. Computed from zonename when only the latter is set, see also the posix_tz function. (Default: \"UTC\")",
 				CustomType:          types.StringType{},
 				Optional:            true,
-				// Computed:   true,
-				// Default:    stringdefault.StaticString("UTC"),
+				Computed:            true,
 			},
 			"zonename": schema.StringAttribute{
-				MarkdownDescription: "IANA/Olson time zone string. If zoneinfo-* packages are present, possible values can be found by running find /usr/share/zoneinfo. See [timezone database](https://github.com/openwrt/luci/blob/master/modules/luci-lua-runtime/luasrc/sys/zoneinfo/tzdata.lua) for a mapping between IANA/Olson and POSIX.1 formats. (For London this corresponds to Europe/London) (Default: UTC)",
-				Description:         "IANA/Olson time zone string. If zoneinfo-* packages are present, possible values can be found by running find /usr/share/zoneinfo. See [timezone database](https://github.com/openwrt/luci/blob/master/modules/luci-lua-runtime/luasrc/sys/zoneinfo/tzdata.lua) for a mapping between IANA/Olson and POSIX.1 formats. (For London this corresponds to Europe/London) (Default: UTC)",
+				MarkdownDescription: "IANA/Olson time zone name, e.g. `Europe/London`. Unknown names are rejected. (Default: UTC)",
+				Description:         "IANA/Olson time zone name, e.g. Europe/London. Unknown names are rejected. (Default: UTC)",
 				CustomType:          types.StringType{},
 				Optional:            true,
-				// Computed:   true,
-				// Default:    stringdefault.StaticString("UTC"),
+				Validators: []validator.String{
+					zoneNameValidator{},
+				},
 			},
 			"zram_comp_algo": schema.StringAttribute{
 				MarkdownDescription: "Compression algorithm to use for ZRAM, can be one of lzo, lzo-rle, lz4, zstd. (Default: \"lzo\")",
 				Description:         "Compression algorithm to use for ZRAM, can be one of lzo, lzo-rle, lz4, zstd. (Default: \"lzo\")",
 				CustomType:          types.StringType{},
 				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("lzo", "lzo-rle", "lz4", "zstd"),
+				},
 			},
 			"zram_size_mb": schema.StringAttribute{
 				MarkdownDescription: "Size of ZRAM in MB. (Default: ramsize in Kb divided by 2048)",
@@ -230,7 +293,95 @@ func (s systemResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Optional:            true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// numericOptions are the options exposed as numbers since the version 1 of
+// the schema, they were strings before.
+var numericOptions = []string{"conloglevel", "cronloglevel", "klogconloglevel", "log_port", "log_size"}
+
+// UpgradeState converts the numeric options of the version 0 of the schema
+// from strings to numbers.
+func (s systemResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	var current resource.SchemaResponse
+	s.Schema(ctx, resource.SchemaRequest{}, &current)
+
+	attributes := maps.Clone(current.Schema.Attributes)
+	for _, name := range numericOptions {
+		attributes[name] = schema.StringAttribute{
+			CustomType: types.StringType{},
+			Optional:   true,
+		}
+	}
+
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: &schema.Schema{Attributes: attributes},
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				var values map[string]tftypes.Value
+				if err := req.State.Raw.As(&values); err != nil {
+					resp.Diagnostics.AddError("Failed to read the prior state", err.Error())
+					return
+				}
+
+				for _, name := range numericOptions {
+					var value *string
+					if err := values[name].As(&value); err != nil {
+						resp.Diagnostics.AddAttributeError(path.Root(name), "Failed to read the prior state", err.Error())
+						continue
+					}
+
+					number := tftypes.NewValue(tftypes.Number, nil)
+					if value != nil {
+						i, err := strconv.ParseInt(*value, 10, 64)
+						if err != nil {
+							resp.Diagnostics.AddAttributeError(path.Root(name), "Invalid number in the prior state", err.Error())
+							continue
+						}
+						number = tftypes.NewValue(tftypes.Number, new(big.Float).SetInt64(i))
+					}
+					values[name] = number
+				}
+				if resp.Diagnostics.HasError() {
+					return
+				}
+				values["device"] = tftypes.NewValue(tftypes.String, nil)
+				values["timeouts"] = tftypes.NewValue(current.Schema.Blocks["timeouts"].Type().TerraformType(ctx), nil)
+
+				resp.State.Raw = tftypes.NewValue(current.Schema.Type().TerraformType(ctx), values)
+			},
+		},
+	}
+}
+
+// ModifyPlan computes timezone out of zonename, when only the latter is set.
+func (s systemResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var timezone, zonename types.StringValue
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("timezone"), &timezone)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("zonename"), &zonename)...)
+	if resp.Diagnostics.HasError() || !timezone.IsNull() {
+		return
 	}
+
+	planned := types.NewStringNull()
+	if zonename.IsUnknown() {
+		planned = types.NewStringUnknown()
+	} else if tz, ok := tzdata.POSIX(zonename.ValueString()); ok {
+		planned = types.NewStringValue(tz)
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("timezone"), planned)...)
 }
 
 func (s *systemResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -238,14 +389,16 @@ func (s *systemResource) Configure(_ context.Context, req resource.ConfigureRequ
 	if data == nil {
 		return
 	}
-	provider, ok := data.(api.Client)
+	devices, ok := api.DevicesOf(data)
 	if !ok {
 		resp.Diagnostics.AddError("Failed to get api client", "")
 		return
 	}
-	s.provider = provider
+	s.devices = devices
 }
 
+// Create adopts the system section found on the router: the system
+// configuration always holds exactly one, that can't be added nor removed.
 func (s systemResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan systemModel
 	diags := req.Plan.Get(ctx, &plan)
@@ -254,24 +407,37 @@ func (s systemResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	name, err := s.provider.Add(ctx, "system", "system")
-	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed to create config %q", plan.Id.ValueString()), err.Error())
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	plan.Id = types.NewStringValue(name)
-	plan.Anonymous = types.NewBoolValue(true)
-	plan.Type = types.NewStringValue("system")
+	s.provider, diags = api.DeviceClient(ctx, s.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	err = s.provider.TSet(ctx, plan, "system", plan.Id.ValueString())
+	sm, err := s.provider.GetSystem(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to find the system section", err.Error())
+		return
+	}
+	setSection(&plan, sm)
+
+	options, err := managedOptions(plan)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", plan.Id.ValueString()), err.Error())
 		return
 	}
 
-	if err := s.provider.CommitOrRevert(ctx, "system", plan.Id.ValueString()); err != nil {
-		resp.Diagnostics.AddError("failed to commit or revert", err.Error())
+	err = s.provider.Transaction(ctx, "system", func(ctx context.Context) error {
+		return s.provider.TSet(ctx, options, "system", plan.section())
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", plan.Id.ValueString()), err.Error())
 		return
 	}
 
@@ -286,13 +452,26 @@ func (s systemResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	sm, err := s.provider.GetAll(ctx, "system", state.Id.ValueString())
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s.provider, diags = api.DeviceClient(ctx, s.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sm, err := s.provider.GetSystem(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read config %q", state.Id.ValueString()), err.Error())
 		return
 	}
 
-	if err := mergo.Merge(&state, sm, mergo.WithOverride, mergo.WithoutDereference); err != nil {
+	if err := refresh(&state, sm, false); err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("failed to merge config system %q", state.Id.ValueString()), err.Error())
 		return
 	}
@@ -314,31 +493,63 @@ func (s systemResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	sm, err := s.provider.GetAll(ctx, s.provider, "system", state.Id.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", state.Id.ValueString()), err.Error())
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if err := mergo.Merge(&state, sm, mergo.WithOverride, mergo.WithoutDereference); err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", state.Id.ValueString()), err.Error())
+	s.provider, diags = api.DeviceClient(ctx, s.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	err = s.provider.TSet(ctx, state, "system", state.Id.ValueString())
+	sm, err := s.provider.GetSystem(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", state.Id.ValueString()), err.Error())
 		return
 	}
+	setSection(&plan, sm)
 
-	if err := s.provider.CommitOrRevert(ctx, "system", state.Id.ValueString()); err != nil {
-		resp.Diagnostics.AddError("failed to commit or revert", err.Error())
+	options, err := managedOptions(plan)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", plan.Id.ValueString()), err.Error())
+		return
+	}
+	previous, err := managedOptions(state)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", plan.Id.ValueString()), err.Error())
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+	err = s.provider.Transaction(ctx, "system", func(ctx context.Context) error {
+		if err := s.provider.TSet(ctx, options, "system", plan.section()); err != nil {
+			return err
+		}
+
+		// the options not managed anymore are reset to their default
+		for name := range previous {
+			if _, ok := options[name]; ok {
+				continue
+			}
+			if err := s.provider.Delete(ctx, "system", plan.section(), name); err != nil {
+				return fmt.Errorf("failed to reset option %q: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update config %q", plan.Id.ValueString()), err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
+// Delete resets the managed options to their default, the section itself is
+// left in place.
 func (s systemResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var state systemModel
 	diags := req.State.Get(ctx, &state)
@@ -346,24 +557,163 @@ func (s systemResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	err := s.provider.Delete(ctx, "system", state.Id.ValueString())
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s.provider, diags = api.DeviceClient(ctx, s.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	options, err := managedOptions(state)
 	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed to delete config system config %q", state.Id.ValueString()), err.Error())
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to reset config %q", state.Id.ValueString()), err.Error())
 		return
 	}
 
-	if err := s.provider.CommitOrRevert(ctx, "system", state.Id.ValueString()); err != nil {
-		resp.Diagnostics.AddError("failed to commit or revert", err.Error())
+	err = s.provider.Transaction(ctx, "system", func(ctx context.Context) error {
+		for name := range options {
+			if err := s.provider.Delete(ctx, "system", state.section(), name); err != nil {
+				return fmt.Errorf("failed to reset option %q: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to reset config %q", state.Id.ValueString()), err.Error())
 		return
 	}
 }
 
-func (s *systemResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+// ImportState takes over all the options found in the system section.
+func (s systemResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, _, client, diags := api.ImportDevice(ctx, s.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	s.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
 	sm, err := s.provider.GetSystem(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to import state", err.Error())
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, sm)...)
+	var state systemModel
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	if err := refresh(&state, sm, true); err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func setSection(m *systemModel, sm *api.System) {
+	m.Id = types.NewStringValue(api.DeviceId(m.Device, sm.Id))
+	m.Type = types.NewStringValue(sm.Type)
+	m.Anonymous = types.NewBoolValue(sm.Anonymous)
+}
+
+// section returns the name of the system section, without the device
+// prefix of the id.
+func (m systemModel) section() string {
+	return api.LocalId(m.Device, m.Id.ValueString())
+}
+
+// managedOptions returns the options set in the model, by their UCI name.
+func managedOptions(m systemModel) (map[string]any, error) {
+	options, err := toOptions(m)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range options {
+		if value == nil {
+			delete(options, name)
+		}
+	}
+	return options, nil
+}
+
+// refresh updates the options of the model with the ones of the section.
+// Unless all is set, only the managed options are refreshed, so that the
+// ones left to their default on the router are not reported as a drift.
+func refresh(m *systemModel, sm *api.System, all bool) error {
+	options, err := toOptions(m)
+	if err != nil {
+		return err
+	}
+	remote, err := toOptions(sm)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range options {
+		if all || value != nil {
+			options[name] = remote[name]
+		}
+	}
+
+	b, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return err
+	}
+	setSection(m, sm)
+	return nil
+}
+
+// toOptions converts a section to its options, leaving out the metadata
+// fields prefixed with a dot.
+func toOptions(section any) (map[string]any, error) {
+	b, err := json.Marshal(section)
+	if err != nil {
+		return nil, err
+	}
+
+	var options map[string]any
+	if err := json.Unmarshal(b, &options); err != nil {
+		return nil, err
+	}
+
+	for name := range options {
+		if strings.HasPrefix(name, ".") {
+			delete(options, name)
+		}
+	}
+	return options, nil
+}
+
+// zoneNameValidator rejects the time zone names missing from the zoneinfo
+// database.
+type zoneNameValidator struct{}
+
+func (v zoneNameValidator) Description(_ context.Context) string {
+	return "value must be an IANA time zone name"
+}
+
+func (v zoneNameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v zoneNameValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, ok := tzdata.POSIX(req.ConfigValue.ValueString()); !ok {
+		resp.Diagnostics.AddAttributeError(req.Path, "Unknown time zone",
+			fmt.Sprintf("%q is not a time zone of the IANA database, e.g. Europe/London.", req.ConfigValue.ValueString()))
+	}
 }
@@ -0,0 +1,520 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/system"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	fwtypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// newFakeSystem keeps the system configuration of the router of the client
+// in memory.
+func newFakeSystem(client *mocks.MockClient, sections []map[string]any) *testutil.FakeUCI {
+	uci := testutil.NewFakeUCI(client, "system", sections...)
+
+	client.
+		EXPECT().
+		GetSystem(gomock.Any()).
+		DoAndReturn(func(_ context.Context) (*api.System, error) {
+			s, err := testutil.GetSection[api.System](uci, "@system[0]")
+			if err != nil {
+				return nil, err
+			}
+			s.Anonymous = true
+			return s, nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		GetNtp(gomock.Any()).
+		DoAndReturn(func(_ context.Context) (*api.Ntp, error) {
+			return testutil.GetSection[api.Ntp](uci, "ntp")
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		GetLed(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) (*api.Led, error) {
+			led, err := testutil.GetSection[api.Led](uci, name)
+			if err == nil && led.Type != "led" {
+				err = fmt.Errorf("section %q is not a led", name)
+			}
+			if err != nil {
+				return nil, errors.Join(api.ErrSectionNotFound, err)
+			}
+			return led, nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		IsRunning(gomock.Any(), "sysntpd").
+		Return(true, nil).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		ReadFile(gomock.Any(), "/var/state/ntpd-stratum").
+		Return([]byte("2\n"), nil).
+		AnyTimes()
+
+	return uci
+}
+
+// checkSingle verifies that there is still a single section of the given
+// type, with the given options. An empty value stands for a reset option.
+func checkSingle(uci *testutil.FakeUCI, sectionType string, options map[string]any) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		found := uci.Sections(sectionType)
+		if len(found) != 1 {
+			return fmt.Errorf("expected a single %s section, got %d", sectionType, len(found))
+		}
+		for name, value := range options {
+			got, ok := found[0][name]
+			switch {
+			case value == "" && ok:
+				return fmt.Errorf("expected option %q to be reset, got %v", name, got)
+			case value != "" && fmt.Sprint(got) != fmt.Sprint(value):
+				return fmt.Errorf("expected option %q to be %v, got %v", name, value, got)
+			}
+		}
+		return nil
+	}
+}
+
+func TestAccSystem_ManagesExistingSection(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	uci := newFakeSystem(testutil.ProviderMocks(t, ctrl, clientFactory), []map[string]any{
+		{
+			".name":    "cfg01e48a",
+			".type":    "system",
+			"hostname": "OpenWrt",
+			"timezone": "UTC",
+			"zonename": "UTC",
+			"log_size": "64",
+		},
+		{
+			".name": "ntp",
+			".type": "timeserver",
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					hostname = "router"
+					timezone = "CET-1CEST,M3.5.0,M10.5.0/3"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_system.system", "id", "cfg01e48a"),
+					resource.TestCheckResourceAttr("openwrt_system.system", "type", "system"),
+					resource.TestCheckResourceAttr("openwrt_system.system", "anonymous", "true"),
+					resource.TestCheckNoResourceAttr("openwrt_system.system", "zonename"),
+					checkSingle(uci, "system", map[string]any{
+						"hostname": "router",
+						"timezone": "CET-1CEST,M3.5.0,M10.5.0/3",
+						"zonename": "UTC",
+						"log_size": "64",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					hostname = "gateway"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_system.system", "id", "cfg01e48a"),
+					resource.TestCheckResourceAttr("openwrt_system.system", "hostname", "gateway"),
+					checkSingle(uci, "system", map[string]any{
+						"hostname": "gateway",
+						"timezone": "",
+						"zonename": "UTC",
+						"log_size": "64",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					hostname = "gateway"
+					log_size = 128
+
+					timeouts {
+						update = "1m"
+					}
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					// the timeouts block is not an option of the section
+					checkSingle(uci, "system", map[string]any{
+						"hostname": "gateway",
+						"log_size": "128",
+						"Timeouts": "",
+						"timeouts": "",
+					}),
+				),
+			},
+			{
+				ResourceName:  "openwrt_system.system",
+				ImportState:   true,
+				ImportStateId: "system",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 {
+						return fmt.Errorf("expected a single state, got %d", len(states))
+					}
+					for name, value := range map[string]any{
+						"id":       "cfg01e48a",
+						"hostname": "gateway",
+						"zonename": "UTC",
+						"log_size": "128",
+					} {
+						if got := states[0].Attributes[name]; got != value {
+							return fmt.Errorf("expected %q to be %q, got %q", name, value, got)
+						}
+					}
+					return nil
+				},
+			},
+		},
+		CheckDestroy: checkSingle(uci, "system", map[string]any{
+			"hostname": "",
+			"zonename": "UTC",
+			"log_size": "",
+		}),
+	})
+}
+
+func TestAccSystem_Device(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	newFakeSystem(testutil.ProviderMocks(t, ctrl, clientFactory), nil)
+	branch := testutil.RouterMocks(ctrl, "branch")
+	uci := newFakeSystem(branch, []map[string]any{
+		{
+			".name":    "cfg01e48a",
+			".type":    "system",
+			"hostname": "OpenWrt",
+		},
+		{
+			".name":   "ntp",
+			".type":   "timeserver",
+			"enabled": "1",
+		},
+	})
+	clientFactory.
+		EXPECT().
+		Get(gomock.Any(), "http://branch.lan", gomock.Any()).
+		Return(branch, nil).
+		AnyTimes()
+
+	const devicesConfig = `
+	provider "openwrt" {
+		user     = "root"
+		password = "test"
+		remote   = "http://test.lan:8080"
+		devices = {
+			branch = {
+				remote   = "http://branch.lan"
+				password = "branch"
+			}
+		}
+	}
+	`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: devicesConfig + `
+				resource "openwrt_system" "system" {
+					device   = "branch"
+					hostname = "branch"
+				}
+
+				resource "openwrt_system_ntp" "ntp" {
+					device  = "branch"
+					enabled = true
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					// the ids import the resources on the same device
+					resource.TestCheckResourceAttr("openwrt_system.system", "id", "branch:cfg01e48a"),
+					resource.TestCheckResourceAttr("openwrt_system_ntp.ntp", "id", "branch:ntp"),
+					checkSingle(uci, "system", map[string]any{"hostname": "branch"}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:      "openwrt_system.system",
+				ImportState:       true,
+				ImportStateId:     "branch:cfg01e48a",
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "openwrt_system_ntp.ntp",
+				ImportState:       true,
+				ImportStateId:     "branch:ntp",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccSystem_ZoneName(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	uci := newFakeSystem(testutil.ProviderMocks(t, ctrl, clientFactory), []map[string]any{
+		{
+			".name":    "cfg01e48a",
+			".type":    "system",
+			"timezone": "UTC",
+			"zonename": "UTC",
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					zonename = "Europe/Londres"
+				}`,
+				ExpectError: regexp.MustCompile(`"Europe/Londres" is not a time zone`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					zonename = "Europe/London"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_system.system", "timezone", "GMT0BST,M3.5.0/1,M10.5.0"),
+					checkSingle(uci, "system", map[string]any{
+						"timezone": "GMT0BST,M3.5.0/1,M10.5.0",
+						"zonename": "Europe/London",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					zonename = "America/New York"
+				}`,
+				Check: checkSingle(uci, "system", map[string]any{
+					"timezone": "EST5EDT,M3.2.0,M11.1.0",
+					"zonename": "America/New York",
+				}),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_system" "system" {
+					hostname = "router"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr("openwrt_system.system", "timezone"),
+					checkSingle(uci, "system", map[string]any{
+						"timezone": "",
+						"zonename": "",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccSystem_Validation(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	uci := newFakeSystem(testutil.ProviderMocks(t, ctrl, clientFactory), []map[string]any{
+		{
+			".name":    "cfg01e48a",
+			".type":    "system",
+			"log_size": "64",
+		},
+	})
+
+	steps := []resource.TestStep{}
+	for attribute, expected := range map[string]string{
+		`log_proto = "tpc"`:          `Attribute log_proto value must be one of`,
+		`log_type = "ring"`:          `Attribute log_type value must be one of`,
+		`zram_comp_algo = "gzip"`:    `Attribute zram_comp_algo value must be one of`,
+		`conloglevel = 9`:            `Attribute conloglevel value must be between 1 and 8`,
+		`cronloglevel = -1`:          `Attribute cronloglevel value must be at least 0`,
+		`log_port = 65536`:           `Attribute log_port value must be between 1 and 65535`,
+		`log_size = 0`:               `Attribute log_size value must be at least 1`,
+		`log_ip = "192.168.1"`:       `"192.168.1" is not an IPv4 nor an IPv6 address`,
+		`hostname = "my_router"`:     `"my_router" is not a valid hostname`,
+		`hostname = "-router.local"`: `"-router.local" is not a valid hostname`,
+	} {
+		steps = append(steps, resource.TestStep{
+			Config: testutil.ProviderConfig + `
+			resource "openwrt_system" "system" {
+				` + attribute + `
+			}`,
+			ExpectError: regexp.MustCompile(expected),
+		})
+	}
+
+	steps = append(steps, resource.TestStep{
+		Config: testutil.ProviderConfig + `
+		resource "openwrt_system" "system" {
+			hostname    = "router.lan"
+			log_ip      = "fd00::1"
+			log_port    = 5140
+			log_proto   = "tcp"
+			log_size    = 128
+			conloglevel = 8
+		}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("openwrt_system.system", "log_port", "5140"),
+			checkSingle(uci, "system", map[string]any{
+				"hostname":    "router.lan",
+				"log_ip":      "fd00::1",
+				"log_port":    "5140",
+				"log_proto":   "tcp",
+				"log_size":    "128",
+				"conloglevel": "8",
+			}),
+		),
+		ConfigPlanChecks: resource.ConfigPlanChecks{
+			PostApplyPostRefresh: []plancheck.PlanCheck{
+				plancheck.ExpectEmptyPlan(),
+			},
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps:                    steps,
+	})
+}
+
+func TestSystem_UpgradeState(t *testing.T) {
+	ctx := context.Background()
+	r, ok := system.NewSystemResource().(fwresource.ResourceWithUpgradeState)
+	if !ok {
+		t.Fatal("the system resource does not upgrade its state")
+	}
+
+	var current fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &current)
+
+	upgrader := r.UpgradeState(ctx)[0]
+	priorType := upgrader.PriorSchema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range priorType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+	values["id"] = tftypes.NewValue(tftypes.String, "cfg01e48a")
+	values["hostname"] = tftypes.NewValue(tftypes.String, "router")
+	values["log_size"] = tftypes.NewValue(tftypes.String, "64")
+	values["log_port"] = tftypes.NewValue(tftypes.String, "514")
+
+	req := fwresource.UpgradeStateRequest{
+		State: &tfsdk.State{
+			Raw:    tftypes.NewValue(priorType, values),
+			Schema: *upgrader.PriorSchema,
+		},
+	}
+	resp := &fwresource.UpgradeStateResponse{
+		State: tfsdk.State{Schema: current.Schema},
+	}
+	upgrader.StateUpgrader(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	for name, expected := range map[string]attr.Value{
+		"hostname":    fwtypes.StringValue("router"),
+		"log_size":    fwtypes.Int64Value(64),
+		"log_port":    fwtypes.Int64Value(514),
+		"conloglevel": fwtypes.Int64Null(),
+	} {
+		var value attr.Value
+		if diags := resp.State.GetAttribute(ctx, path.Root(name), &value); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if value.String() != expected.String() || value.IsNull() != expected.IsNull() {
+			t.Errorf("unexpected %s: got %s, want %s", name, value, expected)
+		}
+	}
+}
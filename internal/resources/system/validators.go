@@ -0,0 +1,62 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package system
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// ipAddressValidator rejects the values that are not an IPv4 nor an IPv6
+// address.
+type ipAddressValidator struct{}
+
+func (v ipAddressValidator) Description(_ context.Context) string {
+	return "value must be an IPv4 or IPv6 address"
+}
+
+func (v ipAddressValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v ipAddressValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := netip.ParseAddr(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid IP address",
+			fmt.Sprintf("%q is not an IPv4 nor an IPv6 address.", req.ConfigValue.ValueString()))
+	}
+}
+
+var hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// hostnameValidator rejects the values that are not a hostname as defined by
+// RFC 1123, i.e. dot separated labels of letters, digits and hyphens.
+type hostnameValidator struct{}
+
+func (v hostnameValidator) Description(_ context.Context) string {
+	return "value must be a valid hostname"
+}
+
+func (v hostnameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v hostnameValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	hostname := req.ConfigValue.ValueString()
+	if len(hostname) > 253 || !hostnameRegexp.MatchString(hostname) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid hostname",
+			fmt.Sprintf("%q is not a valid hostname: it must be made of labels of at most 63 letters, digits and hyphens, separated by dots.", hostname))
+	}
+}
@@ -0,0 +1,432 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uhttpd
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	defaultCertPath = "/etc/uhttpd.crt"
+	defaultKeyPath  = "/etc/uhttpd.key"
+
+	certMode = "0644"
+	keyMode  = "0600"
+)
+
+type certificateModel struct {
+	Certificate types.String `tfsdk:"certificate"`
+	PrivateKey  types.String `tfsdk:"private_key"`
+	CertPath    types.String `tfsdk:"cert_path"`
+	KeyPath     types.String `tfsdk:"key_path"`
+	Fingerprint types.String `tfsdk:"fingerprint"`
+	NotAfter    types.String `tfsdk:"not_after"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type certificateResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure      = (*certificateResource)(nil)
+	_ resource.ResourceWithImportState    = (*certificateResource)(nil)
+	_ resource.ResourceWithModifyPlan     = (*certificateResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*certificateResource)(nil)
+)
+
+// NewCertificateResource return new uhttpd certificate resource.
+func NewCertificateResource() resource.Resource {
+	return &certificateResource{}
+}
+
+func (c certificateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_uhttpd_certificate", req.ProviderTypeName)
+}
+
+func (c certificateResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	path := func(what, defaultPath string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: fmt.Sprintf("Path the %s is written to. (Default: `%s`)", what, defaultPath),
+			Description:         fmt.Sprintf("Path the %s is written to. (Default: %s)", what, defaultPath),
+			Optional:            true,
+			Computed:            true,
+			Default:             stringdefault.StaticString(defaultPath),
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+			Validators: []validator.String{
+				stringvalidator.RegexMatches(pathRegexp, "must be an absolute path"),
+			},
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage the TLS certificate of uhttpd, i.e. a PEM certificate and private key pair. The key is only readable by root. " +
+			"`uhttpd` is restarted after each change, the provider opening a new session afterwards since it goes through it. " +
+			"The default paths are the ones of the default instance, set `cert` and `key` of `openwrt_uhttpd` for other paths. " +
+			"Destroying the resource removes both files, uhttpd generating a new self-signed pair when it restarts if `px5g` is installed.",
+		Description: "Manage the TLS certificate of uhttpd, i.e. a PEM certificate and private key pair. The key is only readable by root. " +
+			"uhttpd is restarted after each change, the provider opening a new session afterwards since it goes through it. " +
+			"The default paths are the ones of the default instance, set cert and key of openwrt_uhttpd for other paths. " +
+			"Destroying the resource removes both files, uhttpd generating a new self-signed pair when it restarts if px5g is installed.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"certificate": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded certificate, optionally followed by its intermediate certificates.",
+				Description:         "PEM encoded certificate, optionally followed by its intermediate certificates.",
+				Required:            true,
+			},
+			"private_key": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded private key of the certificate.",
+				Description:         "PEM encoded private key of the certificate.",
+				Required:            true,
+				Sensitive:           true,
+			},
+			"cert_path": path("certificate", defaultCertPath),
+			"key_path":  path("private key", defaultKeyPath),
+			"fingerprint": schema.StringAttribute{
+				MarkdownDescription: "SHA256 fingerprint of the certificate, in hexadecimal.",
+				Description:         "SHA256 fingerprint of the certificate, in hexadecimal.",
+				Computed:            true,
+			},
+			"not_after": schema.StringAttribute{
+				MarkdownDescription: "Expiration date of the certificate, in RFC 3339 format.",
+				Description:         "Expiration date of the certificate, in RFC 3339 format.",
+				Computed:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (c *certificateResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	c.devices = devices
+}
+
+// ValidateConfig checks that the certificate and the key are a pair, uhttpd
+// failing to start otherwise.
+func (c certificateResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config certificateModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() || config.Certificate.IsUnknown() || config.Certificate.IsNull() {
+		return
+	}
+
+	if _, err := parseCertificate(config.Certificate.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("certificate"), "Invalid certificate", err.Error())
+		return
+	}
+
+	if config.PrivateKey.IsUnknown() || config.PrivateKey.IsNull() {
+		return
+	}
+	if _, err := tls.X509KeyPair([]byte(config.Certificate.ValueString()), []byte(config.PrivateKey.ValueString())); err != nil {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("private_key"), "Invalid private key", err.Error())
+	}
+}
+
+// ModifyPlan computes the fingerprint and the expiration date of the
+// certificate.
+func (c certificateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan certificateModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.Certificate.IsUnknown() {
+		return
+	}
+
+	cert, err := parseCertificate(plan.Certificate.ValueString())
+	if err != nil {
+		return
+	}
+	plan.setCertificateDetails(cert)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, fwpath.Root("fingerprint"), plan.Fingerprint)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, fwpath.Root("not_after"), plan.NotAfter)...)
+}
+
+func (c certificateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan certificateModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.provider, diags = api.DeviceClient(ctx, c.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(c.write(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (c certificateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state certificateModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.provider, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	newState, diags := c.read(ctx, state.CertPath.ValueString(), state.KeyPath.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if newState == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	newState.Device = state.Device
+	newState.Timeouts = state.Timeouts
+	resp.Diagnostics.Append(resp.State.Set(ctx, newState)...)
+}
+
+func (c certificateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan certificateModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.provider, diags = api.DeviceClient(ctx, c.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(c.write(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (c certificateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state certificateModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c.provider, diags = api.DeviceClient(ctx, c.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, filePath := range []string{state.CertPath.ValueString(), state.KeyPath.ValueString()} {
+		if err := c.provider.RemoveFile(ctx, filePath); err != nil && !errors.Is(err, api.ErrFileNotFound) {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed to remove %s", filePath), err.Error())
+			return
+		}
+	}
+
+	if err := c.provider.RestartWebServer(ctx); err != nil {
+		resp.Diagnostics.AddError("Failed to restart uhttpd", err.Error())
+	}
+}
+
+// ImportState accepts the paths of the certificate and of the key, separated
+// by a comma, e.g. /etc/uhttpd.crt,/etc/uhttpd.key.
+func (c certificateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, c.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, fwpath.Root("device"), device)...)
+
+	certPath, keyPath, ok := strings.Cut(id, ",")
+	if !ok || !pathRegexp.MatchString(certPath) || !pathRegexp.MatchString(keyPath) {
+		resp.Diagnostics.AddError("Failed to import state",
+			fmt.Sprintf("expected the paths of the certificate and of the key separated by a comma, e.g. %s,%s, got %q", defaultCertPath, defaultKeyPath, id))
+		return
+	}
+
+	state, diags := c.read(ctx, certPath, keyPath)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if state == nil {
+		resp.Diagnostics.AddError("Failed to import state", fmt.Sprintf("%s or %s does not exist", certPath, keyPath))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, fwpath.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, fwpath.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// read returns the model of the files, or nil when one of them does not
+// exist.
+func (c certificateResource) read(ctx context.Context, certPath, keyPath string) (*certificateModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	m := &certificateModel{
+		CertPath: types.StringValue(certPath),
+		KeyPath:  types.StringValue(keyPath),
+	}
+
+	for _, file := range []struct {
+		path  string
+		value *types.String
+	}{
+		{certPath, &m.Certificate},
+		{keyPath, &m.PrivateKey},
+	} {
+		content, err := c.provider.ReadFile(ctx, file.path)
+		if errors.Is(err, api.ErrFileNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			diags.AddError(fmt.Sprintf("Failed to read %s", file.path), err.Error())
+			return nil, diags
+		}
+		*file.value = types.StringValue(string(content))
+	}
+
+	m.Fingerprint = types.StringNull()
+	m.NotAfter = types.StringNull()
+	// the certificate is refreshed anyway, to be replaced if it is not valid
+	if cert, err := parseCertificate(m.Certificate.ValueString()); err == nil {
+		m.setCertificateDetails(cert)
+	}
+	return m, diags
+}
+
+// write uploads the key then the certificate, and restarts uhttpd to load
+// them.
+func (c certificateResource) write(ctx context.Context, m *certificateModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	cert, err := parseCertificate(m.Certificate.ValueString())
+	if err != nil {
+		diags.AddAttributeError(fwpath.Root("certificate"), "Invalid certificate", err.Error())
+		return diags
+	}
+	m.setCertificateDetails(cert)
+
+	for _, file := range []struct {
+		path, content, mode string
+	}{
+		{m.KeyPath.ValueString(), m.PrivateKey.ValueString(), keyMode},
+		{m.CertPath.ValueString(), m.Certificate.ValueString(), certMode},
+	} {
+		// the key is never readable by the other users, even while written
+		if err := c.provider.WritefileMode(ctx, file.path, []byte(file.content), file.mode); err != nil {
+			diags.AddError(fmt.Sprintf("Failed to write %s", file.path), err.Error())
+			return diags
+		}
+	}
+
+	if err := c.provider.RestartWebServer(ctx); err != nil {
+		diags.AddError("Failed to restart uhttpd", err.Error())
+	}
+	return diags
+}
+
+func (m *certificateModel) setCertificateDetails(cert *x509.Certificate) {
+	fingerprint := sha256.Sum256(cert.Raw)
+	m.Fingerprint = types.StringValue(hex.EncodeToString(fingerprint[:]))
+	m.NotAfter = types.StringValue(cert.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// parseCertificate returns the first certificate of the PEM data, i.e. the
+// one of the server.
+func parseCertificate(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,162 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uhttpd_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+// testCertificate returns a self-signed PEM certificate for the host, its
+// PEM private key and its fingerprint.
+func testCertificate(t *testing.T, host string) (string, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint := sha256.Sum256(der)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})),
+		hex.EncodeToString(fingerprint[:])
+}
+
+func TestAccUhttpdCertificate(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	files := testutil.NewFakeFiles(client, map[string]string{})
+	restarts := testutil.NewRestarts("uhttpd")
+	client.
+		EXPECT().
+		RestartWebServer(gomock.Any()).
+		DoAndReturn(restarts.RestartWebServer).
+		AnyTimes()
+
+	cert, key, fingerprint := testCertificate(t, "router.lan")
+	newCert, newKey, newFingerprint := testCertificate(t, "router.example.com")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_uhttpd_certificate" "main" {
+					certificate = %q
+					private_key = %q
+				}`, cert, newKey),
+				ExpectError: regexp.MustCompile(`Invalid private key`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_uhttpd_certificate" "main" {
+					certificate = "not a certificate"
+					private_key = "not a key"
+				}`,
+				ExpectError: regexp.MustCompile(`no PEM encoded certificate found`),
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_uhttpd_certificate" "main" {
+					certificate = %q
+					private_key = %q
+				}`, cert, key),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_uhttpd_certificate.main", "not_after", "2034-01-01T00:00:00Z"),
+					files.CheckFile("/etc/uhttpd.crt", cert, "0644"),
+					files.CheckFile("/etc/uhttpd.key", key, "0600"),
+					restarts.Check(1),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("openwrt_uhttpd_certificate.main",
+							tfjsonpath.New("fingerprint"), knownvalue.StringExact(fingerprint)),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + fmt.Sprintf(`
+				resource "openwrt_uhttpd_certificate" "main" {
+					certificate = %q
+					private_key = %q
+				}`, newCert, newKey),
+				Check: resource.ComposeTestCheckFunc(
+					files.CheckFile("/etc/uhttpd.crt", newCert, "0644"),
+					files.CheckFile("/etc/uhttpd.key", newKey, "0600"),
+					restarts.Check(2),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_uhttpd_certificate.main", plancheck.ResourceActionUpdate),
+						plancheck.ExpectKnownValue("openwrt_uhttpd_certificate.main",
+							tfjsonpath.New("fingerprint"), knownvalue.StringExact(newFingerprint)),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_uhttpd_certificate.main",
+				ImportState:                          true,
+				ImportStateId:                        "/etc/uhttpd.crt,/etc/uhttpd.key",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "cert_path",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			files.CheckNoFile("/etc/uhttpd.crt"),
+			files.CheckNoFile("/etc/uhttpd.key"),
+			restarts.Check(3),
+		),
+	})
+}
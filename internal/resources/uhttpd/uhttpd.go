@@ -0,0 +1,493 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uhttpd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	customtypes "github.com/foxboron/terraform-provider-openwrt/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const uhttpdConfig = "uhttpd"
+
+var (
+	// listenRegexp matches the [address:]port syntax of the listen options,
+	// IPv6 addresses being bracketed.
+	listenRegexp = regexp.MustCompile(`^(\[[0-9A-Fa-f:.]+\]:|[^\s:\[\]]+:)?[0-9]{1,5}$`)
+	pathRegexp   = regexp.MustCompile(`^/\S*$`)
+)
+
+type uhttpdModel struct {
+	Name          types.String `tfsdk:"name"`
+	ListenHttp    types.List   `tfsdk:"listen_http"`
+	ListenHttps   types.List   `tfsdk:"listen_https"`
+	RedirectHttps types.Bool   `tfsdk:"redirect_https"`
+	Home          types.String `tfsdk:"home"`
+	Cert          types.String `tfsdk:"cert"`
+	Key           types.String `tfsdk:"key"`
+	MaxRequests   types.Int64  `tfsdk:"max_requests"`
+	ScriptTimeout types.Int64  `tfsdk:"script_timeout"`
+	Rfc1918Filter types.Bool   `tfsdk:"rfc1918_filter"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type uhttpdResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*uhttpdResource)(nil)
+	_ resource.ResourceWithImportState = (*uhttpdResource)(nil)
+)
+
+// NewUhttpdResource return new uhttpd resource.
+func NewUhttpdResource() resource.Resource {
+	return &uhttpdResource{}
+}
+
+func (u uhttpdResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_uhttpd", req.ProviderTypeName)
+}
+
+func (u uhttpdResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	listen := func(protocol string) schema.ListAttribute {
+		return schema.ListAttribute{
+			MarkdownDescription: fmt.Sprintf("Addresses to serve %s on, as `[address:]port`, e.g. `0.0.0.0:443` or `[::]:443`.", protocol),
+			Description:         fmt.Sprintf("Addresses to serve %s on, as [address:]port, e.g. 0.0.0.0:443 or [::]:443.", protocol),
+			ElementType:         types.StringType,
+			Optional:            true,
+			Validators: []validator.List{
+				listvalidator.SizeAtLeast(1),
+				listvalidator.ValueStringsAre(
+					stringvalidator.RegexMatches(listenRegexp, "must be a port, optionally preceded by an address"),
+				),
+			},
+		}
+	}
+	path := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: description,
+			Description:         description,
+			Optional:            true,
+			Validators: []validator.String{
+				stringvalidator.RegexMatches(pathRegexp, "must be an absolute path"),
+			},
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a `uhttpd` section of the uhttpd configuration, i.e. an instance of the web server. The default instance is named `main`, import it to manage it. " +
+			"`uhttpd` is restarted after each change, the provider opening a new session afterwards since it goes through it: make sure the instance it talks to keeps listening on the same address.",
+		Description: "Manage a uhttpd section of the uhttpd configuration, i.e. an instance of the web server. The default instance is named main, import it to manage it. " +
+			"uhttpd is restarted after each change, the provider opening a new session afterwards since it goes through it: make sure the instance it talks to keeps listening on the same address.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the section, e.g. `main`.",
+				Description:         "Name of the section, e.g. main.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					customtypes.SectionNameValidator("uhttpd"),
+				},
+			},
+			"listen_http":  listen("HTTP"),
+			"listen_https": listen("HTTPS"),
+			"redirect_https": schema.BoolAttribute{
+				MarkdownDescription: "Redirect the HTTP requests to HTTPS.",
+				Description:         "Redirect the HTTP requests to HTTPS.",
+				Optional:            true,
+			},
+			"home": schema.StringAttribute{
+				MarkdownDescription: "Document root of the instance. (Default: `/www`)",
+				Description:         "Document root of the instance. (Default: /www)",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("/www"),
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(pathRegexp, "must be an absolute path"),
+				},
+			},
+			"cert": path("Path of the certificate used for HTTPS, e.g. the `cert_path` of an `openwrt_uhttpd_certificate`."),
+			"key":  path("Path of the private key used for HTTPS, e.g. the `key_path` of an `openwrt_uhttpd_certificate`."),
+			"max_requests": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of requests served concurrently.",
+				Description:         "Maximum number of requests served concurrently.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"script_timeout": schema.Int64Attribute{
+				MarkdownDescription: "Time in seconds a CGI or Lua script may run for.",
+				Description:         "Time in seconds a CGI or Lua script may run for.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"rfc1918_filter": schema.BoolAttribute{
+				MarkdownDescription: "Reject the requests from public addresses to the private addresses of the router, against DNS rebinding.",
+				Description:         "Reject the requests from public addresses to the private addresses of the router, against DNS rebinding.",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (u *uhttpdResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	u.devices = devices
+}
+
+func (u uhttpdResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan uhttpdModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := plan.Name.ValueString()
+	_, err := u.provider.GetUhttpd(ctx, name)
+	if err == nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Uhttpd section %q already exists", name),
+			"Import it to manage it with this resource.")
+		return
+	}
+	if !errors.Is(err, api.ErrSectionNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read uhttpd section %q", name), err.Error())
+		return
+	}
+
+	options, diags := plan.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(u.commit(ctx, fmt.Sprintf("Failed to add uhttpd section %q", name), func(ctx context.Context) error {
+		return u.provider.AddSection(ctx, uhttpdConfig, "uhttpd", name, options)
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (u uhttpdResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state uhttpdModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uhttpd, err := u.provider.GetUhttpd(ctx, state.Name.ValueString())
+	if errors.Is(err, api.ErrSectionNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read uhttpd section %q", state.Name.ValueString()), err.Error())
+		return
+	}
+
+	refreshed, diags := newUhttpdModel(ctx, uhttpd)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	refreshed.Device = state.Device
+	refreshed.Timeouts = state.Timeouts
+	resp.Diagnostics.Append(resp.State.Set(ctx, refreshed)...)
+}
+
+func (u uhttpdResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state uhttpdModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan uhttpdModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := plan.Name.ValueString()
+	options, diags := plan.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	stateOptions, diags := state.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(u.commit(ctx, fmt.Sprintf("Failed to update uhttpd section %q", name), func(ctx context.Context) error {
+		if err := u.provider.TSet(ctx, options, uhttpdConfig, name); err != nil {
+			return err
+		}
+
+		for option := range stateOptions {
+			if _, ok := options[option]; ok {
+				continue
+			}
+			if err := u.provider.Delete(ctx, uhttpdConfig, name, option); err != nil {
+				return fmt.Errorf("failed to remove option %q: %w", option, err)
+			}
+		}
+		return nil
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (u uhttpdResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state uhttpdModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := state.Name.ValueString()
+	resp.Diagnostics.Append(u.commit(ctx, fmt.Sprintf("Failed to delete uhttpd section %q", name), func(ctx context.Context) error {
+		return u.provider.Delete(ctx, uhttpdConfig, name)
+	})...)
+}
+
+func (u uhttpdResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, u.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	u.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	uhttpd, err := u.provider.GetUhttpd(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+
+	state, diags := newUhttpdModel(ctx, uhttpd)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// commit stages the changes of the uhttpd configuration with stage, commits
+// them, then restarts uhttpd. summary describes the change if it fails.
+func (u uhttpdResource) commit(ctx context.Context, summary string, stage func(ctx context.Context) error) (diags diag.Diagnostics) {
+	if err := u.provider.Transaction(ctx, uhttpdConfig, stage); err != nil {
+		diags.AddError(summary, err.Error())
+		return diags
+	}
+
+	if err := u.provider.RestartWebServer(ctx); err != nil {
+		diags.AddError("Failed to restart uhttpd", err.Error())
+	}
+	return diags
+}
+
+// options returns the options set in the model, by their UCI name.
+func (m uhttpdModel) options(ctx context.Context) (map[string]any, diag.Diagnostics) {
+	options := map[string]any{}
+	for name, value := range map[string]types.List{
+		"listen_http":  m.ListenHttp,
+		"listen_https": m.ListenHttps,
+	} {
+		if value.IsNull() {
+			continue
+		}
+		var addresses []string
+		if diags := value.ElementsAs(ctx, &addresses, false); diags.HasError() {
+			return nil, diags
+		}
+		options[name] = addresses
+	}
+	for name, value := range map[string]types.String{
+		"home": m.Home,
+		"cert": m.Cert,
+		"key":  m.Key,
+	} {
+		if !value.IsNull() {
+			options[name] = value.ValueString()
+		}
+	}
+	for name, value := range map[string]types.Int64{
+		"max_requests":   m.MaxRequests,
+		"script_timeout": m.ScriptTimeout,
+	} {
+		if !value.IsNull() {
+			options[name] = strconv.FormatInt(value.ValueInt64(), 10)
+		}
+	}
+	for name, value := range map[string]types.Bool{
+		"redirect_https": m.RedirectHttps,
+		"rfc1918_filter": m.Rfc1918Filter,
+	} {
+		if !value.IsNull() {
+			options[name] = customtypes.FormatUCIBool(value.ValueBool())
+		}
+	}
+	return options, nil
+}
+
+func newUhttpdModel(ctx context.Context, uhttpd *api.Uhttpd) (uhttpdModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	m := uhttpdModel{
+		Name:          types.StringValue(uhttpd.Id),
+		ListenHttp:    types.ListNull(types.StringType),
+		ListenHttps:   types.ListNull(types.StringType),
+		RedirectHttps: customtypes.UCIBool(uhttpd.RedirectHttps),
+		Home:          customtypes.UCIString(uhttpd.Home),
+		Cert:          customtypes.UCIString(uhttpd.Cert),
+		Key:           customtypes.UCIString(uhttpd.Key),
+		MaxRequests:   types.Int64Null(),
+		ScriptTimeout: types.Int64Null(),
+		Rfc1918Filter: customtypes.UCIBool(uhttpd.Rfc1918Filter),
+	}
+
+	for _, list := range []struct {
+		value     *types.List
+		addresses []string
+	}{
+		{&m.ListenHttp, uhttpd.ListenHttp},
+		{&m.ListenHttps, uhttpd.ListenHttps},
+	} {
+		if len(list.addresses) == 0 {
+			continue
+		}
+		value, d := types.ListValueFrom(ctx, types.StringType, list.addresses)
+		diags.Append(d...)
+		*list.value = value
+	}
+
+	for _, option := range []struct {
+		value *types.Int64
+		name  string
+		raw   string
+	}{
+		{&m.MaxRequests, "max_requests", uhttpd.MaxRequests},
+		{&m.ScriptTimeout, "script_timeout", uhttpd.ScriptTimeout},
+	} {
+		if option.raw == "" {
+			continue
+		}
+		i, err := strconv.ParseInt(option.raw, 10, 64)
+		if err != nil {
+			diags.AddError(fmt.Sprintf("Invalid %s of uhttpd section %q", option.name, uhttpd.Id), err.Error())
+			continue
+		}
+		*option.value = types.Int64Value(i)
+	}
+	return m, diags
+}
@@ -0,0 +1,173 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uhttpd_test
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+)
+
+// newFakeUhttpd keeps the uhttpd configuration in memory, starting with the
+// main instance.
+func newFakeUhttpd(client *mocks.MockClient) *testutil.FakeUCI {
+	uci := testutil.NewFakeUCI(client, "uhttpd", map[string]any{
+		".name":        "main",
+		".type":        "uhttpd",
+		"listen_http":  []string{"0.0.0.0:80", "[::]:80"},
+		"listen_https": []string{"0.0.0.0:443", "[::]:443"},
+		"home":         "/www",
+		"cert":         "/etc/uhttpd.crt",
+		"key":          "/etc/uhttpd.key",
+	})
+
+	client.
+		EXPECT().
+		GetUhttpd(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) (*api.Uhttpd, error) {
+			return testutil.GetSection[api.Uhttpd](uci, name)
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		RestartWebServer(gomock.Any()).
+		DoAndReturn(func(_ context.Context) error {
+			uci.Restarted("uhttpd")
+			return nil
+		}).
+		AnyTimes()
+
+	return uci
+}
+
+// checkUhttpd verifies the options of the named section, or that it is
+// missing if options is nil. uhttpd must have been restarted after the last
+// commit.
+func checkUhttpd(uci *testutil.FakeUCI, name string, options map[string]any) resource.TestCheckFunc {
+	return resource.ComposeTestCheckFunc(
+		uci.CheckRestarted("uhttpd"),
+		uci.CheckSection(name, options),
+	)
+}
+
+func TestAccUhttpd(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	uhttpd := newFakeUhttpd(client)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_uhttpd" "admin" {
+					name        = "admin"
+					listen_http = ["localhost"]
+				}`,
+				ExpectError: regexp.MustCompile(`must be a port, optionally preceded by an address`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_uhttpd" "main" {
+					name = "main"
+				}`,
+				ExpectError: regexp.MustCompile(`Uhttpd section "main" already exists`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_uhttpd" "admin" {
+					name           = "admin"
+					listen_https   = ["192.168.1.1:8443"]
+					home           = "/www-admin"
+					cert           = "/etc/admin.crt"
+					key            = "/etc/admin.key"
+					max_requests   = 5
+					script_timeout = 120
+					rfc1918_filter = true
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkUhttpd(uhttpd, "admin", map[string]any{
+						"listen_https":   []string{"192.168.1.1:8443"},
+						"home":           "/www-admin",
+						"cert":           "/etc/admin.crt",
+						"key":            "/etc/admin.key",
+						"max_requests":   "5",
+						"script_timeout": "120",
+						"rfc1918_filter": "1",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_uhttpd" "admin" {
+					name           = "admin"
+					listen_http    = ["192.168.1.1:8080"]
+					listen_https   = ["192.168.1.1:8443"]
+					redirect_https = true
+					home           = "/www-admin"
+					cert           = "/etc/admin.crt"
+					key            = "/etc/admin.key"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					checkUhttpd(uhttpd, "admin", map[string]any{
+						"listen_http":    []string{"192.168.1.1:8080"},
+						"listen_https":   []string{"192.168.1.1:8443"},
+						"redirect_https": "1",
+						"home":           "/www-admin",
+						"cert":           "/etc/admin.crt",
+						"key":            "/etc/admin.key",
+					}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_uhttpd.admin", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_uhttpd.admin",
+				ImportState:                          true,
+				ImportStateId:                        "admin",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			checkUhttpd(uhttpd, "admin", nil),
+			checkUhttpd(uhttpd, "main", map[string]any{
+				"listen_http":  []string{"0.0.0.0:80", "[::]:80"},
+				"listen_https": []string{"0.0.0.0:443", "[::]:443"},
+				"home":         "/www",
+				"cert":         "/etc/uhttpd.crt",
+				"key":          "/etc/uhttpd.key",
+			}),
+		),
+	})
+}
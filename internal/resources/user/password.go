@@ -0,0 +1,222 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type passwordModel struct {
+	Username        types.String `tfsdk:"username"`
+	Password        types.String `tfsdk:"password"`
+	PasswordVersion types.String `tfsdk:"password_version"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type passwordResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure = (*passwordResource)(nil)
+)
+
+// NewPasswordResource return new user password resource.
+func NewPasswordResource() resource.Resource {
+	return &passwordResource{}
+}
+
+func (p passwordResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_user_password", req.ProviderTypeName)
+}
+
+func (p passwordResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Set the password of a user of openwrt, through the `sys.user.setpasswd` LuCI RPC. The password is write-only: it is never stored in the state, and the hash cannot be read back from the router, so it is only set again when `password_version` changes. Destroying the resource leaves the password in place. Requires Terraform 1.11 or later.",
+		Description:         "Set the password of a user of openwrt, through the sys.user.setpasswd LuCI RPC. The password is write-only: it is never stored in the state, and the hash cannot be read back from the router, so it is only set again when password_version changes. Destroying the resource leaves the password in place. Requires Terraform 1.11 or later.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"username": schema.StringAttribute{
+				MarkdownDescription: "Name of the user, e.g. `root`.",
+				Description:         "Name of the user, e.g. root.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(usernameRegexp, "must be a valid user name"),
+				},
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "New password of the user.",
+				Description:         "New password of the user.",
+				Required:            true,
+				WriteOnly:           true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"password_version": schema.StringAttribute{
+				MarkdownDescription: "Arbitrary value to change whenever `password` changes, so that the new password is set.",
+				Description:         "Arbitrary value to change whenever password changes, so that the new password is set.",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (p *passwordResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	p.devices = devices
+}
+
+func (p passwordResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan passwordModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.provider, diags = api.DeviceClient(ctx, p.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(p.setPassword(ctx, plan.Username.ValueString(), req.Config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Password = types.StringNull()
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read only checks that the user still exists, the password cannot be read
+// back.
+func (p passwordResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state passwordModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.provider, diags = api.DeviceClient(ctx, p.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := p.provider.GetUser(ctx, state.Username.ValueString())
+	if errors.Is(err, api.ErrUserNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read user %q", state.Username.ValueString()), err.Error())
+		return
+	}
+}
+
+// Update sets the password again, which only happens when password_version
+// changes since the password itself never shows up in the plan.
+func (p passwordResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan passwordModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.provider, diags = api.DeviceClient(ctx, p.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(p.setPassword(ctx, plan.Username.ValueString(), req.Config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Password = types.StringNull()
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete leaves the password in place, there is no previous one to restore.
+func (p passwordResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
+
+// setPassword reads the password from the configuration, as write-only
+// attributes are always null in the plan.
+func (p passwordResource) setPassword(ctx context.Context, username string, config tfsdk.Config) (diags diag.Diagnostics) {
+	var password types.String
+	diags.Append(config.GetAttribute(ctx, path.Root("password"), &password)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := p.provider.SetPassword(ctx, username, password.ValueString()); err != nil {
+		diags.AddError(fmt.Sprintf("Failed to set the password of %q", username), err.Error())
+	}
+	return diags
+}
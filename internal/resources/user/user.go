@@ -0,0 +1,342 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	// usernameRegexp follows the default NAME_REGEX of useradd, without the
+	// trailing $ of machine accounts.
+	usernameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+	// gecosRegexp rejects the separators of /etc/passwd.
+	gecosRegexp = regexp.MustCompile(`^[^:\n]*$`)
+)
+
+type userModel struct {
+	Name        types.String `tfsdk:"name"`
+	Uid         types.Int64  `tfsdk:"uid"`
+	Gid         types.Int64  `tfsdk:"gid"`
+	Description types.String `tfsdk:"description"`
+	Home        types.String `tfsdk:"home"`
+	Shell       types.String `tfsdk:"shell"`
+
+	Device   types.String   `tfsdk:"device"`
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+type userResource struct {
+	provider api.Client
+	devices  api.Devices
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*userResource)(nil)
+	_ resource.ResourceWithImportState = (*userResource)(nil)
+)
+
+// NewUserResource return new user resource.
+func NewUserResource() resource.Resource {
+	return &userResource{}
+}
+
+func (u userResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_user", req.ProviderTypeName)
+}
+
+func (u userResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a service account of openwrt, i.e. an entry of `/etc/passwd`. A new account gets a locked entry in `/etc/shadow`, use `openwrt_user_password` to let it log in. The `root` account cannot be managed.",
+		Description:         "Manage a service account of openwrt, i.e. an entry of /etc/passwd. A new account gets a locked entry in /etc/shadow, use openwrt_user_password to let it log in. The root account cannot be managed.",
+		Attributes: map[string]schema.Attribute{
+			"device": api.DeviceSchemaAttribute,
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the account, e.g. `backup`.",
+				Description:         "Name of the account, e.g. backup.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(usernameRegexp, "must be a valid user name"),
+					stringvalidator.NoneOf("root"),
+				},
+			},
+			"uid": schema.Int64Attribute{
+				MarkdownDescription: "User id of the account.",
+				Description:         "User id of the account.",
+				Required:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65534),
+				},
+			},
+			"gid": schema.Int64Attribute{
+				MarkdownDescription: "Primary group id of the account. (Default: `uid` when the account is created, then unchanged)",
+				Description:         "Primary group id of the account. (Default: uid when the account is created, then unchanged)",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Int64{
+					int64validator.Between(0, 65534),
+				},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Description of the account, i.e. the GECOS field.",
+				Description:         "Description of the account, i.e. the GECOS field.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(gecosRegexp, "must not contain a colon or a newline"),
+				},
+			},
+			"home": schema.StringAttribute{
+				MarkdownDescription: "Home directory of the account. (Default: `/var`)",
+				Description:         "Home directory of the account. (Default: /var)",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("/var"),
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^/[^:\n]*$`), "must be an absolute path"),
+				},
+			},
+			"shell": schema.StringAttribute{
+				MarkdownDescription: "Login shell of the account. (Default: `/bin/false`)",
+				Description:         "Login shell of the account. (Default: /bin/false)",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("/bin/false"),
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^/[^:\n]*$`), "must be an absolute path"),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (u *userResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	data := req.ProviderData
+	if data == nil {
+		return
+	}
+	devices, ok := api.DevicesOf(data)
+	if !ok {
+		resp.Diagnostics.AddError("Failed to get api client", "")
+		return
+	}
+	u.devices = devices
+}
+
+func (u userResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan userModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := plan.Name.ValueString()
+	_, err := u.provider.GetUser(ctx, name)
+	if err == nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("User %q already exists", name),
+			"Import it to manage it with this resource.")
+		return
+	}
+	if !errors.Is(err, api.ErrUserNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read user %q", name), err.Error())
+		return
+	}
+
+	if plan.Gid.IsUnknown() {
+		plan.Gid = plan.Uid
+	}
+	if err := u.provider.SetUser(ctx, plan.user()); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to add user %q", name), err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (u userResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state userModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Read)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	user, err := u.provider.GetUser(ctx, state.Name.ValueString())
+	if errors.Is(err, api.ErrUserNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to read user %q", state.Name.ValueString()), err.Error())
+		return
+	}
+
+	state.refresh(user)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (u userResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan userModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, plan.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, plan.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Gid.IsUnknown() {
+		plan.Gid = plan.Uid
+	}
+	if err := u.provider.SetUser(ctx, plan.user()); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to update user %q", plan.Name.ValueString()), err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (u userResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state userModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diags := api.WithOperationTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	u.provider, diags = api.DeviceClient(ctx, u.devices, state.Device)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := u.provider.DeleteUser(ctx, state.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed to delete user %q", state.Name.ValueString()), err.Error())
+	}
+}
+
+func (u userResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	device, id, client, diags := api.ImportDevice(ctx, u.devices, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	u.provider = client
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("device"), device)...)
+
+	if id == "root" {
+		resp.Diagnostics.AddError("Failed to import state", "The root account cannot be managed.")
+		return
+	}
+
+	user, err := u.provider.GetUser(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to import state", err.Error())
+		return
+	}
+
+	var state userModel
+	state.refresh(user)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("device"), &state.Device)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("timeouts"), &state.Timeouts)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (m userModel) user() *api.User {
+	return &api.User{
+		Name:  m.Name.ValueString(),
+		Uid:   int(m.Uid.ValueInt64()),
+		Gid:   int(m.Gid.ValueInt64()),
+		Gecos: m.Description.ValueString(),
+		Home:  m.Home.ValueString(),
+		Shell: m.Shell.ValueString(),
+	}
+}
+
+func (m *userModel) refresh(user *api.User) {
+	m.Name = types.StringValue(user.Name)
+	m.Uid = types.Int64Value(int64(user.Uid))
+	m.Gid = types.Int64Value(int64(user.Gid))
+	m.Description = types.StringNull()
+	if user.Gecos != "" {
+		m.Description = types.StringValue(user.Gecos)
+	}
+	m.Home = types.StringValue(user.Home)
+	m.Shell = types.StringValue(user.Shell)
+}
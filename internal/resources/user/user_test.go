@@ -0,0 +1,315 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package user_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/internal/resources/user"
+	"github.com/foxboron/terraform-provider-openwrt/internal/testutil"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+// fakePasswd keeps the accounts of the router in memory, along with the
+// passwords that were set.
+type fakePasswd struct {
+	mu        sync.Mutex
+	users     map[string]api.User
+	passwords map[string]string
+}
+
+func newFakePasswd(client *mocks.MockClient) *fakePasswd {
+	f := &fakePasswd{
+		users: map[string]api.User{
+			"root":   {Name: "root", Uid: 0, Gid: 0, Home: "/root", Shell: "/bin/ash"},
+			"daemon": {Name: "daemon", Uid: 1, Gid: 1, Gecos: "daemon", Home: "/var", Shell: "/bin/false"},
+		},
+		passwords: map[string]string{},
+	}
+
+	client.
+		EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) (*api.User, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			u, ok := f.users[name]
+			if !ok {
+				return nil, errors.Join(api.ErrUserNotFound, fmt.Errorf("%s in /etc/passwd", name))
+			}
+			return &u, nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		SetUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, u *api.User) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.users[u.Name] = *u
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		DeleteUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.users, name)
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		SetPassword(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name, password string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if _, ok := f.users[name]; !ok {
+				return fmt.Errorf("passwd: unknown user %s", name)
+			}
+			f.passwords[name] = password
+			return nil
+		}).
+		AnyTimes()
+
+	return f
+}
+
+// check verifies the entry of the named account, or that it is missing if
+// expected is nil.
+func (f *fakePasswd) check(name string, expected *api.User) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		u, ok := f.users[name]
+		switch {
+		case expected == nil && ok:
+			return fmt.Errorf("user %q still exists", name)
+		case expected == nil:
+			return nil
+		case !ok:
+			return fmt.Errorf("user %q not found", name)
+		case u != *expected:
+			return fmt.Errorf("expected user %q to be %q, got %q", name, expected.String(), u.String())
+		}
+		return nil
+	}
+}
+
+func TestAccUser(t *testing.T) {
+	os.Setenv("TF_ACC", "1")    //nolint:errcheck
+	defer os.Unsetenv("TF_ACC") //nolint:errcheck
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientFactory := mocks.NewMockClientFactory(ctrl)
+	testAccProtoV6ProviderFactories := testutil.TestAccFactories(clientFactory)
+
+	client := testutil.ProviderMocks(t, ctrl, clientFactory)
+	passwd := newFakePasswd(client)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_user" "root" {
+					name = "root"
+					uid  = 1000
+				}`,
+				ExpectError: regexp.MustCompile(`value must be none of`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_user" "daemon" {
+					name = "daemon"
+					uid  = 1
+				}`,
+				ExpectError: regexp.MustCompile(`User "daemon" already exists`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_user" "backup" {
+					name        = "backup"
+					uid         = 1000
+					description = "backup:daily"
+				}`,
+				ExpectError: regexp.MustCompile(`must not contain a colon or a newline`),
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_user" "backup" {
+					name = "backup"
+					uid  = 1000
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("openwrt_user.backup", "gid", "1000"),
+					resource.TestCheckNoResourceAttr("openwrt_user.backup", "description"),
+					passwd.check("backup", &api.User{Name: "backup", Uid: 1000, Gid: 1000, Home: "/var", Shell: "/bin/false"}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			// the default gid is kept on updates, instead of showing as unknown
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_user" "backup" {
+					name  = "backup"
+					uid   = 1000
+					shell = "/bin/ash"
+				}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_user.backup", plancheck.ResourceActionUpdate),
+						plancheck.ExpectKnownValue("openwrt_user.backup", tfjsonpath.New("gid"), knownvalue.Int64Exact(1000)),
+					},
+				},
+			},
+			{
+				Config: testutil.ProviderConfig + `
+				resource "openwrt_user" "backup" {
+					name        = "backup"
+					uid         = 1000
+					gid         = 65534
+					description = "Backups"
+					home        = "/srv/backup"
+					shell       = "/bin/ash"
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					passwd.check("backup", &api.User{Name: "backup", Uid: 1000, Gid: 65534, Gecos: "Backups", Home: "/srv/backup", Shell: "/bin/ash"}),
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("openwrt_user.backup", plancheck.ResourceActionUpdate),
+					},
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				ResourceName:                         "openwrt_user.backup",
+				ImportState:                          true,
+				ImportStateId:                        "backup",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "name",
+			},
+		},
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			passwd.check("backup", nil),
+			passwd.check("daemon", &api.User{Name: "daemon", Uid: 1, Gid: 1, Gecos: "daemon", Home: "/var", Shell: "/bin/false"}),
+		),
+	})
+}
+
+// passwordValue builds a value of the openwrt_user_password schema.
+func passwordValue(ctx context.Context, t *testing.T, s fwresource.SchemaResponse, username, password, version any) tftypes.Value {
+	t.Helper()
+
+	typ := s.Schema.Type().TerraformType(ctx)
+	return tftypes.NewValue(typ, map[string]tftypes.Value{
+		"device":           tftypes.NewValue(tftypes.String, nil),
+		"username":         tftypes.NewValue(tftypes.String, username),
+		"password":         tftypes.NewValue(tftypes.String, password),
+		"password_version": tftypes.NewValue(tftypes.String, version),
+		"timeouts":         tftypes.NewValue(s.Schema.Blocks["timeouts"].Type().TerraformType(ctx), nil),
+	})
+}
+
+// TestUserPassword calls the resource directly, as the write-only password
+// requires a more recent Terraform than the one of the acceptance tests.
+func TestUserPassword(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	passwd := newFakePasswd(client)
+
+	r := user.NewPasswordResource()
+	var configureResp fwresource.ConfigureResponse
+	r.(fwresource.ResourceWithConfigure).Configure(ctx, fwresource.ConfigureRequest{ProviderData: client}, &configureResp)
+	if configureResp.Diagnostics.HasError() {
+		t.Fatalf("configure: %v", configureResp.Diagnostics)
+	}
+
+	var s fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &s)
+
+	state := tfsdk.State{Schema: s.Schema, Raw: passwordValue(ctx, t, s, nil, nil, nil)}
+	createResp := fwresource.CreateResponse{State: state}
+	r.Create(ctx, fwresource.CreateRequest{
+		Config: tfsdk.Config{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "root", "s3cret", "1")},
+		Plan:   tfsdk.Plan{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "root", nil, "1")},
+	}, &createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("create: %v", createResp.Diagnostics)
+	}
+	if got := passwd.passwords["root"]; got != "s3cret" {
+		t.Errorf("expected the password of root to be set, got %q", got)
+	}
+	if expected := passwordValue(ctx, t, s, "root", nil, "1"); !createResp.State.Raw.Equal(expected) {
+		t.Errorf("expected the state to be %v, got %v", expected, createResp.State.Raw)
+	}
+
+	updateResp := fwresource.UpdateResponse{State: createResp.State}
+	r.Update(ctx, fwresource.UpdateRequest{
+		Config: tfsdk.Config{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "root", "n3w", "2")},
+		Plan:   tfsdk.Plan{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "root", nil, "2")},
+		State:  createResp.State,
+	}, &updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("update: %v", updateResp.Diagnostics)
+	}
+	if got := passwd.passwords["root"]; got != "n3w" {
+		t.Errorf("expected the password of root to be updated, got %q", got)
+	}
+
+	createResp = fwresource.CreateResponse{State: state}
+	r.Create(ctx, fwresource.CreateRequest{
+		Config: tfsdk.Config{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "nobody", "s3cret", nil)},
+		Plan:   tfsdk.Plan{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "nobody", nil, nil)},
+	}, &createResp)
+	if !createResp.Diagnostics.HasError() {
+		t.Errorf("expected setting the password of a missing user to fail")
+	}
+
+	readResp := fwresource.ReadResponse{State: tfsdk.State{Schema: s.Schema, Raw: passwordValue(ctx, t, s, "nobody", nil, nil)}}
+	r.Read(ctx, fwresource.ReadRequest{State: readResp.State}, &readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("read: %v", readResp.Diagnostics)
+	}
+	if !readResp.State.Raw.IsNull() {
+		t.Errorf("expected the password of a missing user to be removed from the state")
+	}
+}
@@ -0,0 +1,451 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build test
+
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"go.uber.org/mock/gomock"
+)
+
+var extendedSyntax = regexp.MustCompile(`^@(\w+)\[(\d+)\]$`)
+
+// FakeUCI keeps the sections of a configuration in memory, the way the router
+// applies the changes of the client. Each section holds its name and type in
+// the .name and .type options, as ubus returns them. The commits are counted,
+// along with the commit count at the last restart of each service.
+type FakeUCI struct {
+	mu       sync.Mutex
+	sections []map[string]any
+	added    int
+
+	commits  int
+	restarts map[string]int
+}
+
+// NewFakeUCI makes the client apply the changes of the config to sections.
+func NewFakeUCI(client *mocks.MockClient, config string, sections ...map[string]any) *FakeUCI {
+	f := &FakeUCI{sections: sections, restarts: map[string]int{}}
+
+	client.
+		EXPECT().
+		AddSection(gomock.Any(), config, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(f.addSection).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Add(gomock.Any(), gomock.Any()).
+		DoAndReturn(f.add).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		TSet(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(f.tSet).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Delete(gomock.Any(), gomock.Any()).
+		DoAndReturn(f.delete).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		CommitOrRevert(gomock.Any(), config).
+		DoAndReturn(func(_ context.Context, _ ...any) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.commits++
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		RestartService(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, service string) error {
+			f.Restarted(service)
+			return nil
+		}).
+		AnyTimes()
+
+	return f
+}
+
+// find returns the section of the name, or of the extended syntax
+// @type[index], along with its index.
+func (f *FakeUCI) find(name string) (int, map[string]any, error) {
+	if m := extendedSyntax.FindStringSubmatch(name); m != nil {
+		index, _ := strconv.Atoi(m[2])
+		for i, s := range f.sections {
+			if s[".type"] != m[1] {
+				continue
+			}
+			if index == 0 {
+				return i, s, nil
+			}
+			index--
+		}
+	}
+	for i, s := range f.sections {
+		if s[".name"] == name {
+			return i, s, nil
+		}
+	}
+	return -1, nil, errors.Join(api.ErrSectionNotFound, fmt.Errorf("section %q not found", name))
+}
+
+func (f *FakeUCI) addSection(_ context.Context, _, sectionType, name string, values map[string]any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, _, err := f.find(name); err == nil {
+		return fmt.Errorf("section %q already exists", name)
+	}
+	s := map[string]any{".name": name, ".type": sectionType}
+	maps.Copy(s, values)
+	f.sections = append(f.sections, s)
+	return nil
+}
+
+func (f *FakeUCI) add(_ context.Context, section ...any) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var name string
+	for {
+		f.added++
+		name = fmt.Sprintf("cfg%06x", f.added)
+		if _, _, err := f.find(name); err != nil {
+			break
+		}
+	}
+	f.sections = append(f.sections, map[string]any{
+		".name":      name,
+		".type":      section[1].(string),
+		".anonymous": true,
+	})
+	return name, nil
+}
+
+// tSet sets the options of the data, a struct of the api being set as its
+// JSON encoding, in which a null resets the option.
+func (f *FakeUCI) tSet(_ context.Context, data any, section ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, s, err := f.find(section[1].(string))
+	if err != nil {
+		return err
+	}
+	options, ok := data.(map[string]any)
+	if !ok {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &options); err != nil {
+			return err
+		}
+	}
+	for name, value := range options {
+		if value == nil {
+			delete(s, name)
+		} else {
+			s[name] = value
+		}
+	}
+	return nil
+}
+
+func (f *FakeUCI) delete(_ context.Context, section ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, s, err := f.find(section[1].(string))
+	if err != nil {
+		return err
+	}
+	if len(section) > 2 {
+		delete(s, section[2].(string))
+		return nil
+	}
+	f.sections = append(f.sections[:i], f.sections[i+1:]...)
+	return nil
+}
+
+// Restarted records a restart of the service, for the restarts the client
+// runs other than with RestartService.
+func (f *FakeUCI) Restarted(service string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restarts[service] = f.commits
+}
+
+// Sections returns a copy of the sections of the type.
+func (f *FakeUCI) Sections(sectionType string) []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var found []map[string]any
+	for _, s := range f.sections {
+		if s[".type"] == sectionType {
+			found = append(found, maps.Clone(s))
+		}
+	}
+	return found
+}
+
+// Lookup returns the name of the first section of the type whose option has
+// the value, or "" if there is none.
+func (f *FakeUCI) Lookup(sectionType, option string, value any) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, s := range f.sections {
+		if s[".type"] == sectionType && s[option] == value {
+			return s[".name"].(string)
+		}
+	}
+	return ""
+}
+
+// GetSection returns the section as the struct of the api, along with its
+// index in the configuration. ErrSectionNotFound is returned if it is missing.
+func GetSection[T any](f *FakeUCI, name string) (*T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, s, err := f.find(name)
+	if err != nil {
+		return nil, err
+	}
+	s = maps.Clone(s)
+	s[".index"] = i
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var section T
+	if err := json.Unmarshal(b, &section); err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// CheckSection verifies the options of the section, or that it is missing if
+// options is nil. The options are compared by their JSON encoding, a list set
+// as []string being read back as []any.
+func (f *FakeUCI) CheckSection(name string, options map[string]any) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		_, s, err := f.find(name)
+		if options == nil {
+			if err == nil {
+				return fmt.Errorf("section %q still exists", name)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		got := map[string]any{}
+		for option, value := range s {
+			if option[0] != '.' {
+				got[option] = value
+			}
+		}
+		gotJSON, err := json.Marshal(got)
+		if err != nil {
+			return err
+		}
+		expectedJSON, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(gotJSON, expectedJSON) {
+			return fmt.Errorf("expected the options of section %q to be %s, got %s", name, expectedJSON, gotJSON)
+		}
+		return nil
+	}
+}
+
+// CheckRestarted verifies that the service was restarted after the last
+// commit.
+func (f *FakeUCI) CheckRestarted(service string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if f.commits == 0 || f.restarts[service] != f.commits {
+			return fmt.Errorf("%s was not restarted after the last commit", service)
+		}
+		return nil
+	}
+}
+
+// FakeFiles keeps the files of the router in memory, along with the mode they
+// were created with.
+type FakeFiles struct {
+	mu    sync.Mutex
+	files map[string]string
+	modes map[string]string
+}
+
+// NewFakeFiles makes the client read, write and remove files.
+func NewFakeFiles(client *mocks.MockClient, files map[string]string) *FakeFiles {
+	f := &FakeFiles{files: files, modes: map[string]string{}}
+
+	client.
+		EXPECT().
+		ReadFile(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string) ([]byte, error) {
+			content, ok := f.File(path)
+			if !ok {
+				return nil, api.ErrFileNotFound
+			}
+			return []byte(content), nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		Writefile(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string, data []byte) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.files[path] = string(data)
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		WritefileMode(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string, data []byte, mode string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.files[path] = string(data)
+			f.modes[path] = mode
+			return nil
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		RemoveFile(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, path string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.files, path)
+			delete(f.modes, path)
+			return nil
+		}).
+		AnyTimes()
+
+	return f
+}
+
+// File returns the content of the file, and whether it exists.
+func (f *FakeFiles) File(path string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.files[path]
+	return content, ok
+}
+
+// CheckFile verifies the content and the mode of the file, the mode being
+// empty unless the file was written with one.
+func (f *FakeFiles) CheckFile(path, content, mode string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		got, ok := f.files[path]
+		if !ok {
+			return fmt.Errorf("%s not found", path)
+		}
+		if got != content {
+			return fmt.Errorf("expected %s to be %q, got %q", path, content, got)
+		}
+		if f.modes[path] != mode {
+			return fmt.Errorf("expected the mode of %s to be %q, got %q", path, mode, f.modes[path])
+		}
+		return nil
+	}
+}
+
+// CheckMode verifies the mode the file was written with.
+func (f *FakeFiles) CheckMode(path, mode string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if got := f.modes[path]; got != mode {
+			return fmt.Errorf("expected the mode of %s to be %q, got %q", path, mode, got)
+		}
+		return nil
+	}
+}
+
+// CheckNoFile verifies that the file is missing.
+func (f *FakeFiles) CheckNoFile(path string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		if _, ok := f.File(path); ok {
+			return fmt.Errorf("%s still exists", path)
+		}
+		return nil
+	}
+}
+
+// Restarts counts the restarts of a service, its methods standing for the
+// ones of the client.
+type Restarts struct {
+	service string
+	count   atomic.Int64
+}
+
+func NewRestarts(service string) *Restarts {
+	return &Restarts{service: service}
+}
+
+func (r *Restarts) RestartService(_ context.Context, _ string) error {
+	r.count.Add(1)
+	return nil
+}
+
+func (r *Restarts) RestartWebServer(_ context.Context) error {
+	r.count.Add(1)
+	return nil
+}
+
+// Check verifies the count of restarts.
+func (r *Restarts) Check(expected int64) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		if got := r.count.Load(); got != expected {
+			return fmt.Errorf("expected %s to be restarted %d times, got %d", r.service, expected, got)
+		}
+		return nil
+	}
+}
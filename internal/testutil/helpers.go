@@ -14,11 +14,13 @@ import (
 
 	"github.com/foxboron/terraform-provider-openwrt/internal/api"
 	"github.com/foxboron/terraform-provider-openwrt/internal/provider"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
 	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"go.uber.org/mock/gomock"
 )
 
 var (
@@ -121,3 +123,27 @@ func (cS *StateChecker) verifyAttribute(attributeValue interface{}) error {
 		return fmt.Errorf("attribute %+v not managed", attributeValue)
 	}
 }
+
+// ExpectTransactions makes the client run the transactions as the router
+// would for a single resource: the changes are staged, then committed with
+// CommitOrRevert.
+func ExpectTransactions(client *mocks.MockClient) {
+	client.
+		EXPECT().
+		Transaction(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, config string, stage func(ctx context.Context) error) error {
+			if err := stage(ctx); err != nil {
+				return err
+			}
+			return client.CommitOrRevert(ctx, config)
+		}).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		TransactionTo(gomock.Any(), gomock.Any(), "", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, config, _ string, stage func(ctx context.Context) error) error {
+			return client.Transaction(ctx, config, stage)
+		}).
+		AnyTimes()
+}
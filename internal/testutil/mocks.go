@@ -0,0 +1,70 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build test
+
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/api"
+	"github.com/foxboron/terraform-provider-openwrt/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// ProviderConfig configures the provider with the router of ProviderMocks.
+const ProviderConfig = `
+provider "openwrt" {
+	user     = "root"
+	password = "test"
+	remote   = "http://test.lan:8080"
+}
+`
+
+// ProviderMocks returns the client of the router of ProviderConfig, which the
+// client factory connects to.
+func ProviderMocks(t *testing.T, ctrl *gomock.Controller, clientFactory *mocks.MockClientFactory) *mocks.MockClient {
+	client := RouterMocks(ctrl, "test")
+	timeouts := mocks.NewMockTimeouts(ctrl)
+
+	clientFactory.
+		EXPECT().
+		ParseTimeouts(gomock.Any(), gomock.Any()).
+		Return(timeouts, nil).
+		AnyTimes()
+
+	clientFactory.
+		EXPECT().
+		Get(gomock.Any(), "http://test.lan:8080", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ api.Timeouts) (api.Client, error) {
+			t.Logf("Get method called")
+			return client, nil
+		}).
+		AnyTimes()
+
+	return client
+}
+
+// RouterMocks returns the client of a router the provider logs in to as root
+// with password, running the transactions as ExpectTransactions does.
+func RouterMocks(ctrl *gomock.Controller, password string) *mocks.MockClient {
+	client := mocks.NewMockClient(ctrl)
+
+	ExpectTransactions(client)
+
+	client.
+		EXPECT().
+		Auth(gomock.Any(), "root", password).
+		Return(nil).
+		AnyTimes()
+
+	client.
+		EXPECT().
+		UpdatePackages(gomock.Any()).
+		Return(nil).
+		AnyTimes()
+
+	return client
+}
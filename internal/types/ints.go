@@ -0,0 +1,123 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Int64Value is a number stored by UCI as a string: it is marshalled to a
+// JSON string, and unmarshalled from either a JSON string or number.
+type Int64Value struct {
+	basetypes.Int64Value
+}
+
+func NewInt64Value(i int64) Int64Value {
+	return Int64Value{
+		Int64Value: basetypes.NewInt64Value(i),
+	}
+}
+
+var _ basetypes.Int64Valuable = Int64Value{}
+
+func (i *Int64Value) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		i.Int64Value = basetypes.NewInt64Null()
+	case float64:
+		i.Int64Value = basetypes.NewInt64Value(int64(v))
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", v, err)
+		}
+		i.Int64Value = basetypes.NewInt64Value(parsed)
+	default:
+		return fmt.Errorf("invalid number %s", data)
+	}
+	return nil
+}
+
+func (i Int64Value) MarshalJSON() ([]byte, error) {
+	if i.IsNull() || i.IsUnknown() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(strconv.FormatInt(i.ValueInt64(), 10))
+}
+
+func (v Int64Value) Equal(o attr.Value) bool {
+	other, ok := o.(Int64Value)
+
+	if !ok {
+		return false
+	}
+
+	return v.Int64Value.Equal(other.Int64Value)
+}
+
+func (v Int64Value) Type(ctx context.Context) attr.Type {
+	return Int64Type{}
+}
+
+type Int64Type struct {
+	basetypes.Int64Type
+}
+
+var _ basetypes.Int64Typable = Int64Type{}
+
+func (t Int64Type) Equal(o attr.Type) bool {
+	other, ok := o.(Int64Type)
+
+	if !ok {
+		return false
+	}
+
+	return t.Int64Type.Equal(other.Int64Type)
+}
+
+func (t Int64Type) String() string {
+	return "Int64Type"
+}
+
+func (t Int64Type) ValueFromInt64(ctx context.Context, in basetypes.Int64Value) (basetypes.Int64Valuable, diag.Diagnostics) {
+	value := Int64Value{
+		Int64Value: in,
+	}
+	return value, nil
+}
+
+func (t Int64Type) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.Int64Type.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	int64Value, ok := attrValue.(basetypes.Int64Value)
+
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	int64Valuable, diags := t.ValueFromInt64(ctx, int64Value)
+
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting Int64Value to Int64Valuable: %v", diags)
+	}
+
+	return int64Valuable, nil
+}
+
+func (t Int64Type) ValueType(ctx context.Context) attr.Value {
+	return Int64Value{}
+}
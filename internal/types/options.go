@@ -0,0 +1,66 @@
+package types
+
+import (
+	"context"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// UCIBool parses a UCI boolean, the way config_get_bool does. An unset
+// option is null.
+func UCIBool(s string) basetypes.BoolValue {
+	switch s {
+	case "":
+		return basetypes.NewBoolNull()
+	case "1", "on", "true", "yes", "enabled":
+		return basetypes.NewBoolValue(true)
+	default:
+		return basetypes.NewBoolValue(false)
+	}
+}
+
+// FormatUCIBool formats a boolean option as uci does.
+func FormatUCIBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// UCIString returns the value of an option, null when it is unset.
+func UCIString(s string) basetypes.StringValue {
+	if s == "" {
+		return basetypes.NewStringNull()
+	}
+	return basetypes.NewStringValue(s)
+}
+
+// SectionNameValidator rejects the names UCI does not accept for a section
+// of the given type.
+func SectionNameValidator(sectionType string) validator.String {
+	return sectionNameValidator{sectionType: sectionType}
+}
+
+type sectionNameValidator struct {
+	sectionType string
+}
+
+func (v sectionNameValidator) Description(_ context.Context) string {
+	return "value must be made of letters, digits and underscores"
+}
+
+func (v sectionNameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v sectionNameValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if err := uci.CheckSection(&uci.Section{Type: v.sectionType, Name: req.ConfigValue.ValueString()}); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid section name", err.Error())
+	}
+}
@@ -23,6 +23,18 @@ func NewStringValue(s string) StringValue {
 	}
 }
 
+func NewStringNull() StringValue {
+	return StringValue{
+		StringValue: basetypes.NewStringNull(),
+	}
+}
+
+func NewStringUnknown() StringValue {
+	return StringValue{
+		StringValue: basetypes.NewStringUnknown(),
+	}
+}
+
 func (t *StringValue) UnmarshalJSON(data []byte) error {
 	var v *string
 	if err := json.Unmarshal(data, &v); err != nil {
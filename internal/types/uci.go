@@ -0,0 +1,136 @@
+package types
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// UCIValue is a string holding the content of an UCI configuration file.
+// Two values are semantically equal when they describe the same sections and
+// options, regardless of quoting, indentation, comments and option order.
+type UCIValue struct {
+	basetypes.StringValue
+}
+
+var _ basetypes.StringValuableWithSemanticEquals = UCIValue{}
+
+func NewUCIValue(s string) UCIValue {
+	return UCIValue{
+		StringValue: basetypes.NewStringValue(s),
+	}
+}
+
+func NewUCINull() UCIValue {
+	return UCIValue{
+		StringValue: basetypes.NewStringNull(),
+	}
+}
+
+func NewUCIUnknown() UCIValue {
+	return UCIValue{
+		StringValue: basetypes.NewStringUnknown(),
+	}
+}
+
+func (v UCIValue) Equal(o attr.Value) bool {
+	other, ok := o.(UCIValue)
+
+	if !ok {
+		return false
+	}
+
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v UCIValue) StringSemanticEquals(ctx context.Context, sv basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	newValue, ok := sv.(UCIValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			"An unexpected value type was received while performing semantic equality checks. "+
+				"Please report this to the provider developers.\n\n"+
+				"Expected Value Type: "+fmt.Sprintf("%T", v)+"\n"+
+				"Got Value Type: "+fmt.Sprintf("%T", sv),
+		)
+		return false, diags
+	}
+
+	if v.ValueString() == newValue.ValueString() {
+		return true, diags
+	}
+
+	// content that is not valid UCI is compared as plain text
+	prior, err := uci.Parse(v.ValueString())
+	if err != nil {
+		return false, diags
+	}
+	current, err := uci.Parse(newValue.ValueString())
+	if err != nil {
+		return false, diags
+	}
+
+	return uci.Equal(prior, current), diags
+}
+
+func (v UCIValue) Type(ctx context.Context) attr.Type {
+	return UCIType{}
+}
+
+type UCIType struct {
+	basetypes.StringType
+}
+
+var _ basetypes.StringTypable = UCIType{}
+
+func (t UCIType) Equal(o attr.Type) bool {
+	other, ok := o.(UCIType)
+
+	if !ok {
+		return false
+	}
+
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t UCIType) String() string {
+	return "UCIType"
+}
+
+func (t UCIType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	value := UCIValue{
+		StringValue: in,
+	}
+	return value, nil
+}
+
+func (t UCIType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+
+	return stringValuable, nil
+}
+
+func (t UCIType) ValueType(ctx context.Context) attr.Value {
+	return UCIValue{}
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"context"
+	"sort"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// UCISectionModel is the structured form of an UCI section, shared by the
+// `sections` attribute of openwrt_configfile and the uci_decode function.
+type UCISectionModel struct {
+	Type    basetypes.StringValue `tfsdk:"type"`
+	Name    basetypes.StringValue `tfsdk:"name"`
+	Options basetypes.MapValue    `tfsdk:"options"`
+	Lists   basetypes.MapValue    `tfsdk:"lists"`
+}
+
+// UCISectionAttrTypes are the attribute types of UCISectionModel.
+var UCISectionAttrTypes = map[string]attr.Type{
+	"type":    basetypes.StringType{},
+	"name":    basetypes.StringType{},
+	"options": basetypes.MapType{ElemType: basetypes.StringType{}},
+	"lists":   basetypes.MapType{ElemType: basetypes.ListType{ElemType: basetypes.StringType{}}},
+}
+
+// NewUCISections converts the parsed configuration to a list of sections.
+// Anonymous sections have a null name, and sections without options or lists
+// have null maps.
+func NewUCISections(ctx context.Context, c *uci.Config) (basetypes.ListValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	sections := make([]UCISectionModel, 0, len(c.Sections))
+	for _, s := range c.Sections {
+		options := map[string]string{}
+		lists := map[string][]string{}
+		for _, o := range s.Options {
+			if o.IsList {
+				lists[o.Name] = o.Values
+			} else {
+				options[o.Name] = o.Values[0]
+			}
+		}
+
+		section := UCISectionModel{
+			Type:    basetypes.NewStringValue(s.Type),
+			Name:    basetypes.NewStringNull(),
+			Options: basetypes.NewMapNull(basetypes.StringType{}),
+			Lists:   basetypes.NewMapNull(basetypes.ListType{ElemType: basetypes.StringType{}}),
+		}
+		if s.Name != "" {
+			section.Name = basetypes.NewStringValue(s.Name)
+		}
+		if len(options) > 0 {
+			v, d := basetypes.NewMapValueFrom(ctx, basetypes.StringType{}, options)
+			diags.Append(d...)
+			section.Options = v
+		}
+		if len(lists) > 0 {
+			v, d := basetypes.NewMapValueFrom(ctx, basetypes.ListType{ElemType: basetypes.StringType{}}, lists)
+			diags.Append(d...)
+			section.Lists = v
+		}
+		sections = append(sections, section)
+	}
+
+	v, d := basetypes.NewListValueFrom(ctx, basetypes.ObjectType{AttrTypes: UCISectionAttrTypes}, sections)
+	diags.Append(d...)
+	return v, diags
+}
+
+// UCIConfigFromSections builds the configuration described by a list of
+// sections. Maps are unordered, so the options of every section are sorted by
+// name, followed by the lists sorted by name.
+func UCIConfigFromSections(ctx context.Context, l basetypes.ListValue) (*uci.Config, diag.Diagnostics) {
+	var sections []UCISectionModel
+	diags := l.ElementsAs(ctx, &sections, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	c := &uci.Config{}
+	for _, s := range sections {
+		section := &uci.Section{
+			Type: s.Type.ValueString(),
+			Name: s.Name.ValueString(),
+		}
+
+		var options map[string]string
+		diags.Append(s.Options.ElementsAs(ctx, &options, false)...)
+		for _, name := range sortedKeys(options) {
+			section.Options = append(section.Options, &uci.Option{
+				Name:   name,
+				Values: []string{options[name]},
+			})
+		}
+
+		var lists map[string][]string
+		diags.Append(s.Lists.ElementsAs(ctx, &lists, false)...)
+		for _, name := range sortedKeys(lists) {
+			section.Options = append(section.Options, &uci.Option{
+				Name:   name,
+				Values: lists[name],
+				IsList: true,
+			})
+		}
+
+		c.Sections = append(c.Sections, section)
+	}
+
+	return c, diags
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
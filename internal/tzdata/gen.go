@@ -0,0 +1,77 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build ignore
+
+// gen.go writes zones.go, the mapping between the IANA time zone names and
+// their POSIX.1 strings, out of the zoneinfo database shipped with Go. The
+// POSIX.1 string is the footer of the TZif files, from version 2 onwards.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+func main() {
+	r, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close() //nolint:errcheck
+
+	zones := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close() //nolint:errcheck
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !bytes.HasPrefix(data, []byte("TZif")) || data[4] < '2' {
+			continue
+		}
+		footer := strings.TrimSuffix(string(data), "\n")
+		footer = footer[strings.LastIndexByte(footer, '\n')+1:]
+		if footer == "" {
+			continue
+		}
+		zones[f.Name] = footer
+	}
+
+	names := make([]string, 0, len(zones))
+	for name := range zones {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen.go from the zoneinfo database of %s; DO NOT EDIT.\n\n", runtime.Version())
+	fmt.Fprintf(&b, "package tzdata\n\n")
+	fmt.Fprintf(&b, "var zones = map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "%q: %q,\n", name, zones[name])
+	}
+	fmt.Fprintf(&b, "}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("zones.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
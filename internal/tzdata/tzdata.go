@@ -0,0 +1,18 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate go run gen.go
+
+// Package tzdata maps the IANA time zone names to the POSIX.1 strings used
+// by openwrt, the way the zoneinfo table of LuCI does.
+package tzdata
+
+import "strings"
+
+// POSIX returns the POSIX.1 string of the zone, e.g. GMT0BST,M3.5.0/1,M10.5.0
+// for Europe/London. The names are accepted as written by LuCI too, with
+// spaces instead of underscores, e.g. America/New York.
+func POSIX(zone string) (string, bool) {
+	tz, ok := zones[strings.ReplaceAll(zone, " ", "_")]
+	return tz, ok
+}
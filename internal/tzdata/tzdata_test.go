@@ -0,0 +1,31 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package tzdata_test
+
+import (
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/tzdata"
+)
+
+func TestPOSIX(t *testing.T) {
+	for zone, expected := range map[string]string{
+		"Europe/London":    "GMT0BST,M3.5.0/1,M10.5.0",
+		"America/New_York": "EST5EDT,M3.2.0,M11.1.0",
+		"America/New York": "EST5EDT,M3.2.0,M11.1.0",
+		"Asia/Kolkata":     "IST-5:30",
+		"UTC":              "UTC0",
+	} {
+		tz, ok := tzdata.POSIX(zone)
+		if !ok || tz != expected {
+			t.Errorf("unexpected POSIX string for %s: got %q, want %q", zone, tz, expected)
+		}
+	}
+
+	for _, zone := range []string{"", "Europe", "Europe/Londres", "GMT0BST,M3.5.0/1,M10.5.0"} {
+		if tz, ok := tzdata.POSIX(zone); ok {
+			t.Errorf("unexpected POSIX string for %q: %q", zone, tz)
+		}
+	}
+}
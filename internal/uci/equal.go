@@ -0,0 +1,28 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uci
+
+import "slices"
+
+// Equal reports whether two configurations are semantically the same. The
+// order of the sections and of list values is significant, as the daemons
+// rely on it (e.g. firewall rules), while the order of the options inside a
+// section, quoting, indentation and comments are not.
+func Equal(a, b *Config) bool {
+	return slices.EqualFunc(a.Sections, b.Sections, equalSection)
+}
+
+func equalSection(a, b *Section) bool {
+	if a.Type != b.Type || a.Name != b.Name || len(a.Options) != len(b.Options) {
+		return false
+	}
+
+	for _, option := range a.Options {
+		other := b.Option(option.Name)
+		if other == nil || other.IsList != option.IsList || !slices.Equal(other.Values, option.Values) {
+			return false
+		}
+	}
+	return true
+}
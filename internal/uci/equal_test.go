@@ -0,0 +1,61 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uci_test
+
+import (
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+)
+
+func TestEqual(t *testing.T) {
+	base := "config interface 'lan'\n\toption proto 'static'\n\toption ipaddr '192.168.1.1'\n\tlist dns '1.1.1.1'\n\tlist dns '8.8.8.8'\n\nconfig rule\n\toption name 'a'\n\nconfig rule\n\toption name 'b'\n"
+
+	for _, tc := range []struct {
+		name  string
+		other string
+		equal bool
+	}{
+		{
+			name:  "quoting, indentation and comments",
+			other: "# comment\nconfig interface lan\n  option proto \"static\"\n  option ipaddr 192.168.1.1 # inline\n  list dns 1.1.1.1\n  list dns 8.8.8.8\nconfig rule\n  option name a\nconfig rule\n  option name b\n",
+			equal: true,
+		},
+		{
+			name:  "options order",
+			other: "config interface 'lan'\n\tlist dns '1.1.1.1'\n\toption ipaddr '192.168.1.1'\n\tlist dns '8.8.8.8'\n\toption proto 'static'\nconfig rule\n\toption name 'a'\nconfig rule\n\toption name 'b'\n",
+			equal: true,
+		},
+		{
+			name:  "list values order",
+			other: "config interface 'lan'\n\toption proto 'static'\n\toption ipaddr '192.168.1.1'\n\tlist dns '8.8.8.8'\n\tlist dns '1.1.1.1'\nconfig rule\n\toption name 'a'\nconfig rule\n\toption name 'b'\n",
+			equal: false,
+		},
+		{
+			name:  "sections order",
+			other: "config interface 'lan'\n\toption proto 'static'\n\toption ipaddr '192.168.1.1'\n\tlist dns '1.1.1.1'\n\tlist dns '8.8.8.8'\nconfig rule\n\toption name 'b'\nconfig rule\n\toption name 'a'\n",
+			equal: false,
+		},
+		{
+			name:  "option value",
+			other: "config interface 'lan'\n\toption proto 'dhcp'\n\toption ipaddr '192.168.1.1'\n\tlist dns '1.1.1.1'\n\tlist dns '8.8.8.8'\nconfig rule\n\toption name 'a'\nconfig rule\n\toption name 'b'\n",
+			equal: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := uci.Parse(base)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			b, err := uci.Parse(tc.other)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := uci.Equal(a, b); got != tc.equal {
+				t.Errorf("expected equality to be %t, got %t", tc.equal, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Format renders the configuration in the canonical text written by
+// `uci export`: single quoted values, a tab before every option and an empty
+// line between sections. Parsing the result gives back an equal Config.
+func Format(c *Config) string {
+	var b strings.Builder
+
+	if c.Package != "" {
+		b.WriteString("package " + quote(c.Package) + "\n\n")
+	}
+
+	for i, section := range c.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("config " + word(section.Type, validType))
+		if section.Name != "" {
+			b.WriteString(" " + quote(section.Name))
+		}
+		b.WriteString("\n")
+
+		for _, option := range section.Options {
+			keyword := "option"
+			if option.IsList {
+				keyword = "list"
+			}
+			for _, value := range option.Values {
+				b.WriteString("\t" + keyword + " " + word(option.Name, validName) + " " + quote(value) + "\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// word writes names as they are, unless they would not be read back as a
+// single argument.
+func word(s string, valid func(string) bool) string {
+	if valid(s) {
+		return s
+	}
+	return quote(s)
+}
+
+// quote single quotes the value. Single quotes can't be escaped inside of a
+// single quoted string, so they are closed, escaped and opened again.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CheckSection reports what would prevent the section from being formatted
+// and parsed back: invalid names, empty lists and names used both by an
+// option and a list.
+func CheckSection(s *Section) error {
+	var errs []error
+
+	if !validType(s.Type) {
+		errs = append(errs, fmt.Errorf("invalid section type %q", s.Type))
+	}
+	if s.Name != "" && !validName(s.Name) {
+		errs = append(errs, fmt.Errorf("invalid section name %q", s.Name))
+	}
+
+	seen := map[string]bool{}
+	for _, option := range s.Options {
+		switch {
+		case !validName(option.Name):
+			errs = append(errs, fmt.Errorf("invalid option name %q", option.Name))
+		case seen[option.Name]:
+			errs = append(errs, fmt.Errorf("option %q is defined more than once", option.Name))
+		case len(option.Values) == 0:
+			errs = append(errs, fmt.Errorf("list %q has no values", option.Name))
+		}
+		seen[option.Name] = true
+	}
+
+	return errors.Join(errs...)
+}
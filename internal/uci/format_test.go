@@ -0,0 +1,75 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uci_test
+
+import (
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+)
+
+func TestFormat(t *testing.T) {
+	c := &uci.Config{
+		Sections: []*uci.Section{
+			{
+				Type: "interface",
+				Name: "lan",
+				Options: []*uci.Option{
+					{Name: "proto", Values: []string{"static"}},
+					{Name: "description", Values: []string{"it's \"home\"\n# not a comment"}},
+					{Name: "dns", Values: []string{"1.1.1.1", "8.8.8.8"}, IsList: true},
+				},
+			},
+			{
+				Type: "rule",
+				Options: []*uci.Option{
+					{Name: "name", Values: []string{""}},
+				},
+			},
+		},
+	}
+
+	text := uci.Format(c)
+
+	expected := "config interface 'lan'\n" +
+		"\toption proto 'static'\n" +
+		"\toption description 'it'\\''s \"home\"\n# not a comment'\n" +
+		"\tlist dns '1.1.1.1'\n" +
+		"\tlist dns '8.8.8.8'\n" +
+		"\n" +
+		"config rule\n" +
+		"\toption name ''\n"
+	if text != expected {
+		t.Errorf("unexpected text:\n got: %q\nwant: %q", text, expected)
+	}
+
+	parsed, err := uci.Parse(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !uci.Equal(parsed, c) {
+		t.Errorf("formatted text is not parsed back to the same configuration:\n%s", text)
+	}
+}
+
+func TestCheckSection(t *testing.T) {
+	err := uci.CheckSection(&uci.Section{
+		Type: "rule",
+		Name: "my-rule",
+		Options: []*uci.Option{
+			{Name: "proto", IsList: true},
+			{Name: "dest.port", Values: []string{"22"}},
+			{Name: "name", Values: []string{"a"}},
+			{Name: "name", Values: []string{"b"}, IsList: true},
+		},
+	})
+
+	expected := "invalid section name \"my-rule\"\n" +
+		"list \"proto\" has no values\n" +
+		"invalid option name \"dest.port\"\n" +
+		"option \"name\" is defined more than once"
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error:\n got: %v\nwant: %s", err, expected)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+// Package uci parses the text format of the files in /etc/config, following
+// the grammar accepted by libuci.
+package uci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Config is a parsed UCI configuration file.
+type Config struct {
+	Package  string
+	Sections []*Section
+}
+
+// Section is a `config` block. Name is empty for anonymous sections.
+type Section struct {
+	Type    string
+	Name    string
+	Options []*Option
+
+	Line int
+}
+
+// Option is either an `option` or a `list` of the section, with all of its
+// values in order of appearance.
+type Option struct {
+	Name   string
+	Values []string
+	IsList bool
+
+	Line int
+}
+
+// Option returns the option with the given name, or nil.
+func (s *Section) Option(name string) *Option {
+	for _, o := range s.Options {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// SyntaxError locates an error in the parsed text. Line and Column are 1-based.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// SyntaxErrors collects all the errors found while parsing.
+type SyntaxErrors []*SyntaxError
+
+func (e SyntaxErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+type token struct {
+	text   string
+	line   int
+	column int
+}
+
+// Parse parses the UCI text. Parsing goes on after an error, so that all the
+// problems of the text are reported at once as SyntaxErrors.
+func Parse(text string) (*Config, error) {
+	p := &parser{
+		text:   text,
+		line:   1,
+		column: 1,
+		config: &Config{},
+	}
+	p.parse()
+
+	if len(p.errs) > 0 {
+		return p.config, p.errs
+	}
+	return p.config, nil
+}
+
+type parser struct {
+	text   string
+	pos    int
+	line   int
+	column int
+
+	config  *Config
+	section *Section
+	errs    SyntaxErrors
+}
+
+func (p *parser) errorf(line, column int, format string, args ...any) {
+	p.errs = append(p.errs, &SyntaxError{
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (p *parser) parse() {
+	for p.pos < len(p.text) {
+		tokens, ok := p.statement()
+		if ok && len(tokens) > 0 {
+			p.command(tokens)
+		}
+	}
+}
+
+func (p *parser) next() byte {
+	c := p.text[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return c
+}
+
+// statement tokenizes the text up to the end of the current line. Quoted
+// strings may span multiple lines. It reports false when the statement is
+// malformed and has to be skipped.
+func (p *parser) statement() ([]token, bool) {
+	var (
+		tokens []token
+		ok     = true
+	)
+
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		switch {
+		case c == '\n':
+			p.next()
+			return tokens, ok
+		case c == ' ' || c == '\t' || c == '\r':
+			p.next()
+		case c == '#':
+			for p.pos < len(p.text) && p.text[p.pos] != '\n' {
+				p.next()
+			}
+		default:
+			t, tokenOk := p.word()
+			ok = ok && tokenOk
+			tokens = append(tokens, t)
+		}
+	}
+
+	return tokens, ok
+}
+
+// word reads a single argument, made of any concatenation of unquoted,
+// single quoted and double quoted parts.
+func (p *parser) word() (token, bool) {
+	t := token{line: p.line, column: p.column}
+	var b strings.Builder
+
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		switch c {
+		case ' ', '\t', '\r', '\n', '#':
+			t.text = b.String()
+			return t, true
+		case '\'':
+			line, column := p.line, p.column
+			p.next()
+			closed := false
+			for p.pos < len(p.text) {
+				c = p.next()
+				if c == '\'' {
+					closed = true
+					break
+				}
+				b.WriteByte(c)
+			}
+			if !closed {
+				p.errorf(line, column, "unterminated single quote")
+				return t, false
+			}
+		case '"':
+			line, column := p.line, p.column
+			p.next()
+			closed := false
+			for p.pos < len(p.text) {
+				c = p.next()
+				if c == '"' {
+					closed = true
+					break
+				}
+				if c == '\\' && p.pos < len(p.text) {
+					c = p.next()
+				}
+				b.WriteByte(c)
+			}
+			if !closed {
+				p.errorf(line, column, "unterminated double quote")
+				return t, false
+			}
+		case '\\':
+			p.next()
+			if p.pos < len(p.text) {
+				b.WriteByte(p.next())
+			}
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+
+	t.text = b.String()
+	return t, true
+}
+
+func (p *parser) command(tokens []token) {
+	keyword := tokens[0]
+	args := tokens[1:]
+
+	switch keyword.text {
+	case "package":
+		if !p.arguments(keyword, args, 1, 1) {
+			return
+		}
+		if len(p.config.Sections) > 0 {
+			p.errorf(keyword.line, keyword.column, "package must be declared before any section")
+			return
+		}
+		p.config.Package = args[0].text
+	case "config":
+		if !p.arguments(keyword, args, 1, 2) {
+			p.section = nil
+			return
+		}
+		section := &Section{
+			Type: args[0].text,
+			Line: keyword.line,
+		}
+		if !validType(section.Type) {
+			p.errorf(args[0].line, args[0].column, "invalid section type %q", section.Type)
+		}
+		if len(args) == 2 {
+			section.Name = args[1].text
+			if !validName(section.Name) {
+				p.errorf(args[1].line, args[1].column, "invalid section name %q", section.Name)
+			}
+		}
+		p.config.Sections = append(p.config.Sections, section)
+		p.section = section
+	case "option", "list":
+		if p.section == nil {
+			p.errorf(keyword.line, keyword.column, "%s %q outside of a section", keyword.text, optionName(args))
+			return
+		}
+		if !p.arguments(keyword, args, 2, 2) {
+			return
+		}
+		name := args[0].text
+		if !validName(name) {
+			p.errorf(args[0].line, args[0].column, "invalid option name %q", name)
+			return
+		}
+		p.addValue(keyword, name, args[1].text)
+	default:
+		p.errorf(keyword.line, keyword.column, "unknown keyword %q", keyword.text)
+	}
+}
+
+func (p *parser) addValue(keyword token, name, value string) {
+	isList := keyword.text == "list"
+	option := p.section.Option(name)
+
+	switch {
+	case option == nil:
+		p.section.Options = append(p.section.Options, &Option{
+			Name:   name,
+			Values: []string{value},
+			IsList: isList,
+			Line:   keyword.line,
+		})
+	case isList:
+		// as in libuci, a list extends an option with the same name
+		option.Values = append(option.Values, value)
+		option.IsList = true
+	default:
+		// as in libuci, a repeated option overrides the previous value
+		option.Values = []string{value}
+		option.IsList = false
+	}
+}
+
+func (p *parser) arguments(keyword token, args []token, minArgs, maxArgs int) bool {
+	switch {
+	case len(args) < minArgs:
+		p.errorf(keyword.line, keyword.column, "%s expects at least %d argument(s), got %d", keyword.text, minArgs, len(args))
+		return false
+	case len(args) > maxArgs:
+		extra := args[maxArgs]
+		p.errorf(extra.line, extra.column, "unexpected argument %q for %s", extra.text, keyword.text)
+		return false
+	}
+	return true
+}
+
+func optionName(args []token) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0].text
+}
+
+// validName mirrors uci_validate_name: names are made of alphanumeric
+// characters and underscores.
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !isAlnum(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// validType mirrors uci_validate_type, which also allows dashes.
+func validType(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !isAlnum(c) && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,175 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uci_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/foxboron/terraform-provider-openwrt/internal/uci"
+)
+
+func TestParse(t *testing.T) {
+	text := `# managed by terraform
+package network
+
+config interface 'loopback'
+	option device 'lo'
+	option proto "static"
+	option ipaddr 127.0.0.1
+
+config device
+	option name 'br-lan'
+	list ports 'lan1'
+	list ports "lan2"  # trailing comment
+	option description 'multi
+line'
+	option escaped "say \"hi\""
+	option concat 'a'"b"c
+`
+
+	c, err := uci.Parse(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &uci.Config{
+		Package: "network",
+		Sections: []*uci.Section{
+			{
+				Type: "interface",
+				Name: "loopback",
+				Line: 4,
+				Options: []*uci.Option{
+					{Name: "device", Values: []string{"lo"}, Line: 5},
+					{Name: "proto", Values: []string{"static"}, Line: 6},
+					{Name: "ipaddr", Values: []string{"127.0.0.1"}, Line: 7},
+				},
+			},
+			{
+				Type: "device",
+				Line: 9,
+				Options: []*uci.Option{
+					{Name: "name", Values: []string{"br-lan"}, Line: 10},
+					{Name: "ports", Values: []string{"lan1", "lan2"}, IsList: true, Line: 11},
+					{Name: "description", Values: []string{"multi\nline"}, Line: 13},
+					{Name: "escaped", Values: []string{`say "hi"`}, Line: 15},
+					{Name: "concat", Values: []string{"abc"}, Line: 16},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("unexpected result:\n got: %+v\nwant: %+v", c, expected)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		text     string
+		expected []uci.SyntaxError
+	}{
+		{
+			name: "unbalanced single quote",
+			text: "config system\n\toption hostname 'router\n",
+			expected: []uci.SyntaxError{
+				{Line: 2, Column: 18, Msg: "unterminated single quote"},
+			},
+		},
+		{
+			name: "unbalanced double quote",
+			text: "config system\n\toption hostname \"router\n",
+			expected: []uci.SyntaxError{
+				{Line: 2, Column: 18, Msg: "unterminated double quote"},
+			},
+		},
+		{
+			name: "unknown keyword",
+			text: "config system\n\toptoin hostname 'router'\n",
+			expected: []uci.SyntaxError{
+				{Line: 2, Column: 2, Msg: `unknown keyword "optoin"`},
+			},
+		},
+		{
+			name: "option outside of a section",
+			text: "option hostname 'router'\nconfig system\n",
+			expected: []uci.SyntaxError{
+				{Line: 1, Column: 1, Msg: `option "hostname" outside of a section`},
+			},
+		},
+		{
+			name: "missing value",
+			text: "config system\n\toption hostname\n",
+			expected: []uci.SyntaxError{
+				{Line: 2, Column: 2, Msg: "option expects at least 2 argument(s), got 1"},
+			},
+		},
+		{
+			name: "too many arguments",
+			text: "config system\n\toption hostname router lan\n",
+			expected: []uci.SyntaxError{
+				{Line: 2, Column: 25, Msg: `unexpected argument "lan" for option`},
+			},
+		},
+		{
+			name: "invalid names",
+			text: "config sys.tem 'my-name'\n\toption host.name 'router'\n",
+			expected: []uci.SyntaxError{
+				{Line: 1, Column: 8, Msg: `invalid section type "sys.tem"`},
+				{Line: 1, Column: 16, Msg: `invalid section name "my-name"`},
+				{Line: 2, Column: 9, Msg: `invalid option name "host.name"`},
+			},
+		},
+		{
+			name: "all errors are reported",
+			text: "list a 'b'\nconfig system\n\tfoo\n\toption x 'y\n",
+			expected: []uci.SyntaxError{
+				{Line: 1, Column: 1, Msg: `list "a" outside of a section`},
+				{Line: 3, Column: 2, Msg: `unknown keyword "foo"`},
+				{Line: 4, Column: 11, Msg: "unterminated single quote"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := uci.Parse(tc.text)
+
+			var syntaxErrs uci.SyntaxErrors
+			if !errors.As(err, &syntaxErrs) {
+				t.Fatalf("expected syntax errors, got %v", err)
+			}
+
+			got := make([]uci.SyntaxError, 0, len(syntaxErrs))
+			for _, e := range syntaxErrs {
+				got = append(got, *e)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected errors:\n got: %+v\nwant: %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateSectionTypes(t *testing.T) {
+	c, err := uci.Parse("config system\nconfig timeserver 'ntp'\nconfig interface 'lan'\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = uci.ValidateSectionTypes("system", c)
+
+	var syntaxErrs uci.SyntaxErrors
+	if !errors.As(err, &syntaxErrs) {
+		t.Fatalf("expected syntax errors, got %v", err)
+	}
+	if len(syntaxErrs) != 1 || syntaxErrs[0].Line != 3 {
+		t.Errorf("expected a single error on line 3, got %v", syntaxErrs)
+	}
+
+	if err := uci.ValidateSectionTypes("custom", c); err != nil {
+		t.Errorf("unknown configurations must not be checked, got %v", err)
+	}
+}
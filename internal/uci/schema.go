@@ -0,0 +1,70 @@
+// Copyright (c) https://github.com/Foxboron/terraform-provider-openwrt/graphs/contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package uci
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sectionTypes lists the section types understood by the daemons reading
+// the most common configuration files.
+var sectionTypes = map[string][]string{
+	"dhcp": {
+		"boot", "circuitid", "cname", "dhcp", "dnsmasq", "domain", "host",
+		"hostrecord", "ipset", "mac", "match", "mxhost", "odhcpd", "relay",
+		"remoteid", "srvhost", "subscrid", "tag", "userclass", "vendorclass",
+	},
+	"dropbear": {"dropbear"},
+	"firewall": {
+		"defaults", "forwarding", "include", "ipset", "nat", "redirect",
+		"rule", "zone",
+	},
+	"network": {
+		"alias", "bridge-vlan", "device", "globals", "interface", "route",
+		"route6", "rule", "rule6", "switch", "switch_port", "switch_vlan",
+	},
+	"rpcd":     {"login", "rpcd"},
+	"system":   {"button", "led", "rngd", "system", "timeserver"},
+	"uhttpd":   {"cert", "uhttpd"},
+	"wireless": {"wifi-device", "wifi-iface", "wifi-station", "wifi-vlan"},
+}
+
+// KnownConfig reports whether section types are known for the configuration.
+func KnownConfig(config string) bool {
+	_, ok := sectionTypes[config]
+	return ok
+}
+
+// ValidateSectionTypes checks the section types against the ones known for
+// the named configuration. Unknown configurations are not checked.
+func ValidateSectionTypes(config string, c *Config) error {
+	var errs SyntaxErrors
+	for _, s := range c.Sections {
+		if err := ValidateSectionType(config, s); err != nil {
+			errs = append(errs, &SyntaxError{
+				Line:   s.Line,
+				Column: 1,
+				Msg:    err.Error(),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateSectionType checks the type of a single section. Unknown
+// configurations are not checked.
+func ValidateSectionType(config string, s *Section) error {
+	types, ok := sectionTypes[config]
+	if !ok || slices.Contains(types, s.Type) {
+		return nil
+	}
+	return fmt.Errorf("unknown section type %q for %s, expected one of: %s",
+		s.Type, config, strings.Join(types, ", "))
+}